@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	db "instashop/database"
+	"instashop/internal/repositories"
+	"instashop/internal/utils"
+	"instashop/models"
+)
+
+const minPasswordLength = 8
+
+func runCreateAdmin(args []string) error {
+	fs := newFlagSet("create-admin", "-email <email> [flags]")
+	email := fs.String("email", "", "the admin's email address")
+	password := fs.String("password", "", "the admin's password; read from stdin when empty")
+	firstName := fs.String("first-name", "Admin", "the admin's first name")
+	lastName := fs.String("last-name", "", "the admin's last name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		fs.Usage()
+		return fmt.Errorf("-email is required")
+	}
+	hashedPassword, err := passwordHash(*password)
+	if err != nil {
+		return err
+	}
+
+	if err := connectDB(); err != nil {
+		return err
+	}
+	userRepo := repositories.NewUserRepository(db.Client)
+	_, exist, err := userRepo.FetchOne(models.User{Email: *email})
+	if err != nil {
+		return err
+	}
+	if exist {
+		return fmt.Errorf("a user with email %s already exists", *email)
+	}
+
+	isAdmin, isVerified := true, true
+	admin := models.User{
+		Email:      *email,
+		FirstName:  *firstName,
+		LastName:   *lastName,
+		Password:   hashedPassword,
+		IsAdmin:    &isAdmin,
+		IsVerified: &isVerified,
+	}
+	if err := userRepo.Create(&admin); err != nil {
+		return err
+	}
+	fmt.Printf("Created admin %s (id %d)\n", admin.Email, admin.ID)
+	return nil
+}
+
+func runResetPassword(args []string) error {
+	fs := newFlagSet("reset-password", "-email <email> [flags]")
+	email := fs.String("email", "", "the user's email address")
+	password := fs.String("password", "", "the new password; read from stdin when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		fs.Usage()
+		return fmt.Errorf("-email is required")
+	}
+	hashedPassword, err := passwordHash(*password)
+	if err != nil {
+		return err
+	}
+
+	if err := connectDB(); err != nil {
+		return err
+	}
+	userRepo := repositories.NewUserRepository(db.Client)
+	user, exist, err := userRepo.FetchOne(models.User{Email: *email})
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("no user with email %s", *email)
+	}
+	if err := userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return err
+	}
+	fmt.Printf("Reset the password of %s\n", user.Email)
+	return nil
+}
+
+// passwordHash checks and hashes password, reading it from stdin if it's
+// empty so it needn't appear in shell history.
+func passwordHash(password string) (string, error) {
+	if password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("reading password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	return utils.HashPassword(password)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+	db "instashop/database"
+	"instashop/internal/cache"
+	"instashop/internal/common"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/internal/utils"
+)
+
+func runExportCatalog(args []string) error {
+	fs := newFlagSet("export-catalog", "[-o file]")
+	output := fs.String("o", "", "file to write; stdout when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := connectDB(); err != nil {
+		return err
+	}
+	catalog, err := db.ExportCatalog(db.Client)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(catalog); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d product(s) to %s\n", len(catalog.Products), *output)
+	}
+	return nil
+}
+
+func runImportCatalog(args []string) error {
+	fs := newFlagSet("import-catalog", "<file>")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("import-catalog needs a file")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	catalog, err := db.ParseFixtures(data)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", fs.Arg(0), err)
+	}
+
+	if err := connectDB(); err != nil {
+		return err
+	}
+	result, srvErr := newProductService().ImportCatalog(context.Background(), catalog.ProductImports())
+	if srvErr != nil {
+		return errors.New(srvErr.Message)
+	}
+
+	fmt.Printf("Created %d and updated %d product(s)\n", result.Created, result.Updated)
+	return nil
+}
+
+// newProductService wires the product service the way the API does, so
+// imports are validated and record their events in the outbox.
+func newProductService() services.ProductClient {
+	currencyRepo := repositories.NewCurrencyRepository(db.Client)
+	return services.NewProductService(
+		repositories.NewTransactor(db.Client),
+		repositories.NewProductRepository(db.Client),
+		repositories.NewVariantRepository(db.Client),
+		currencyRepo,
+		repositories.NewOutboxRepository(db.Client),
+		services.NewPricer(currencyRepo),
+		services.NewProductCache(cache.Default(), utils.GetConfig().CacheTTL),
+		common.NewRestErr(),
+	)
+}
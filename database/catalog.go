@@ -0,0 +1,108 @@
+package db
+
+import (
+	"sort"
+
+	"gorm.io/gorm"
+	"instashop/internal/dtos"
+	"instashop/models"
+)
+
+// ExportCatalog returns every product that isn't deleted, with its variants
+// and per-currency prices, in the fixture format that import-catalog reads.
+func ExportCatalog(db *gorm.DB) (*Fixtures, error) {
+	var products []models.Product
+	err := db.Preload("Variants.OptionValues.OptionType").Order("id").Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	var prices []models.ProductPrice
+	if err := db.Joins("JOIN products ON products.id = product_prices.product_id AND products.deleted_at IS NULL").
+		Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	pricesByProduct := make(map[uint]map[string]int64)
+	for _, price := range prices {
+		if pricesByProduct[price.ProductID] == nil {
+			pricesByProduct[price.ProductID] = map[string]int64{}
+		}
+		pricesByProduct[price.ProductID][price.Currency] = price.Amount
+	}
+
+	catalog := &Fixtures{Products: make([]productFixture, 0, len(products))}
+	for _, product := range products {
+		fixture := productFixture{
+			Name:        product.Name,
+			Description: product.Description,
+			Price:       product.Price.Amount,
+			Currency:    product.Price.Currency,
+			Stock:       product.Stock,
+			Category:    product.Category,
+			ImageURL:    product.ImageURL,
+			Status:      string(product.Status),
+			TaxClass:    product.TaxClass,
+			WeightGrams: product.WeightGrams,
+			LengthMM:    product.LengthMM,
+			WidthMM:     product.WidthMM,
+			HeightMM:    product.HeightMM,
+			Prices:      pricesByProduct[product.ID],
+		}
+		sort.Slice(product.Variants, func(i, j int) bool {
+			return product.Variants[i].SKU < product.Variants[j].SKU
+		})
+		for _, variant := range product.Variants {
+			variantFixture := variantFixture{SKU: variant.SKU, Price: variant.PriceAmount, Stock: variant.Stock}
+			for _, value := range variant.OptionValues {
+				if variantFixture.Options == nil {
+					variantFixture.Options = map[string]string{}
+				}
+				if value.OptionType != nil {
+					variantFixture.Options[value.OptionType.Name] = value.Value
+				}
+			}
+			fixture.Variants = append(fixture.Variants, variantFixture)
+		}
+		catalog.Products = append(catalog.Products, fixture)
+	}
+	return catalog, nil
+}
+
+// ProductImports turns the catalog's products into the requests that
+// ProductService.ImportCatalog takes. Products without a currency are left
+// to default to the store's.
+func (f *Fixtures) ProductImports() []dtos.ImportProductRequest {
+	inputs := make([]dtos.ImportProductRequest, 0, len(f.Products))
+	for _, product := range f.Products {
+		input := dtos.ImportProductRequest{
+			Name:        product.Name,
+			Description: product.Description,
+			Price:       product.Price,
+			Currency:    product.Currency,
+			Stock:       product.Stock,
+			ImageURL:    product.ImageURL,
+			Category:    product.Category,
+			Status:      product.Status,
+			TaxClass:    product.TaxClass,
+			WeightGrams: product.WeightGrams,
+			LengthMM:    product.LengthMM,
+			WidthMM:     product.WidthMM,
+			HeightMM:    product.HeightMM,
+		}
+		for currency, amount := range product.Prices {
+			input.Prices = append(input.Prices, dtos.ProductPriceRequest{Currency: currency, Amount: amount})
+		}
+		sort.Slice(input.Prices, func(i, j int) bool {
+			return input.Prices[i].Currency < input.Prices[j].Currency
+		})
+		for _, variant := range product.Variants {
+			input.Variants = append(input.Variants, dtos.CreateVariantRequest{
+				SKU:     variant.SKU,
+				Price:   variant.Price,
+				Stock:   variant.Stock,
+				Options: variant.Options,
+			})
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
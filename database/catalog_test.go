@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"instashop/internal/cache"
+	"instashop/internal/common"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/models"
+)
+
+func importCatalog(t *testing.T, db *gorm.DB, catalog *Fixtures) {
+	t.Helper()
+	currencyRepo := repositories.NewCurrencyRepository(db)
+	productService := services.NewProductService(
+		repositories.NewTransactor(db),
+		repositories.NewProductRepository(db),
+		repositories.NewVariantRepository(db),
+		currencyRepo,
+		repositories.NewOutboxRepository(db),
+		services.NewPricer(currencyRepo),
+		services.NewProductCache(cache.NewMemoryCache(100), time.Minute),
+		common.NewRestErr(),
+	)
+	if _, srvErr := productService.ImportCatalog(context.Background(), catalog.ProductImports()); srvErr != nil {
+		t.Fatalf("importing: %s", srvErr.Message)
+	}
+}
+
+func TestCatalogRoundTrip(t *testing.T) {
+	source, target := testDB(t), testDB(t)
+	for _, db := range []*gorm.DB{source, target} {
+		if err := Migrate(db, "USD"); err != nil {
+			t.Fatalf("migrating: %v", err)
+		}
+	}
+
+	variantPrice := int64(2200)
+	catalog := &Fixtures{Products: []productFixture{{
+		Name:        "Mug",
+		Description: "Stoneware mug",
+		Price:       2000,
+		Currency:    "USD",
+		Stock:       5,
+		Category:    "kitchen",
+		Status:      string(models.ProductStatusActive),
+		TaxClass:    models.DefaultTaxClass,
+		WeightGrams: 350,
+		LengthMM:    120,
+		WidthMM:     90,
+		HeightMM:    100,
+		Prices:      map[string]int64{"EUR": 1900, "JPY": 3000},
+		Variants: []variantFixture{
+			{SKU: "MUG-BLUE", Stock: 2, Options: map[string]string{"color": "blue"}},
+			{SKU: "MUG-RED", Price: &variantPrice, Stock: 3, Options: map[string]string{"color": "red"}},
+		},
+	}}}
+	importCatalog(t, source, catalog)
+
+	exported, err := ExportCatalog(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(exported, catalog) {
+		t.Fatalf("exported %+v, want %+v", exported.Products, catalog.Products)
+	}
+
+	importCatalog(t, target, exported)
+	reexported, err := ExportCatalog(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reexported, exported) {
+		t.Fatalf("re-exported %+v, want %+v", reexported.Products, exported.Products)
+	}
+}
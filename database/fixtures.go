@@ -0,0 +1,157 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed fixtures
+var fixtureFiles embed.FS
+
+// Fixtures is a named set of seed data, read from fixtures/<name>.yaml,
+// .yml or .json. Prices are in minor units of the store currency unless a
+// product names its own. Catalog exports use the same format.
+type Fixtures struct {
+	ShippingZones []zoneFixture    `yaml:"shipping_zones,omitempty"`
+	TaxRules      []taxRuleFixture `yaml:"tax_rules,omitempty"`
+	Products      []productFixture `yaml:"products,omitempty"`
+	Users         []userFixture    `yaml:"users,omitempty"`
+	Orders        []orderFixture   `yaml:"orders,omitempty"`
+}
+
+type zoneFixture struct {
+	Name      string   `yaml:"name"`
+	Countries []string `yaml:"countries"`
+	Methods   []struct {
+		Name     string `yaml:"name"`
+		Type     string `yaml:"type"`
+		Rate     int64  `yaml:"rate"`
+		PerKg    int64  `yaml:"per_kg"`
+		FreeOver int64  `yaml:"free_over"`
+	} `yaml:"methods"`
+}
+
+type taxRuleFixture struct {
+	Name     string `yaml:"name"`
+	Country  string `yaml:"country"`
+	Region   string `yaml:"region"`
+	TaxClass string `yaml:"tax_class"`
+	Rate     int    `yaml:"rate"`
+}
+
+// productFixture's Prices are per-currency overrides, keyed by currency.
+type productFixture struct {
+	Name        string           `yaml:"name"`
+	Description string           `yaml:"description"`
+	Price       int64            `yaml:"price"`
+	Currency    string           `yaml:"currency,omitempty"`
+	Stock       int              `yaml:"stock"`
+	Category    string           `yaml:"category,omitempty"`
+	ImageURL    string           `yaml:"image_url,omitempty"`
+	Status      string           `yaml:"status,omitempty"`
+	TaxClass    string           `yaml:"tax_class,omitempty"`
+	WeightGrams int              `yaml:"weight_grams,omitempty"`
+	LengthMM    int              `yaml:"length_mm,omitempty"`
+	WidthMM     int              `yaml:"width_mm,omitempty"`
+	HeightMM    int              `yaml:"height_mm,omitempty"`
+	Prices      map[string]int64 `yaml:"prices,omitempty"`
+	Variants    []variantFixture `yaml:"variants,omitempty"`
+}
+
+type variantFixture struct {
+	SKU     string            `yaml:"sku"`
+	Price   *int64            `yaml:"price,omitempty"`
+	Stock   int               `yaml:"stock"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+type userFixture struct {
+	Email       string `yaml:"email"`
+	FirstName   string `yaml:"first_name"`
+	LastName    string `yaml:"last_name"`
+	Password    string `yaml:"password"`
+	PhoneNumber string `yaml:"phone_number"`
+	IsVerified  bool   `yaml:"is_verified"`
+}
+
+// orderFixture is an order placed by User, the email of a fixture user.
+// Items name products by Product, and variants by SKU.
+type orderFixture struct {
+	User          string `yaml:"user"`
+	Status        string `yaml:"status"`
+	PlacedDaysAgo int    `yaml:"placed_days_ago"`
+	Items         []struct {
+		Product  string `yaml:"product"`
+		SKU      string `yaml:"sku"`
+		Quantity int    `yaml:"quantity"`
+	} `yaml:"items"`
+	ShippingAddress struct {
+		Line1      string `yaml:"line1"`
+		City       string `yaml:"city"`
+		Region     string `yaml:"region"`
+		PostalCode string `yaml:"postal_code"`
+		Country    string `yaml:"country"`
+	} `yaml:"shipping_address"`
+}
+
+// FixtureSets lists the names of the embedded fixture sets.
+func FixtureSets() []string {
+	entries, _ := fs.ReadDir(fixtureFiles, "fixtures")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if name, ok := fixtureSetName(entry.Name()); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFixtures reads fixtures from YAML or JSON, which the YAML parser
+// accepts as it is.
+func ParseFixtures(data []byte) (*Fixtures, error) {
+	var fixtures Fixtures
+	if err := yaml.Unmarshal(data, &fixtures); err != nil {
+		return nil, err
+	}
+	return &fixtures, nil
+}
+
+// LoadFixtures reads the embedded fixture set called name.
+func LoadFixtures(name string) (*Fixtures, error) {
+	entries, err := fs.ReadDir(fixtureFiles, "fixtures")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if setName, ok := fixtureSetName(entry.Name()); !ok || setName != name {
+			continue
+		}
+		data, err := fs.ReadFile(fixtureFiles, path.Join("fixtures", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		fixtures, err := ParseFixtures(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing fixtures %s: %w", entry.Name(), err)
+		}
+		return fixtures, nil
+	}
+	return nil, fmt.Errorf("no fixture set named %q, have %s", name, strings.Join(FixtureSets(), ", "))
+}
+
+func fixtureSetName(file string) (string, bool) {
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		if strings.HasSuffix(file, ext) {
+			return strings.TrimSuffix(file, ext), true
+		}
+	}
+	return "", false
+}
@@ -1,15 +1,334 @@
 package db
 
 import (
+	"embed"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
 	"gorm.io/gorm"
 	"instashop/models"
 )
 
-func Migrate(db *gorm.DB) {
-	db.AutoMigrate(
-		&models.User{},
-		&models.Order{},
-		&models.Product{},
-		&models.OrderItem{},
-	)
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change, read from
+// migrations/<version>_<name>.up.sql and its matching .down.sql.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied, if it has been.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of schema_migrations, one per applied migration.
+type schemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// ErrDropsAllTables is returned when a rollback would undo the first
+// migration, which drops every table and its data, without ConfirmDropAll.
+var ErrDropsAllTables = errors.New("rolling back the first migration drops every table; confirm it explicitly")
+
+// Migrator applies and rolls back the embedded migrations. Each migration
+// runs in its own transaction together with its schema_migrations row, under
+// an advisory lock so app instances starting together don't race.
+//
+// Migrations that convert existing data can read the store currency and how
+// many minor units it has from the instashop.store_currency and
+// instashop.minor_units settings.
+type Migrator struct {
+	db            *gorm.DB
+	migrations    []Migration
+	storeCurrency string
+
+	// ConfirmDropAll allows rollbacks that undo the first migration.
+	ConfirmDropAll bool
+}
+
+func NewMigrator(db *gorm.DB, storeCurrency string) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, storeCurrency: storeCurrency}, nil
+}
+
+// Migrate brings the schema up to the latest migration.
+func Migrate(db *gorm.DB, storeCurrency string) error {
+	migrator, err := NewMigrator(db, storeCurrency)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up()
+	return err
+}
+
+// Up applies every pending migration in order and returns how many it
+// applied.
+func (m *Migrator) Up() (int, error) {
+	if len(m.migrations) == 0 {
+		return 0, nil
+	}
+	return m.To(m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down rolls back the latest steps applied migrations.
+func (m *Migrator) Down(steps int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	versions := sortedVersions(applied)
+	if steps > len(versions) {
+		steps = len(versions)
+	}
+
+	var target uint
+	if steps < len(versions) {
+		target = versions[len(versions)-steps-1]
+	}
+	return m.To(target)
+}
+
+// To applies or rolls back migrations until version is the latest applied.
+// Version 0 rolls everything back.
+func (m *Migrator) To(version uint) (int, error) {
+	if version != 0 && m.find(version) == nil {
+		return 0, fmt.Errorf("no migration with version %d", version)
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	versions := sortedVersions(applied)
+	if len(versions) > 0 && versions[0] > version && versions[0] == m.migrations[0].Version && !m.ConfirmDropAll {
+		return 0, ErrDropsAllTables
+	}
+
+	count := 0
+	for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+		migration := m.find(versions[i])
+		if migration == nil {
+			return count, fmt.Errorf("migration %d is applied but this build has no file for it", versions[i])
+		}
+		ran, err := m.run(*migration, false)
+		if err != nil {
+			return count, err
+		}
+		if ran {
+			count++
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		ran, err := m.run(migration, true)
+		if err != nil {
+			return count, err
+		}
+		if ran {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Status lists every known migration with when it was applied.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// run applies (up) or rolls back one migration. It re-checks
+// schema_migrations once it holds the lock and returns false if another
+// instance got there first.
+func (m *Migrator) run(migration Migration, up bool) (bool, error) {
+	ran := false
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey()).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&schemaMigration{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if (count > 0) == up {
+			return nil
+		}
+
+		err := tx.Exec("SELECT set_config('instashop.store_currency', ?, true), set_config('instashop.minor_units', ?, true)",
+			m.storeCurrency, strconv.Itoa(models.MinorUnits(m.storeCurrency))).Error
+		if err != nil {
+			return err
+		}
+
+		if up {
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			ran = true
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		}
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return err
+		}
+		ran = true
+		return tx.Delete(&schemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		direction := "applying"
+		if !up {
+			direction = "rolling back"
+		}
+		return false, fmt.Errorf("%s migration %04d_%s: %w", direction, migration.Version, migration.Name, err)
+	}
+
+	if ran {
+		if up {
+			log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+		} else {
+			log.Printf("Rolled back migration %04d_%s", migration.Version, migration.Name)
+		}
+	}
+	return ran, nil
+}
+
+func (m *Migrator) applied() (map[uint]schemaMigration, error) {
+	err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []schemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version uint) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// loadMigrations reads the migration files, ordered by version. Every
+// version needs both an up and a down file.
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named <version>_<name>.<up|down>.sql", entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration file %s has an invalid version", entry.Name())
+		}
+		content, err := fs.ReadFile(files, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files named both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func sortedVersions(applied map[uint]schemaMigration) []uint {
+	versions := make([]uint, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+	return versions
+}
+
+func migrationLockKey() int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte("instashop.migrations"))
+	return int64(hash.Sum64())
 }
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB opens TEST_DATABASE_URL with a single connection whose search_path
+// starts at a throwaway schema, so each test sees an empty database. Tests
+// that need it are skipped when the variable isn't set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	if err := db.Exec(fmt.Sprintf("SET search_path TO %s, public", schema)).Error; err != nil {
+		t.Fatalf("setting search_path: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+	return db
+}
+
+// baselineSchema is what AutoMigrate created for the original four models,
+// before migrations existed.
+var baselineSchema = []string{
+	`CREATE TABLE users (
+		id bigserial PRIMARY KEY,
+		email text,
+		first_name text,
+		last_name text,
+		password text,
+		phone_number text,
+		profile_picture text,
+		is_verified boolean DEFAULT false,
+		is_admin boolean DEFAULT false,
+		created_at timestamptz,
+		updated_at timestamptz
+	)`,
+	`CREATE TABLE orders (
+		id bigserial PRIMARY KEY,
+		user_id bigint NOT NULL,
+		status varchar(20) DEFAULT 'pending',
+		total_price decimal NOT NULL,
+		created_at timestamptz,
+		updated_at timestamptz
+	)`,
+	`CREATE TABLE products (
+		id bigserial PRIMARY KEY,
+		name text NOT NULL,
+		description text NOT NULL,
+		price decimal NOT NULL,
+		stock bigint NOT NULL,
+		created_at timestamptz,
+		updated_at timestamptz
+	)`,
+	`CREATE TABLE order_items (
+		id bigserial PRIMARY KEY,
+		order_id bigint NOT NULL,
+		product_id bigint NOT NULL,
+		quantity bigint NOT NULL,
+		price decimal NOT NULL,
+		created_at timestamptz,
+		updated_at timestamptz,
+		CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders(id)
+	)`,
+	`INSERT INTO users (email, first_name) VALUES ('ada@example.com', 'Ada')`,
+	`INSERT INTO products (name, description, price, stock, created_at, updated_at)
+		VALUES ('Lamp', 'A desk lamp', 19.99, 4, now(), now())`,
+	`INSERT INTO orders (user_id, status, total_price, created_at, updated_at)
+		VALUES (1, 'completed', 39.98, now(), now())`,
+	`INSERT INTO order_items (order_id, product_id, quantity, price, created_at, updated_at)
+		VALUES (1, 1, 2, 19.99, now(), now())`,
+}
+
+func buildBaseline(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, stmt := range baselineSchema {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("building baseline schema: %v", err)
+		}
+	}
+}
+
+func TestMigrateUpgradesBaselineSchema(t *testing.T) {
+	db := testDB(t)
+	buildBaseline(t, db)
+
+	if err := Migrate(db, "USD"); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	var product struct {
+		PriceAmount   int64
+		PriceCurrency string
+		Status        string
+	}
+	if err := db.Raw("SELECT price_amount, price_currency, status FROM products WHERE id = 1").Scan(&product).Error; err != nil {
+		t.Fatal(err)
+	}
+	if product.PriceAmount != 1999 || product.PriceCurrency != "USD" || product.Status != "active" {
+		t.Errorf("product = %+v, want 1999 USD active", product)
+	}
+
+	var order struct {
+		TotalPriceAmount int64
+		SubtotalAmount   int64
+		SubtotalCurrency string
+	}
+	if err := db.Raw("SELECT total_price_amount, subtotal_amount, subtotal_currency FROM orders WHERE id = 1").Scan(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	if order.TotalPriceAmount != 3998 || order.SubtotalAmount != 3998 || order.SubtotalCurrency != "USD" {
+		t.Errorf("order = %+v, want total and subtotal 3998 USD", order)
+	}
+
+	var item struct {
+		PriceAmount int64
+		ProductName string
+	}
+	if err := db.Raw("SELECT price_amount, product_name FROM order_items WHERE id = 1").Scan(&item).Error; err != nil {
+		t.Fatal(err)
+	}
+	if item.PriceAmount != 1999 || item.ProductName != "Lamp" {
+		t.Errorf("order item = %+v, want 1999 and the product's name", item)
+	}
+
+	for _, column := range []struct{ table, name string }{
+		{"products", "price"}, {"orders", "total_price"}, {"order_items", "price"},
+	} {
+		if db.Migrator().HasColumn(column.table, column.name) {
+			t.Errorf("%s.%s was not dropped", column.table, column.name)
+		}
+	}
+}
+
+func TestMigrateConvertsToStoreCurrency(t *testing.T) {
+	db := testDB(t)
+	buildBaseline(t, db)
+
+	// JPY has no minor unit, so 19.99 rounds to 20 yen.
+	if err := Migrate(db, "JPY"); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	var product struct {
+		PriceAmount   int64
+		PriceCurrency string
+	}
+	if err := db.Raw("SELECT price_amount, price_currency FROM products WHERE id = 1").Scan(&product).Error; err != nil {
+		t.Fatal(err)
+	}
+	if product.PriceAmount != 20 || product.PriceCurrency != "JPY" {
+		t.Errorf("product = %+v, want 20 JPY", product)
+	}
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	db := testDB(t)
+	migrator, err := NewMigrator(db, "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	// Running again finds nothing to do.
+	if count, err := migrator.Up(); err != nil || count != 0 {
+		t.Fatalf("second Up = %d, %v; want 0, nil", count, err)
+	}
+
+	if _, err := migrator.To(0); !errors.Is(err, ErrDropsAllTables) {
+		t.Fatalf("To(0) without confirmation = %v, want ErrDropsAllTables", err)
+	}
+	if !db.Migrator().HasTable("users") {
+		t.Fatal("users was dropped without confirmation")
+	}
+
+	migrator.ConfirmDropAll = true
+	if _, err := migrator.To(0); err != nil {
+		t.Fatalf("To(0): %v", err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("users survived rolling everything back")
+	}
+}
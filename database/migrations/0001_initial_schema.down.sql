@@ -0,0 +1,24 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS return_items;
+DROP TABLE IF EXISTS return_requests;
+DROP TABLE IF EXISTS fulfillment_items;
+DROP TABLE IF EXISTS fulfillments;
+DROP TABLE IF EXISTS shipping_methods;
+DROP TABLE IF EXISTS shipping_zones;
+DROP TABLE IF EXISTS tax_rules;
+DROP TABLE IF EXISTS order_discounts;
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
+DROP TABLE IF EXISTS product_prices;
+DROP TABLE IF EXISTS exchange_rates;
+DROP TABLE IF EXISTS variant_option_values;
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS option_values;
+DROP TABLE IF EXISTS option_types;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,384 @@
+-- Schema as the models defined it when migrations replaced AutoMigrate, for
+-- a fresh database. A database the first AutoMigrate built already has
+-- users, products, orders and order_items in their original shape; those
+-- are skipped here and 0002 brings them up to date, so the indexes on their
+-- newer columns live there too.
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial,
+    email text,
+    first_name text,
+    last_name text,
+    password text,
+    phone_number text,
+    profile_picture text,
+    is_verified boolean DEFAULT false,
+    is_admin boolean DEFAULT false,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS products (
+    id bigserial,
+    name text NOT NULL,
+    description text NOT NULL,
+    price_amount bigint NOT NULL DEFAULT 0,
+    price_currency varchar(3) NOT NULL DEFAULT 'USD',
+    stock bigint NOT NULL,
+    image_url text,
+    category text,
+    status varchar(20) DEFAULT 'active',
+    tax_class varchar(50) NOT NULL DEFAULT 'standard',
+    weight_grams bigint NOT NULL DEFAULT 0,
+    length_mm bigint NOT NULL DEFAULT 0,
+    width_mm bigint NOT NULL DEFAULT 0,
+    height_mm bigint NOT NULL DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id bigserial,
+    user_id bigint NOT NULL,
+    status varchar(20) DEFAULT 'pending',
+    total_price_amount bigint NOT NULL DEFAULT 0,
+    total_price_currency varchar(3) NOT NULL DEFAULT 'USD',
+    created_at timestamptz,
+    updated_at timestamptz,
+    subtotal_amount bigint NOT NULL DEFAULT 0,
+    subtotal_currency varchar(3) NOT NULL DEFAULT 'USD',
+    discount_total_amount bigint NOT NULL DEFAULT 0,
+    discount_total_currency varchar(3) NOT NULL DEFAULT 'USD',
+    coupon_code text,
+    base_currency varchar(3),
+    exchange_rate numeric(20,10) NOT NULL DEFAULT 1,
+    exchange_rate_id bigint,
+    tax_total_amount bigint NOT NULL DEFAULT 0,
+    tax_total_currency varchar(3) NOT NULL DEFAULT 'USD',
+    tax_inclusive boolean NOT NULL DEFAULT false,
+    shipping_line1 text,
+    shipping_line2 text,
+    shipping_city text,
+    shipping_region text,
+    shipping_postal_code text,
+    shipping_country varchar(2),
+    shipping_method_id bigint,
+    shipping_method_name text,
+    shipping_total_amount bigint NOT NULL DEFAULT 0,
+    shipping_total_currency varchar(3) NOT NULL DEFAULT 'USD',
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id bigserial,
+    order_id bigint NOT NULL,
+    product_id bigint NOT NULL,
+    variant_id bigint,
+    quantity bigint NOT NULL,
+    price_amount bigint NOT NULL DEFAULT 0,
+    price_currency varchar(3) NOT NULL DEFAULT 'USD',
+    created_at timestamptz,
+    updated_at timestamptz,
+    product_name text NOT NULL DEFAULT '',
+    sku text,
+    description_excerpt text,
+    image_url text,
+    tax_amount bigint NOT NULL DEFAULT 0,
+    tax_currency varchar(3) NOT NULL DEFAULT 'USD',
+    tax_rate bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_order_items_product FOREIGN KEY (product_id) REFERENCES products(id),
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+
+CREATE TABLE IF NOT EXISTS option_types (
+    id bigserial,
+    name text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_option_types_name ON option_types (name);
+
+CREATE TABLE IF NOT EXISTS option_values (
+    id bigserial,
+    option_type_id bigint NOT NULL,
+    value text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_option_types_values FOREIGN KEY (option_type_id) REFERENCES option_types(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_option_type_value ON option_values (option_type_id,value);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id bigserial,
+    product_id bigint NOT NULL,
+    sku text NOT NULL,
+    price_amount bigint DEFAULT null,
+    stock bigint NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_products_variants FOREIGN KEY (product_id) REFERENCES products(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_sku ON product_variants (sku);
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants (product_id);
+
+CREATE TABLE IF NOT EXISTS variant_option_values (
+    product_variant_id bigint,
+    option_value_id bigint,
+    PRIMARY KEY (product_variant_id,option_value_id),
+    CONSTRAINT fk_variant_option_values_product_variant FOREIGN KEY (product_variant_id) REFERENCES product_variants(id),
+    CONSTRAINT fk_variant_option_values_option_value FOREIGN KEY (option_value_id) REFERENCES option_values(id)
+);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id bigserial,
+    base_currency varchar(3) NOT NULL,
+    quote_currency varchar(3) NOT NULL,
+    rate numeric(20,10) NOT NULL,
+    effective_at timestamptz NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_exchange_rate_pair ON exchange_rates (base_currency,quote_currency,effective_at);
+
+CREATE TABLE IF NOT EXISTS product_prices (
+    id bigserial,
+    product_id bigint NOT NULL,
+    currency varchar(3) NOT NULL,
+    amount bigint NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_price_currency ON product_prices (product_id,currency);
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id bigserial,
+    code text NOT NULL,
+    type varchar(20) NOT NULL,
+    percent_off bigint NOT NULL DEFAULT 0,
+    amount_off_amount bigint NOT NULL DEFAULT 0,
+    amount_off_currency varchar(3) NOT NULL DEFAULT 'USD',
+    min_spend_amount bigint NOT NULL DEFAULT 0,
+    min_spend_currency varchar(3) NOT NULL DEFAULT 'USD',
+    usage_limit bigint DEFAULT null,
+    per_user_limit bigint DEFAULT null,
+    times_used bigint NOT NULL DEFAULT 0,
+    starts_at timestamptz DEFAULT null,
+    ends_at timestamptz DEFAULT null,
+    product_ids text,
+    categories text,
+    is_active boolean DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coupons_code ON coupons (code);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id bigserial,
+    coupon_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    order_id bigint NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_order_id ON coupon_redemptions (order_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemption_user ON coupon_redemptions (coupon_id,user_id);
+
+CREATE TABLE IF NOT EXISTS order_discounts (
+    id bigserial,
+    order_id bigint NOT NULL,
+    coupon_id bigint NOT NULL,
+    code text NOT NULL,
+    type varchar(20) NOT NULL,
+    amount_amount bigint NOT NULL DEFAULT 0,
+    amount_currency varchar(3) NOT NULL DEFAULT 'USD',
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_orders_discounts FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+CREATE INDEX IF NOT EXISTS idx_order_discounts_order_id ON order_discounts (order_id);
+
+CREATE TABLE IF NOT EXISTS tax_rules (
+    id bigserial,
+    name text NOT NULL,
+    country varchar(2) NOT NULL,
+    region varchar(100) NOT NULL DEFAULT '',
+    tax_class varchar(50) NOT NULL,
+    rate bigint NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_rule_scope ON tax_rules (country,region,tax_class);
+
+CREATE TABLE IF NOT EXISTS shipping_zones (
+    id bigserial,
+    name text NOT NULL,
+    countries text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shipping_zones_name ON shipping_zones (name);
+
+CREATE TABLE IF NOT EXISTS shipping_methods (
+    id bigserial,
+    zone_id bigint NOT NULL,
+    name text NOT NULL,
+    type varchar(30) NOT NULL,
+    rate_amount bigint NOT NULL DEFAULT 0,
+    rate_currency varchar(3) NOT NULL DEFAULT 'USD',
+    per_kg_amount bigint NOT NULL DEFAULT 0,
+    per_kg_currency varchar(3) NOT NULL DEFAULT 'USD',
+    free_over_amount bigint NOT NULL DEFAULT 0,
+    free_over_currency varchar(3) NOT NULL DEFAULT 'USD',
+    is_active boolean DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_shipping_zones_methods FOREIGN KEY (zone_id) REFERENCES shipping_zones(id)
+);
+CREATE INDEX IF NOT EXISTS idx_shipping_methods_zone_id ON shipping_methods (zone_id);
+
+CREATE TABLE IF NOT EXISTS fulfillments (
+    id bigserial,
+    order_id bigint NOT NULL,
+    carrier text NOT NULL,
+    tracking_number text,
+    tracking_url text,
+    status varchar(20) NOT NULL DEFAULT 'shipped',
+    shipped_at timestamptz,
+    delivered_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_fulfillments_order_id ON fulfillments (order_id);
+CREATE INDEX IF NOT EXISTS idx_fulfillments_tracking_number ON fulfillments (tracking_number);
+
+CREATE TABLE IF NOT EXISTS fulfillment_items (
+    id bigserial,
+    fulfillment_id bigint NOT NULL,
+    order_item_id bigint NOT NULL,
+    quantity bigint NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_fulfillments_items FOREIGN KEY (fulfillment_id) REFERENCES fulfillments(id)
+);
+CREATE INDEX IF NOT EXISTS idx_fulfillment_items_order_item_id ON fulfillment_items (order_item_id);
+CREATE INDEX IF NOT EXISTS idx_fulfillment_items_fulfillment_id ON fulfillment_items (fulfillment_id);
+
+CREATE TABLE IF NOT EXISTS return_requests (
+    id bigserial,
+    order_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'requested',
+    reason text NOT NULL,
+    photo_urls text,
+    admin_note text,
+    refund_amount bigint NOT NULL DEFAULT 0,
+    refund_currency varchar(3) NOT NULL DEFAULT 'USD',
+    resolved_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_return_requests_order_id ON return_requests (order_id);
+CREATE INDEX IF NOT EXISTS idx_return_requests_status ON return_requests (status);
+CREATE INDEX IF NOT EXISTS idx_return_requests_user_id ON return_requests (user_id);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    id bigserial,
+    return_request_id bigint NOT NULL,
+    order_item_id bigint NOT NULL,
+    quantity bigint NOT NULL,
+    restock boolean NOT NULL DEFAULT false,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_return_requests_items FOREIGN KEY (return_request_id) REFERENCES return_requests(id)
+);
+CREATE INDEX IF NOT EXISTS idx_return_items_order_item_id ON return_items (order_item_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_return_request_id ON return_items (return_request_id);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id bigserial,
+    user_id bigint NOT NULL,
+    key varchar(255) NOT NULL,
+    fingerprint varchar(64) NOT NULL,
+    status_code bigint NOT NULL DEFAULT 0,
+    response_body bytea,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_user_key ON idempotency_keys (user_id,key);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id bigserial,
+    name varchar(100) NOT NULL,
+    payload jsonb NOT NULL,
+    occurred_at timestamptz NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    attempts bigint NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL,
+    last_error text,
+    published_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_due ON outbox_events (status,next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_name ON outbox_events (name);
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id bigserial,
+    url text NOT NULL,
+    description varchar(255),
+    secret varchar(100) NOT NULL,
+    events text,
+    is_active boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial,
+    endpoint_id bigint NOT NULL,
+    event_id bigint NOT NULL,
+    event_name varchar(100) NOT NULL,
+    payload jsonb NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    attempts bigint NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL,
+    response_status bigint,
+    response_body text,
+    last_error text,
+    delivered_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_due ON webhook_deliveries (status,next_attempt_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_delivery_event ON webhook_deliveries (endpoint_id,event_id);
+
+-- Catalog search: a weighted tsvector over name and description for
+-- full-text ranking, and trigram indexes for the fuzzy fallback.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_description_trgm ON products USING GIN (description gin_trgm_ops);
@@ -0,0 +1,7 @@
+-- The columns and converted prices are what 0001 defines on a fresh
+-- database, so only the indexes this migration owns are rolled back.
+DROP INDEX IF EXISTS idx_order_items_variant_id;
+DROP INDEX IF EXISTS idx_orders_coupon_code;
+DROP INDEX IF EXISTS idx_products_deleted_at;
+DROP INDEX IF EXISTS idx_products_category;
+DROP INDEX IF EXISTS idx_products_status;
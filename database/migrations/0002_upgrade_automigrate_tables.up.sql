@@ -0,0 +1,118 @@
+-- Brings users, products, orders and order_items up to the shape 0001 gives
+-- them on a fresh database when the first AutoMigrate created them instead:
+-- adds the columns they lack, converts their float prices to minor units
+-- and backfills what older orders never recorded. On a fresh database only
+-- the indexes are new.
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS price_amount bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS price_currency varchar(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS image_url text,
+    ADD COLUMN IF NOT EXISTS category text,
+    ADD COLUMN IF NOT EXISTS status varchar(20) DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS tax_class varchar(50) NOT NULL DEFAULT 'standard',
+    ADD COLUMN IF NOT EXISTS weight_grams bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS length_mm bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS width_mm bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS height_mm bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS total_price_amount bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS total_price_currency varchar(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS subtotal_amount bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS subtotal_currency varchar(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS discount_total_amount bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount_total_currency varchar(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS coupon_code text,
+    ADD COLUMN IF NOT EXISTS base_currency varchar(3),
+    ADD COLUMN IF NOT EXISTS exchange_rate numeric(20,10) NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS exchange_rate_id bigint,
+    ADD COLUMN IF NOT EXISTS tax_total_amount bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_total_currency varchar(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS tax_inclusive boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS shipping_line1 text,
+    ADD COLUMN IF NOT EXISTS shipping_line2 text,
+    ADD COLUMN IF NOT EXISTS shipping_city text,
+    ADD COLUMN IF NOT EXISTS shipping_region text,
+    ADD COLUMN IF NOT EXISTS shipping_postal_code text,
+    ADD COLUMN IF NOT EXISTS shipping_country varchar(2),
+    ADD COLUMN IF NOT EXISTS shipping_method_id bigint,
+    ADD COLUMN IF NOT EXISTS shipping_method_name text,
+    ADD COLUMN IF NOT EXISTS shipping_total_amount bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS shipping_total_currency varchar(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS variant_id bigint,
+    ADD COLUMN IF NOT EXISTS price_amount bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS price_currency varchar(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS product_name text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS sku text,
+    ADD COLUMN IF NOT EXISTS description_excerpt text,
+    ADD COLUMN IF NOT EXISTS image_url text,
+    ADD COLUMN IF NOT EXISTS tax_amount bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_currency varchar(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS tax_rate bigint NOT NULL DEFAULT 0;
+
+-- The float price columns only exist on AutoMigrate-built tables, and every
+-- row in such a table predates the columns added above, so the backfills
+-- run only when the float column is still there to convert. Prices were in
+-- major units of the store currency.
+DO $$
+DECLARE
+    store_currency varchar(3) := current_setting('instashop.store_currency');
+    scale numeric := power(10::numeric, current_setting('instashop.minor_units')::int);
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'products' AND column_name = 'price') THEN
+        UPDATE products SET
+            price_amount = round(price * scale)::bigint,
+            price_currency = store_currency;
+        ALTER TABLE products DROP COLUMN price;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'orders' AND column_name = 'total_price') THEN
+        UPDATE orders SET
+            total_price_amount = round(total_price * scale)::bigint,
+            total_price_currency = store_currency;
+        -- Orders placed before discounts, tax and shipping existed were
+        -- charged exactly their item total.
+        UPDATE orders SET
+            subtotal_amount = total_price_amount,
+            subtotal_currency = total_price_currency,
+            discount_total_currency = total_price_currency,
+            tax_total_currency = total_price_currency,
+            shipping_total_currency = total_price_currency;
+        ALTER TABLE orders DROP COLUMN total_price;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'order_items' AND column_name = 'price') THEN
+        UPDATE order_items SET
+            price_amount = round(price * scale)::bigint,
+            price_currency = store_currency,
+            tax_currency = store_currency;
+        -- Purchase-time snapshots weren't recorded yet; the product as it
+        -- is now is the closest there is.
+        UPDATE order_items SET
+            product_name = products.name,
+            description_excerpt = left(products.description, 200),
+            image_url = products.image_url
+        FROM products
+        WHERE order_items.product_id = products.id;
+        ALTER TABLE order_items DROP COLUMN price;
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint
+                   WHERE conname = 'fk_order_items_product' AND conrelid = 'order_items'::regclass) THEN
+        ALTER TABLE order_items ADD CONSTRAINT fk_order_items_product
+            FOREIGN KEY (product_id) REFERENCES products(id);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_products_status ON products (status);
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_orders_coupon_code ON orders (coupon_code);
+CREATE INDEX IF NOT EXISTS idx_order_items_variant_id ON order_items (variant_id);
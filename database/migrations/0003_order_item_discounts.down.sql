@@ -0,0 +1,3 @@
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS discount_currency,
+    DROP COLUMN IF EXISTS discount_amount;
@@ -0,0 +1,33 @@
+-- Each order item records its share of the coupon discount so returns can
+-- refund what was actually paid for the line.
+ALTER TABLE order_items
+    ADD COLUMN discount_amount bigint NOT NULL DEFAULT 0,
+    ADD COLUMN discount_currency varchar(3) NOT NULL DEFAULT 'USD';
+
+UPDATE order_items SET discount_currency = price_currency;
+
+-- Orders placed before this didn't record which lines their coupon
+-- covered, so the item discount is spread over every line in proportion to
+-- its total, with the rounding leftover on the last line. Free-shipping
+-- discounts came off shipping rather than the items.
+WITH shares AS (
+    SELECT order_items.id,
+           order_items.order_id,
+           orders.discount_total_amount AS discount,
+           orders.discount_total_amount * order_items.price_amount * order_items.quantity
+               / orders.subtotal_amount AS share,
+           row_number() OVER (PARTITION BY order_items.order_id ORDER BY order_items.id DESC) AS from_last
+    FROM order_items
+    JOIN orders ON orders.id = order_items.order_id
+    WHERE orders.discount_total_amount > 0
+      AND orders.subtotal_amount > 0
+      AND NOT EXISTS (SELECT 1 FROM order_discounts
+                      WHERE order_discounts.order_id = orders.id AND order_discounts.type = 'free_shipping')
+), totals AS (
+    SELECT order_id, sum(share) AS allocated FROM shares GROUP BY order_id
+)
+UPDATE order_items SET discount_amount = shares.share
+    + CASE WHEN shares.from_last = 1 THEN shares.discount - totals.allocated ELSE 0 END
+FROM shares
+JOIN totals ON totals.order_id = shares.order_id
+WHERE order_items.id = shares.id;
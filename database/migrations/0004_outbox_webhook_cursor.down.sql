@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_outbox_webhooks_unqueued;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS webhooks_queued_at;
@@ -0,0 +1,10 @@
+-- Webhook deliveries are queued from the outbox on their own, rather than
+-- after the event reaches the broker, so they get their own marker.
+ALTER TABLE outbox_events ADD COLUMN webhooks_queued_at timestamptz;
+
+-- Published events had their deliveries queued when they were published,
+-- and failed ones were given up on; only pending events still need them.
+UPDATE outbox_events SET webhooks_queued_at = coalesce(published_at, updated_at, now())
+WHERE status <> 'pending';
+
+CREATE INDEX idx_outbox_webhooks_unqueued ON outbox_events (id) WHERE webhooks_queued_at IS NULL;
@@ -0,0 +1,11 @@
+-- Without the column a deleted variant would come back, so deleted ones are
+-- removed for good, as deleting a variant used to do.
+DELETE FROM variant_option_values
+WHERE product_variant_id IN (SELECT id FROM product_variants WHERE deleted_at IS NOT NULL);
+DELETE FROM product_variants WHERE deleted_at IS NOT NULL;
+
+DROP INDEX idx_product_variants_sku;
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (sku);
+
+DROP INDEX IF EXISTS idx_product_variants_deleted_at;
+ALTER TABLE product_variants DROP COLUMN IF EXISTS deleted_at;
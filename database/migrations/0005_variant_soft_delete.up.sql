@@ -0,0 +1,8 @@
+-- Variants are soft-deleted, like products, so order items referencing them
+-- still resolve. Only live variants need unique SKUs, so a deleted variant's
+-- SKU can be used again.
+ALTER TABLE product_variants ADD COLUMN deleted_at timestamptz;
+CREATE INDEX idx_product_variants_deleted_at ON product_variants (deleted_at);
+
+DROP INDEX idx_product_variants_sku;
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (sku) WHERE deleted_at IS NULL;
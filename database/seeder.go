@@ -1,9 +1,473 @@
 package db
 
 import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
+	"instashop/internal/repositories"
+	"instashop/internal/utils"
+	"instashop/models"
 )
 
+// SeedOptions says what Seed creates. The admin is only created when both
+// AdminEmail and AdminPassword are set, and an empty Fixtures or "none"
+// skips the fixtures.
+type SeedOptions struct {
+	AdminEmail    string
+	AdminPassword string
+	Fixtures      string
+	Currency      string
+}
+
+// StartSeeder seeds what the configuration asks for.
 func StartSeeder(db *gorm.DB) error {
+	config := utils.GetConfig()
+	return Seed(db, SeedOptions{
+		AdminEmail:    config.AdminEmail,
+		AdminPassword: config.AdminPassword,
+		Fixtures:      config.SeedFixtures,
+		Currency:      config.StoreCurrency,
+	})
+}
+
+// Seed creates the admin and loads the fixture set in opts. It is safe to
+// run repeatedly: anything that already exists, matched by email, name or
+// SKU, is left as it is.
+func Seed(db *gorm.DB, opts SeedOptions) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", seederLockKey()).Error; err != nil {
+			return err
+		}
+		if opts.AdminEmail != "" && opts.AdminPassword != "" {
+			if err := seedAdmin(tx, opts.AdminEmail, opts.AdminPassword); err != nil {
+				return err
+			}
+		}
+		if opts.Fixtures == "" || opts.Fixtures == "none" {
+			return nil
+		}
+
+		fixtures, err := LoadFixtures(opts.Fixtures)
+		if err != nil {
+			return err
+		}
+		seeder := &fixtureSeeder{tx: tx, currency: opts.Currency}
+		if err := seeder.seed(fixtures); err != nil {
+			return fmt.Errorf("seeding %s fixtures: %w", opts.Fixtures, err)
+		}
+		log.Printf("Seeded %s fixtures", opts.Fixtures)
+		return nil
+	})
+}
+
+func seedAdmin(tx *gorm.DB, email, password string) error {
+	var count int64
+	if err := tx.Model(&models.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	isAdmin, isVerified := true, true
+	admin := models.User{
+		Email:      email,
+		FirstName:  "Admin",
+		Password:   hashedPassword,
+		IsAdmin:    &isAdmin,
+		IsVerified: &isVerified,
+	}
+	if err := tx.Create(&admin).Error; err != nil {
+		return err
+	}
+	log.Printf("Created admin %s", email)
+	return nil
+}
+
+// fixtureSeeder writes one fixture set inside a transaction, remembering
+// what it finds or creates so later fixtures can refer to it.
+type fixtureSeeder struct {
+	tx       *gorm.DB
+	currency string
+	products map[string]*models.Product
+	variants map[string]*models.ProductVariant
+}
+
+func (s *fixtureSeeder) seed(fixtures *Fixtures) error {
+	s.products = map[string]*models.Product{}
+	s.variants = map[string]*models.ProductVariant{}
+
+	for _, zone := range fixtures.ShippingZones {
+		if err := s.seedShippingZone(zone); err != nil {
+			return err
+		}
+	}
+	for _, rule := range fixtures.TaxRules {
+		if err := s.seedTaxRule(rule); err != nil {
+			return err
+		}
+	}
+	for _, product := range fixtures.Products {
+		if err := s.seedProduct(product); err != nil {
+			return err
+		}
+	}
+
+	// Orders are only added for users created in this run, so seeding twice
+	// doesn't give a user the same orders twice.
+	newUsers := map[string]uint{}
+	for _, user := range fixtures.Users {
+		id, created, err := s.seedUser(user)
+		if err != nil {
+			return err
+		}
+		if created {
+			newUsers[user.Email] = id
+		}
+	}
+	for _, order := range fixtures.Orders {
+		userID, ok := newUsers[order.User]
+		if !ok {
+			continue
+		}
+		if err := s.seedOrder(order, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fixtureSeeder) seedShippingZone(fixture zoneFixture) error {
+	zone := models.ShippingZone{Name: fixture.Name}
+	err := s.tx.Where("name = ?", fixture.Name).First(&zone).Error
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	for _, country := range fixture.Countries {
+		zone.Countries = append(zone.Countries, strings.ToUpper(country))
+	}
+	for _, method := range fixture.Methods {
+		zone.Methods = append(zone.Methods, models.ShippingMethod{
+			Name:     method.Name,
+			Type:     models.ShippingMethodType(method.Type),
+			Rate:     models.NewMoney(method.Rate, s.currency),
+			PerKg:    models.NewMoney(method.PerKg, s.currency),
+			FreeOver: models.NewMoney(method.FreeOver, s.currency),
+		})
+	}
+	return s.tx.Create(&zone).Error
+}
+
+func (s *fixtureSeeder) seedTaxRule(fixture taxRuleFixture) error {
+	taxClass := fixture.TaxClass
+	if taxClass == "" {
+		taxClass = models.DefaultTaxClass
+	}
+	rule := models.TaxRule{
+		Name:     fixture.Name,
+		Country:  strings.ToUpper(fixture.Country),
+		Region:   fixture.Region,
+		TaxClass: taxClass,
+		Rate:     fixture.Rate,
+	}
+	return s.tx.Where(models.TaxRule{Country: rule.Country, Region: rule.Region, TaxClass: rule.TaxClass}).
+		Attrs(rule).
+		FirstOrCreate(&rule).Error
+}
+
+func (s *fixtureSeeder) seedProduct(fixture productFixture) error {
+	var product models.Product
+	err := s.tx.Unscoped().Preload("Variants").Where("name = ?", fixture.Name).First(&product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		product, err = s.createProduct(fixture)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.products[product.Name] = &product
+	for i := range product.Variants {
+		s.variants[product.Variants[i].SKU] = &product.Variants[i]
+	}
+	return nil
+}
+
+func (s *fixtureSeeder) createProduct(fixture productFixture) (models.Product, error) {
+	product := models.Product{Name: fixture.Name}
+	s.applyProduct(&product, fixture)
+	if err := s.tx.Omit("Variants").Create(&product).Error; err != nil {
+		return product, err
+	}
+	for currency, amount := range fixture.Prices {
+		price := models.ProductPrice{ProductID: product.ID, Currency: strings.ToUpper(currency), Amount: amount}
+		if err := s.tx.Create(&price).Error; err != nil {
+			return product, err
+		}
+	}
+
+	for _, variantFixture := range fixture.Variants {
+		variant := models.ProductVariant{ProductID: product.ID, SKU: variantFixture.SKU}
+		if err := s.saveVariant(&variant, variantFixture); err != nil {
+			return product, err
+		}
+		product.Variants = append(product.Variants, variant)
+	}
+	return product, nil
+}
+
+// applyProduct copies the fixture's fields onto product, filling in the
+// defaults for those it leaves out.
+func (s *fixtureSeeder) applyProduct(product *models.Product, fixture productFixture) {
+	currency := fixture.Currency
+	if currency == "" {
+		currency = s.currency
+	}
+	status := models.ProductStatus(fixture.Status)
+	if status == "" {
+		status = models.ProductStatusActive
+	}
+	taxClass := fixture.TaxClass
+	if taxClass == "" {
+		taxClass = models.DefaultTaxClass
+	}
+
+	product.Description = fixture.Description
+	product.Price = models.NewMoney(fixture.Price, currency)
+	product.Stock = fixture.Stock
+	product.ImageURL = fixture.ImageURL
+	product.Category = fixture.Category
+	product.Status = status
+	product.TaxClass = taxClass
+	product.WeightGrams = fixture.WeightGrams
+	product.LengthMM = fixture.LengthMM
+	product.WidthMM = fixture.WidthMM
+	product.HeightMM = fixture.HeightMM
+}
+
+// saveVariant creates variant, or updates it if it has an ID, from fixture.
+func (s *fixtureSeeder) saveVariant(variant *models.ProductVariant, fixture variantFixture) error {
+	optionValues, err := s.optionValues(fixture.Options)
+	if err != nil {
+		return err
+	}
+	variant.PriceAmount = fixture.Price
+	variant.Stock = fixture.Stock
+	variant.OptionValues = optionValues
+
+	if variant.ID == 0 {
+		err = s.tx.Create(variant).Error
+	} else if err = s.tx.Omit("OptionValues").Save(variant).Error; err == nil {
+		err = s.tx.Model(variant).Association("OptionValues").Replace(optionValues)
+	}
+	if err != nil {
+		return fmt.Errorf("variant %s: %w", variant.SKU, err)
+	}
 	return nil
 }
+
+// optionValues finds or creates the option types and values of a variant,
+// given as option type name to value, e.g. size: M.
+func (s *fixtureSeeder) optionValues(options map[string]string) ([]models.OptionValue, error) {
+	values := make([]models.OptionValue, 0, len(options))
+	for typeName, value := range options {
+		optionType := models.OptionType{Name: typeName}
+		if err := s.tx.Where(optionType).FirstOrCreate(&optionType).Error; err != nil {
+			return nil, err
+		}
+		optionValue := models.OptionValue{OptionTypeID: optionType.ID, Value: value}
+		if err := s.tx.Where(optionValue).FirstOrCreate(&optionValue).Error; err != nil {
+			return nil, err
+		}
+		values = append(values, optionValue)
+	}
+	return values, nil
+}
+
+func (s *fixtureSeeder) seedUser(fixture userFixture) (uint, bool, error) {
+	var user models.User
+	err := s.tx.Where("email = ?", fixture.Email).First(&user).Error
+	if err == nil {
+		return user.ID, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, err
+	}
+
+	hashedPassword, err := utils.HashPassword(fixture.Password)
+	if err != nil {
+		return 0, false, err
+	}
+	isVerified := fixture.IsVerified
+	user = models.User{
+		Email:       fixture.Email,
+		FirstName:   fixture.FirstName,
+		LastName:    fixture.LastName,
+		Password:    hashedPassword,
+		PhoneNumber: fixture.PhoneNumber,
+		IsVerified:  &isVerified,
+	}
+	if err := s.tx.Create(&user).Error; err != nil {
+		return 0, false, err
+	}
+	return user.ID, true, nil
+}
+
+// seedOrder records an order as already placed, at catalog prices and
+// without tax or shipping charges, along with its shipment once it's past
+// shipping. The fixture stock levels are what's left after orders that are
+// past pending; a pending order still takes its stock from them like a real
+// one, since cancelling or expiring it puts the stock back.
+func (s *fixtureSeeder) seedOrder(fixture orderFixture, userID uint) error {
+	status := models.OrderStatus(fixture.Status)
+	if status == "" {
+		status = models.OrderStatusPending
+	}
+	placedAt := time.Now().AddDate(0, 0, -fixture.PlacedDaysAgo)
+	subtotal := models.NewMoney(0, s.currency)
+
+	order := models.Order{
+		UserID:        userID,
+		Status:        status,
+		DiscountTotal: models.NewMoney(0, s.currency),
+		TaxTotal:      models.NewMoney(0, s.currency),
+		ShippingTotal: models.NewMoney(0, s.currency),
+		BaseCurrency:  s.currency,
+		ExchangeRate:  1,
+		ShippingAddress: models.Address{
+			Line1:      fixture.ShippingAddress.Line1,
+			City:       fixture.ShippingAddress.City,
+			Region:     fixture.ShippingAddress.Region,
+			PostalCode: fixture.ShippingAddress.PostalCode,
+			Country:    strings.ToUpper(fixture.ShippingAddress.Country),
+		},
+		CreatedAt: placedAt,
+		UpdatedAt: placedAt,
+	}
+
+	for _, itemFixture := range fixture.Items {
+		product, ok := s.products[itemFixture.Product]
+		if !ok {
+			return fmt.Errorf("order for %s names unknown product %q", fixture.User, itemFixture.Product)
+		}
+		item := models.OrderItem{
+			ProductID:          product.ID,
+			Quantity:           itemFixture.Quantity,
+			Price:              product.Price,
+			ProductName:        product.Name,
+			DescriptionExcerpt: utils.Excerpt(product.Description, 200),
+			ImageURL:           product.ImageURL,
+			TaxAmount:          models.NewMoney(0, s.currency),
+			DiscountAmount:     models.NewMoney(0, s.currency),
+			CreatedAt:          placedAt,
+			UpdatedAt:          placedAt,
+		}
+		if itemFixture.SKU != "" {
+			variant, ok := s.variants[itemFixture.SKU]
+			if !ok {
+				return fmt.Errorf("order for %s names unknown SKU %q", fixture.User, itemFixture.SKU)
+			}
+			item.VariantID = &variant.ID
+			item.SKU = variant.SKU
+			item.Price = variant.EffectivePrice(product.Price)
+		}
+
+		if status == models.OrderStatusPending {
+			if err := s.takeStock(item); err != nil {
+				return fmt.Errorf("order for %s: %w", fixture.User, err)
+			}
+		}
+
+		lineTotal := item.Price.Mul(item.Quantity)
+		var err error
+		if subtotal, err = subtotal.Add(lineTotal); err != nil {
+			return err
+		}
+		order.Items = append(order.Items, item)
+	}
+	order.Subtotal = subtotal
+	order.TotalPrice = subtotal
+
+	if err := s.tx.Create(&order).Error; err != nil {
+		return err
+	}
+	return s.seedFulfillment(order, placedAt)
+}
+
+// seedFulfillment ships the whole of an order that's past shipping in one
+// parcel, a day after it was placed, so its tracking and returns work like a
+// real one's. Delivered and completed orders arrived two days later.
+func (s *fixtureSeeder) seedFulfillment(order models.Order, placedAt time.Time) error {
+	var delivered bool
+	switch order.Status {
+	case models.OrderStatusShipped:
+	case models.OrderStatusDelivered, models.OrderStatusCompleted:
+		delivered = true
+	default:
+		return nil
+	}
+
+	now := time.Now()
+	shippedAt := minTime(placedAt.AddDate(0, 0, 1), now)
+	fulfillment := models.Fulfillment{
+		OrderID:        order.ID,
+		Carrier:        "Fixture Post",
+		TrackingNumber: fmt.Sprintf("FX%08d", order.ID),
+		Status:         models.FulfillmentStatusShipped,
+		ShippedAt:      shippedAt,
+		CreatedAt:      shippedAt,
+		UpdatedAt:      shippedAt,
+	}
+	if delivered {
+		deliveredAt := minTime(shippedAt.AddDate(0, 0, 2), now)
+		fulfillment.Status = models.FulfillmentStatusDelivered
+		fulfillment.DeliveredAt = &deliveredAt
+		fulfillment.UpdatedAt = deliveredAt
+	}
+	for _, item := range order.Items {
+		fulfillment.Items = append(fulfillment.Items, models.FulfillmentItem{
+			OrderItemID: item.ID,
+			Quantity:    item.Quantity,
+		})
+	}
+	return s.tx.Create(&fulfillment).Error
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// takeStock reserves an order item's units the way placing the order would.
+func (s *fixtureSeeder) takeStock(item models.OrderItem) error {
+	var err error
+	if item.VariantID != nil {
+		_, err = repositories.NewVariantRepository(s.tx).DecreaseStock(*item.VariantID, item.Quantity)
+	} else {
+		_, err = repositories.NewProductRepository(s.tx).DecreaseStock(item.ProductID, item.Quantity)
+	}
+	if errors.Is(err, repositories.ErrInsufficientStock) {
+		return fmt.Errorf("not enough stock of %s for %d", item.ProductName, item.Quantity)
+	}
+	return err
+}
+
+func seederLockKey() int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte("instashop.seeder"))
+	return int64(hash.Sum64())
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"testing"
+
+	"instashop/models"
+)
+
+func TestSeedShipsOrdersPastShipping(t *testing.T) {
+	db := testDB(t)
+	if err := Migrate(db, "USD"); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	if err := Seed(db, SeedOptions{Fixtures: "test", Currency: "USD"}); err != nil {
+		t.Fatalf("seeding: %v", err)
+	}
+
+	var order models.Order
+	if err := db.Preload("Items").Where("status = ?", models.OrderStatusDelivered).First(&order).Error; err != nil {
+		t.Fatalf("finding delivered order: %v", err)
+	}
+	var fulfillments []models.Fulfillment
+	if err := db.Preload("Items").Where("order_id = ?", order.ID).Find(&fulfillments).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(fulfillments) != 1 {
+		t.Fatalf("got %d fulfillments, want 1", len(fulfillments))
+	}
+	fulfillment := fulfillments[0]
+	if fulfillment.Status != models.FulfillmentStatusDelivered || fulfillment.DeliveredAt == nil {
+		t.Fatalf("fulfillment is %s delivered at %v, want delivered", fulfillment.Status, fulfillment.DeliveredAt)
+	}
+	if len(fulfillment.Items) != len(order.Items) {
+		t.Fatalf("got %d fulfillment items, want %d", len(fulfillment.Items), len(order.Items))
+	}
+	for i, item := range fulfillment.Items {
+		if item.OrderItemID != order.Items[i].ID || item.Quantity != order.Items[i].Quantity {
+			t.Fatalf("fulfillment item %+v doesn't ship order item %+v", item, order.Items[i])
+		}
+	}
+}
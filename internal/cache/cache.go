@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"instashop/internal/utils"
+)
+
+// Cache stores byte values under string keys. Get returns nil without an
+// error when the key is missing or expired. A zero ttl keeps the value until
+// it is deleted: it must not be evicted to make room, so Redis needs one of
+// the volatile-* maxmemory policies.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+var (
+	shared     Cache
+	sharedOnce sync.Once
+)
+
+// Default returns the process-wide cache: Redis when REDIS_ADDR is set,
+// otherwise an in-memory cache. Every caller gets the same instance so
+// invalidations are seen everywhere.
+func Default() Cache {
+	sharedOnce.Do(func() {
+		config := utils.GetConfig()
+		if config.RedisAddr != "" {
+			shared = NewRedisCache(config.RedisAddr, config.RedisPassword, config.RedisDB)
+			return
+		}
+		log.Info("REDIS_ADDR not set, using in-memory cache")
+		shared = NewMemoryCache(defaultMemoryEntries)
+	})
+	return shared
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultMemoryEntries = 10000
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryCache is a Cache local to the process. When it holds maxEntries it
+// drops expired entries, then arbitrary ones, to make room. Values set with a
+// zero ttl are pinned: they are kept apart, never evicted and don't count
+// toward maxEntries, so markers like ProductCache's versions aren't lost.
+type MemoryCache struct {
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	pinned     map[string][]byte
+	maxEntries int
+}
+
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		entries:    make(map[string]memoryEntry),
+		pinned:     make(map[string][]byte),
+		maxEntries: maxEntries,
+	}
+}
+
+func (m *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if value, ok := m.pinned[key]; ok {
+		return value, nil
+	}
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if entry.expired(time.Now()) {
+		delete(m.entries, key)
+		return nil, nil
+	}
+	return entry.value, nil
+}
+
+func (m *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ttl <= 0 {
+		delete(m.entries, key)
+		m.pinned[key] = value
+		return nil
+	}
+	delete(m.pinned, key)
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= m.maxEntries {
+		m.evict()
+	}
+	m.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+		delete(m.pinned, key)
+	}
+	return nil
+}
+
+func (m *MemoryCache) evict() {
+	now := time.Now()
+	for key, entry := range m.entries {
+		if entry.expired(now) {
+			delete(m.entries, key)
+		}
+	}
+	for key := range m.entries {
+		if len(m.entries) < m.maxEntries {
+			return
+		}
+		delete(m.entries, key)
+	}
+}
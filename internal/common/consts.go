@@ -40,6 +40,30 @@ func (r *RestErr) RequestNotAllowed(message string) *RestErr {
 	}
 }
 
+func (r *RestErr) Conflict(message string) *RestErr {
+	return &RestErr{
+		Message:    message,
+		Success:    false,
+		StatusCode: http.StatusConflict,
+	}
+}
+
+func (r *RestErr) TooManyRequests(message string) *RestErr {
+	return &RestErr{
+		Message:    message,
+		Success:    false,
+		StatusCode: http.StatusTooManyRequests,
+	}
+}
+
+func (r *RestErr) ServiceUnavailable(message string) *RestErr {
+	return &RestErr{
+		Message:    message,
+		Success:    false,
+		StatusCode: http.StatusServiceUnavailable,
+	}
+}
+
 func NewRestErr() *RestErr {
 	return &RestErr{}
 }
@@ -61,4 +85,45 @@ const (
 	ErrCanOnlyCancelPendingOrder = "only orders in Pending status can be canceled"
 	ErrOrderNotFound             = "order not found"
 	ErrUnauthorized              = "unauthorized"
+	ErrVariantNotFound           = "variant not found"
+	ErrVariantRequired           = "product has variants, a variant must be selected"
+	ErrSKUAlreadyInUse           = "sku already in use"
+	ErrPriceRequiredForCurrency  = "price is required when changing currency"
+	ErrInvalidCursor             = "invalid cursor"
+	ErrProductUnavailable        = "product is not available for purchase"
+	ErrSameCurrencyPair          = "base and quote currency must differ"
+	ErrNoExchangeRate            = "no exchange rate available for the requested currency"
+	ErrCouponNotFound            = "coupon not found"
+	ErrCouponCodeInUse           = "coupon code already in use"
+	ErrCouponInvalid             = "percentage coupons need percent_off and fixed coupons need amount_off"
+	ErrCouponNotActive           = "coupon is not active"
+	ErrCouponUsageLimitReached   = "coupon usage limit reached"
+	ErrCouponMinSpendNotMet      = "order does not meet the coupon's minimum spend"
+	ErrCouponNotApplicable       = "coupon does not apply to any item in the order"
+	ErrTaxRuleNotFound           = "tax rule not found"
+	ErrTaxRuleExists             = "a tax rule already exists for this country, region and tax class"
+	ErrShippingZoneNotFound      = "shipping zone not found"
+	ErrShippingZoneExists        = "a shipping zone with this name already exists"
+	ErrShippingMethodNotFound    = "shipping method not found"
+	ErrShippingMethodInvalid     = "weight_based methods need per_kg and free_over_threshold methods need free_over"
+	ErrShippingMethodUnavailable = "shipping method is not available for this address"
+	ErrShippingMethodRequired    = "choose a shipping method for this order"
+	ErrFulfillmentNotFound       = "fulfillment not found"
+	ErrOrderNotFulfillable       = "order cannot be fulfilled in its current status"
+	ErrInvalidFulfillmentItem    = "fulfillment item is not in the order or exceeds the unshipped quantity"
+	ErrNothingToFulfill          = "every item in this order has already been shipped"
+	ErrOrderNotReturnable        = "only delivered orders can be returned"
+	ErrInvalidReturnItem         = "return item is not in the order or exceeds the quantity that can still be returned"
+	ErrReturnNotFound            = "return not found"
+	ErrReturnAlreadyResolved     = "return has already been approved or rejected"
+	ErrRefundExceedsOrderTotal   = "refund would exceed the amount paid for the order"
+	ErrInvalidIdempotencyKey     = "idempotency key must be at most 255 characters"
+	ErrIdempotencyKeyReused      = "idempotency key was already used for a different request"
+	ErrIdempotencyKeyInProgress  = "a request with this idempotency key is still being processed"
+	ErrInvalidDateRange          = "from and to must be dates (YYYY-MM-DD) or RFC 3339 timestamps, with from before to"
+	ErrWebhookNotFound           = "webhook endpoint not found"
+	ErrWebhookDeliveryNotFound   = "webhook delivery not found"
+	ErrUnknownWebhookEvent       = "events must be known event names, or * for every event"
+	ErrTooManyRequests           = "too many requests, please try again later"
+	ErrRequestTimeout            = "the request took too long, please try again"
 )
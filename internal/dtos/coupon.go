@@ -0,0 +1,28 @@
+package dtos
+
+import (
+	"time"
+)
+
+type CreateCouponRequest struct {
+	Code         string     `json:"code" validate:"required,max=64"`
+	Type         string     `json:"type" validate:"required,oneof=percentage fixed free_shipping"`
+	PercentOff   float64    `json:"percent_off" validate:"omitempty,gt=0,max=100"`
+	AmountOff    int64      `json:"amount_off" validate:"omitempty,min=0"`
+	MinSpend     int64      `json:"min_spend" validate:"omitempty,min=0"`
+	Currency     string     `json:"currency" validate:"omitempty,len=3,alpha"`
+	UsageLimit   *int       `json:"usage_limit" validate:"omitempty,min=1"`
+	PerUserLimit *int       `json:"per_user_limit" validate:"omitempty,min=1"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	ProductIDs   []uint     `json:"product_ids"`
+	Categories   []string   `json:"categories"`
+}
+
+type UpdateCouponRequest struct {
+	IsActive     *bool      `json:"is_active"`
+	UsageLimit   *int       `json:"usage_limit" validate:"omitempty,min=1"`
+	PerUserLimit *int       `json:"per_user_limit" validate:"omitempty,min=1"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+}
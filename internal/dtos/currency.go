@@ -0,0 +1,15 @@
+package dtos
+
+import "time"
+
+type CreateExchangeRateRequest struct {
+	BaseCurrency  string     `json:"base_currency" validate:"required,len=3,alpha"`
+	QuoteCurrency string     `json:"quote_currency" validate:"required,len=3,alpha"`
+	Rate          float64    `json:"rate" validate:"required,gt=0"`
+	EffectiveAt   *time.Time `json:"effective_at" validate:"omitempty"`
+}
+
+type ProductPriceRequest struct {
+	Currency string `json:"currency" validate:"required,len=3,alpha"`
+	Amount   int64  `json:"amount" validate:"min=0"`
+}
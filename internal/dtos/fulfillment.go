@@ -0,0 +1,40 @@
+package dtos
+
+import "time"
+
+// Leaving Items empty ships every unit not yet shipped.
+type CreateFulfillmentRequest struct {
+	Carrier        string                         `json:"carrier" validate:"required,max=100"`
+	TrackingNumber string                         `json:"tracking_number" validate:"omitempty,max=100"`
+	TrackingURL    string                         `json:"tracking_url" validate:"omitempty,url"`
+	Items          []CreateFulfillmentItemRequest `json:"items" validate:"dive"`
+}
+
+type CreateFulfillmentItemRequest struct {
+	OrderItemID uint `json:"order_item_id" validate:"required"`
+	Quantity    int  `json:"quantity" validate:"required,min=1"`
+}
+
+type ShipmentDetail struct {
+	ID             uint                 `json:"id"`
+	Carrier        string               `json:"carrier"`
+	TrackingNumber string               `json:"tracking_number,omitempty"`
+	TrackingURL    string               `json:"tracking_url,omitempty"`
+	Status         string               `json:"status"`
+	ShippedAt      time.Time            `json:"shipped_at"`
+	DeliveredAt    *time.Time           `json:"delivered_at,omitempty"`
+	Items          []ShipmentItemDetail `json:"items"`
+}
+
+type ShipmentItemDetail struct {
+	OrderItemID uint   `json:"order_item_id"`
+	ProductName string `json:"product_name"`
+	SKU         string `json:"sku,omitempty"`
+	Quantity    int    `json:"quantity"`
+}
+
+type TrackingResponse struct {
+	OrderID   uint             `json:"order_id"`
+	Status    string           `json:"status"`
+	Shipments []ShipmentDetail `json:"shipments"`
+}
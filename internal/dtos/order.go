@@ -2,28 +2,82 @@ package dtos
 
 import (
 	"time"
+
+	"instashop/models"
 )
 
+// PlaceOrderRequest may leave out ShippingMethodID while the store has no
+// shipping zones; such orders ship free.
 type PlaceOrderRequest struct {
-	Items []OrderItemRequest `json:"items" validate:"required,min=1"`
+	Items            []OrderItemRequest `json:"items" validate:"required,min=1,dive"`
+	Currency         string             `json:"currency" validate:"omitempty,len=3,alpha"`
+	CouponCode       string             `json:"coupon_code" validate:"omitempty,max=64"`
+	ShippingAddress  Address            `json:"shipping_address" validate:"required"`
+	ShippingMethodID uint               `json:"shipping_method_id" validate:"omitempty"`
+}
+
+type Address struct {
+	Line1      string `json:"line1" validate:"required,max=255"`
+	Line2      string `json:"line2,omitempty" validate:"omitempty,max=255"`
+	City       string `json:"city" validate:"required,max=100"`
+	Region     string `json:"region,omitempty" validate:"omitempty,max=100"`
+	PostalCode string `json:"postal_code,omitempty" validate:"omitempty,max=20"`
+	Country    string `json:"country" validate:"required,len=2,alpha"`
 }
 
 type OrderItemRequest struct {
-	ProductID uint `json:"product_id" validate:"required"`
-	Quantity  int  `json:"quantity" validate:"required,min=1"`
+	ProductID uint  `json:"product_id" validate:"required"`
+	VariantID *uint `json:"variant_id" validate:"omitempty"`
+	Quantity  int   `json:"quantity" validate:"required,min=1"`
 }
 
 type OrderResponse struct {
-	ID         uint              `json:"id"`
-	UserID     uint              `json:"user_id"`
-	Status     string            `json:"status"`
-	TotalPrice float64           `json:"total_price"`
-	Items      []OrderItemDetail `json:"items"`
-	CreatedAt  time.Time         `json:"created_at"`
+	ID              uint                  `json:"id"`
+	UserID          uint                  `json:"user_id"`
+	Status          string                `json:"status"`
+	Subtotal        models.Money          `json:"subtotal"`
+	DiscountTotal   models.Money          `json:"discount_total"`
+	ShippingTotal   models.Money          `json:"shipping_total"`
+	ShippingMethod  string                `json:"shipping_method"`
+	TaxTotal        models.Money          `json:"tax_total"`
+	TaxInclusive    bool                  `json:"tax_inclusive"`
+	TotalPrice      models.Money          `json:"total_price"`
+	ExchangeRate    float64               `json:"exchange_rate"`
+	CouponCode      string                `json:"coupon_code,omitempty"`
+	Discounts       []OrderDiscountDetail `json:"discounts"`
+	Items           []OrderItemDetail     `json:"items"`
+	ShippingAddress Address               `json:"shipping_address"`
+	CreatedAt       time.Time             `json:"created_at"`
+}
+
+type OrderDiscountDetail struct {
+	Code   string       `json:"code"`
+	Type   string       `json:"type"`
+	Amount models.Money `json:"amount"`
 }
 
 type OrderItemDetail struct {
-	ProductID uint    `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ProductID          uint             `json:"product_id"`
+	VariantID          *uint            `json:"variant_id,omitempty"`
+	Quantity           int              `json:"quantity"`
+	Price              models.Money     `json:"price"`
+	ProductName        string           `json:"product_name"`
+	SKU                string           `json:"sku,omitempty"`
+	DescriptionExcerpt string           `json:"description_excerpt,omitempty"`
+	ImageURL           string           `json:"image_url,omitempty"`
+	TaxAmount          models.Money     `json:"tax_amount"`
+	TaxRate            int              `json:"tax_rate"`
+	DiscountAmount     models.Money     `json:"discount_amount"`
+	Product            *ProductResponse `json:"product,omitempty"`
+}
+
+// From and To are dates (2006-01-02) or RFC 3339 timestamps. A date-only To
+// includes the whole day.
+type ListOrdersRequest struct {
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"pageSize" validate:"omitempty,min=1,max=100"`
+	Status   string `query:"status" validate:"omitempty,oneof=pending partially_shipped shipped delivered completed cancelled"`
+	From     string `query:"from"`
+	To       string `query:"to"`
+	Sort     string `query:"sort" validate:"omitempty,oneof=created_at -created_at total -total"`
 }
@@ -1,29 +1,215 @@
 package dtos
 
 import (
+	"encoding/json"
 	"time"
+
+	"instashop/models"
 )
 
+// CreateProductRequest's Price is in minor units of Currency, e.g. 1999 for
+// 19.99 USD. Prices used to be sent as major-unit numbers; one with a
+// fractional part, like 19.99, is still read that way into LegacyPrice so
+// older clients keep working, but a whole number is always minor units.
 type CreateProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description" validate:"required"`
-	Price       float64 `json:"price" validate:"required,min=0"`
-	Stock       int     `json:"stock" validate:"required,min=0"`
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description" validate:"required"`
+	Price       int64    `json:"price" validate:"required_without=LegacyPrice,min=0"`
+	LegacyPrice *float64 `json:"-" validate:"omitempty,min=0"`
+	Currency    string   `json:"currency" validate:"omitempty,len=3,alpha"`
+	Stock       int      `json:"stock" validate:"required,min=0"`
+	ImageURL    string   `json:"image_url" validate:"omitempty,url"`
+	Category    string   `json:"category" validate:"omitempty,max=100"`
+	Status      string   `json:"status" validate:"omitempty,oneof=draft active archived"`
+	TaxClass    string   `json:"tax_class" validate:"omitempty,max=50"`
+	WeightGrams int      `json:"weight_grams" validate:"min=0"`
+	LengthMM    int      `json:"length_mm" validate:"min=0"`
+	WidthMM     int      `json:"width_mm" validate:"min=0"`
+	HeightMM    int      `json:"height_mm" validate:"min=0"`
 }
 
+// UpdateProductRequest's Price is in minor units of the product's currency,
+// or of Currency when it changes it, which needs a Price too. Fractional
+// prices are read into LegacyPrice as for CreateProductRequest.
 type UpdateProductRequest struct {
-	Name        string  `json:"name" validate:"omitempty"`
-	Description string  `json:"description" validate:"omitempty"`
-	Price       float64 `json:"price" validate:"omitempty,min=0"`
-	Stock       int     `json:"stock" validate:"omitempty,min=0"`
+	Name        string   `json:"name" validate:"omitempty"`
+	Description string   `json:"description" validate:"omitempty"`
+	Price       *int64   `json:"price" validate:"omitempty,min=0"`
+	LegacyPrice *float64 `json:"-" validate:"omitempty,min=0"`
+	Currency    string   `json:"currency" validate:"omitempty,len=3,alpha"`
+	Stock       int      `json:"stock" validate:"omitempty,min=0"`
+	ImageURL    string   `json:"image_url" validate:"omitempty,url"`
+	Category    string   `json:"category" validate:"omitempty,max=100"`
+	Status      string   `json:"status" validate:"omitempty,oneof=draft active archived"`
+	TaxClass    string   `json:"tax_class" validate:"omitempty,max=50"`
+	WeightGrams *int     `json:"weight_grams" validate:"omitempty,min=0"`
+	LengthMM    *int     `json:"length_mm" validate:"omitempty,min=0"`
+	WidthMM     *int     `json:"width_mm" validate:"omitempty,min=0"`
+	HeightMM    *int     `json:"height_mm" validate:"omitempty,min=0"`
+}
+
+func (r *CreateProductRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateProductRequest
+	var raw struct {
+		plain
+		Price json.Number `json:"price"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = CreateProductRequest(raw.plain)
+
+	price, legacy, err := parsePrice(raw.Price)
+	if err != nil {
+		return err
+	}
+	if price != nil {
+		r.Price = *price
+	}
+	r.LegacyPrice = legacy
+	return nil
+}
+
+func (r *UpdateProductRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateProductRequest
+	var raw struct {
+		plain
+		Price json.Number `json:"price"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = UpdateProductRequest(raw.plain)
+
+	var err error
+	r.Price, r.LegacyPrice, err = parsePrice(raw.Price)
+	return err
+}
+
+// parsePrice reads a whole-number price as minor units, and one with a
+// fractional part as a legacy major-unit price.
+func parsePrice(number json.Number) (*int64, *float64, error) {
+	if number == "" {
+		return nil, nil, nil
+	}
+	if price, err := number.Int64(); err == nil {
+		return &price, nil, nil
+	}
+	legacy, err := number.Float64()
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &legacy, nil
 }
 
 type ProductResponse struct {
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Stock       int       `json:"stock"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint         `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       models.Money `json:"price"`
+	Stock       int          `json:"stock"`
+	ImageURL    string       `json:"image_url,omitempty"`
+	Category    string       `json:"category,omitempty"`
+	Status      string       `json:"status"`
+	TaxClass    string       `json:"tax_class"`
+	WeightGrams int          `json:"weight_grams"`
+	LengthMM    int          `json:"length_mm"`
+	WidthMM     int          `json:"width_mm"`
+	HeightMM    int          `json:"height_mm"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type CreateVariantRequest struct {
+	SKU     string            `json:"sku" validate:"required"`
+	Price   *int64            `json:"price" validate:"omitempty,min=0"`
+	Stock   int               `json:"stock" validate:"min=0"`
+	Options map[string]string `json:"options" validate:"required,min=1"`
+}
+
+// ImportProductRequest is one product of a catalog import, matched to an
+// existing product by name. Prices are per-currency overrides to set; ones it
+// doesn't list are left alone.
+type ImportProductRequest struct {
+	Name        string                 `json:"name" validate:"required"`
+	Description string                 `json:"description" validate:"required"`
+	Price       int64                  `json:"price" validate:"min=0"`
+	Currency    string                 `json:"currency" validate:"omitempty,len=3,alpha"`
+	Stock       int                    `json:"stock" validate:"min=0"`
+	ImageURL    string                 `json:"image_url" validate:"omitempty,url"`
+	Category    string                 `json:"category" validate:"omitempty,max=100"`
+	Status      string                 `json:"status" validate:"omitempty,oneof=draft active archived"`
+	TaxClass    string                 `json:"tax_class" validate:"omitempty,max=50"`
+	WeightGrams int                    `json:"weight_grams" validate:"min=0"`
+	LengthMM    int                    `json:"length_mm" validate:"min=0"`
+	WidthMM     int                    `json:"width_mm" validate:"min=0"`
+	HeightMM    int                    `json:"height_mm" validate:"min=0"`
+	Prices      []ProductPriceRequest  `json:"prices" validate:"dive"`
+	Variants    []CreateVariantRequest `json:"variants" validate:"dive"`
+}
+
+// CatalogImportResult reports what a catalog import changed. ProductIDs are
+// the products created or updated.
+type CatalogImportResult struct {
+	Created    int    `json:"created"`
+	Updated    int    `json:"updated"`
+	ProductIDs []uint `json:"product_ids"`
+}
+
+type UpdateVariantRequest struct {
+	SKU   string `json:"sku" validate:"omitempty"`
+	Price *int64 `json:"price" validate:"omitempty,min=0"`
+	Stock *int   `json:"stock" validate:"omitempty,min=0"`
+}
+
+// SearchProductsRequest's MinPrice and MaxPrice are minor units of Currency,
+// or of the store currency when it's empty.
+type SearchProductsRequest struct {
+	Query    string `query:"q" validate:"omitempty,max=200"`
+	MinPrice *int64 `query:"min_price" validate:"omitempty,min=0"`
+	MaxPrice *int64 `query:"max_price" validate:"omitempty,min=0"`
+	InStock  bool   `query:"in_stock"`
+	Currency string `query:"currency" validate:"omitempty,len=3,alpha"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"pageSize" validate:"omitempty,min=1,max=100"`
+}
+
+type ProductSearchHit struct {
+	Product ProductResponse `json:"product"`
+	Rank    float64         `json:"rank"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// ProductSearchFacets' price range labels are in major units of Currency.
+type ProductSearchFacets struct {
+	Currency     string       `json:"currency"`
+	PriceRanges  []FacetCount `json:"price_ranges"`
+	Availability []FacetCount `json:"availability"`
+}
+
+type ProductSearchResponse struct {
+	Results    []ProductSearchHit  `json:"results"`
+	Facets     ProductSearchFacets `json:"facets"`
+	TotalCount int64               `json:"total_count"`
+	Fuzzy      bool                `json:"fuzzy"`
+}
+
+// ListProductsRequest's MinPrice and MaxPrice are minor units of Currency,
+// or of the store currency when it's empty.
+type ListProductsRequest struct {
+	Mode     string `query:"mode" validate:"omitempty,oneof=offset cursor"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"pageSize" validate:"omitempty,min=1,max=100"`
+	Cursor   string `query:"cursor"`
+	Sort     string `query:"sort" validate:"omitempty,oneof=price -price created_at -created_at name -name"`
+	Status   string `query:"status" validate:"omitempty,oneof=draft active archived"`
+	MinPrice *int64 `query:"min_price" validate:"omitempty,min=0"`
+	MaxPrice *int64 `query:"max_price" validate:"omitempty,min=0"`
+	InStock  bool   `query:"in_stock"`
+	Deleted  bool   `query:"deleted"`
+	Currency string `query:"currency" validate:"omitempty,len=3,alpha"`
 }
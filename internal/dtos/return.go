@@ -0,0 +1,57 @@
+package dtos
+
+import (
+	"time"
+
+	"instashop/models"
+)
+
+type CreateReturnRequest struct {
+	Items     []ReturnItemRequest `json:"items" validate:"required,min=1,dive"`
+	Reason    string              `json:"reason" validate:"required,max=1000"`
+	PhotoURLs []string            `json:"photo_urls" validate:"max=10,dive,url"`
+}
+
+type ReturnItemRequest struct {
+	OrderItemID uint `json:"order_item_id" validate:"required"`
+	Quantity    int  `json:"quantity" validate:"required,min=1"`
+}
+
+// RefundAmount is in minor units of the order's currency. When omitted the
+// refund is the price paid for the returned units, including any tax added
+// on top.
+type ApproveReturnRequest struct {
+	RefundAmount *int64               `json:"refund_amount" validate:"omitempty,min=0"`
+	Restock      []RestockItemRequest `json:"restock" validate:"dive"`
+	Note         string               `json:"note" validate:"omitempty,max=1000"`
+}
+
+type RestockItemRequest struct {
+	ReturnItemID uint `json:"return_item_id" validate:"required"`
+	Restock      bool `json:"restock"`
+}
+
+type RejectReturnRequest struct {
+	Note string `json:"note" validate:"required,max=1000"`
+}
+
+type ReturnResponse struct {
+	ID           uint               `json:"id"`
+	OrderID      uint               `json:"order_id"`
+	UserID       uint               `json:"user_id"`
+	Status       string             `json:"status"`
+	Reason       string             `json:"reason"`
+	PhotoURLs    []string           `json:"photo_urls"`
+	AdminNote    string             `json:"admin_note,omitempty"`
+	RefundAmount models.Money       `json:"refund_amount"`
+	Items        []ReturnItemDetail `json:"items"`
+	ResolvedAt   *time.Time         `json:"resolved_at,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+type ReturnItemDetail struct {
+	ID          uint `json:"id"`
+	OrderItemID uint `json:"order_item_id"`
+	Quantity    int  `json:"quantity"`
+	Restock     bool `json:"restock"`
+}
@@ -0,0 +1,39 @@
+package dtos
+
+import "instashop/models"
+
+type CreateShippingZoneRequest struct {
+	Name      string   `json:"name" validate:"required,max=100"`
+	Countries []string `json:"countries" validate:"dive,len=2,alpha"`
+}
+
+// Amounts are in minor units of Currency, which defaults to the store's.
+type CreateShippingMethodRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Type     string `json:"type" validate:"required,oneof=flat weight_based free_over_threshold"`
+	Rate     int64  `json:"rate" validate:"min=0"`
+	PerKg    int64  `json:"per_kg" validate:"min=0"`
+	FreeOver int64  `json:"free_over" validate:"min=0"`
+	Currency string `json:"currency" validate:"omitempty,len=3,alpha"`
+}
+
+type UpdateShippingMethodRequest struct {
+	Name     string `json:"name" validate:"omitempty,max=100"`
+	Rate     *int64 `json:"rate" validate:"omitempty,min=0"`
+	PerKg    *int64 `json:"per_kg" validate:"omitempty,min=0"`
+	FreeOver *int64 `json:"free_over" validate:"omitempty,min=0"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type ShippingQuoteRequest struct {
+	Items           []OrderItemRequest `json:"items" validate:"required,min=1,dive"`
+	Currency        string             `json:"currency" validate:"omitempty,len=3,alpha"`
+	ShippingAddress Address            `json:"shipping_address" validate:"required"`
+}
+
+type ShippingQuote struct {
+	MethodID uint         `json:"method_id"`
+	Name     string       `json:"name"`
+	Type     string       `json:"type"`
+	Cost     models.Money `json:"cost"`
+}
@@ -0,0 +1,15 @@
+package dtos
+
+// Rate is a percentage, e.g. 7.5 for 7.5%.
+type TaxRuleRequest struct {
+	Name     string  `json:"name" validate:"required,max=100"`
+	Country  string  `json:"country" validate:"required,len=2,alpha"`
+	Region   string  `json:"region" validate:"omitempty,max=100"`
+	TaxClass string  `json:"tax_class" validate:"omitempty,max=50"`
+	Rate     float64 `json:"rate" validate:"min=0,max=100"`
+}
+
+type UpdateTaxRuleRequest struct {
+	Name string   `json:"name" validate:"omitempty,max=100"`
+	Rate *float64 `json:"rate" validate:"omitempty,min=0,max=100"`
+}
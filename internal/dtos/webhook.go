@@ -0,0 +1,56 @@
+package dtos
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Events are event names such as order.placed, or * for every event.
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2048"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Events      []string `json:"events" validate:"required,min=1,dive,required"`
+}
+
+type UpdateWebhookRequest struct {
+	URL          string   `json:"url" validate:"omitempty,url,max=2048"`
+	Description  *string  `json:"description" validate:"omitempty,max=255"`
+	Events       []string `json:"events" validate:"omitempty,min=1,dive,required"`
+	IsActive     *bool    `json:"is_active"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+// Secret is only included when the endpoint is created or its secret
+// rotated.
+type WebhookEndpointResponse struct {
+	ID          uint      `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"`
+	IsActive    bool      `json:"is_active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ListWebhookDeliveriesRequest struct {
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"pageSize" validate:"omitempty,min=1,max=100"`
+	Status   string `query:"status" validate:"omitempty,oneof=pending succeeded failed"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint            `json:"id"`
+	EndpointID     uint            `json:"endpoint_id"`
+	EventID        uint            `json:"event_id"`
+	EventName      string          `json:"event_name"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	ResponseBody   string          `json:"response_body,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
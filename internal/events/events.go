@@ -0,0 +1,138 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"instashop/models"
+)
+
+const (
+	UserSignedUp    = "user.signed_up"
+	OrderPlaced     = "order.placed"
+	OrderCancelled  = "order.cancelled"
+	OrderExpired    = "order.expired"
+	OrderShipped    = "order.shipped"
+	OrderDelivered  = "order.delivered"
+	ProductCreated  = "product.created"
+	ProductUpdated  = "product.updated"
+	ProductDeleted  = "product.deleted"
+	ProductStockLow = "product.stock_low"
+)
+
+// Names lists every event the shop records.
+var Names = []string{
+	UserSignedUp,
+	OrderPlaced,
+	OrderCancelled,
+	OrderExpired,
+	OrderShipped,
+	OrderDelivered,
+	ProductCreated,
+	ProductUpdated,
+	ProductDeleted,
+	ProductStockLow,
+}
+
+// Event is something that happened in the shop that other parts of the
+// system may want to react to. ID is the outbox row's ID once the event has
+// been recorded.
+type Event struct {
+	ID         uint        `json:"id,omitempty"`
+	Name       string      `json:"name"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Payload    interface{} `json:"payload"`
+}
+
+func New(name string, payload interface{}) Event {
+	return Event{Name: name, OccurredAt: time.Now().UTC(), Payload: payload}
+}
+
+// Record turns the event into an outbox row, ready to be written in the
+// transaction that made the change.
+func (e Event) Record() (*models.OutboxEvent, error) {
+	payload, err := json.Marshal(e.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutboxEvent{
+		Name:          e.Name,
+		Payload:       payload,
+		OccurredAt:    e.OccurredAt,
+		Status:        models.OutboxStatusPending,
+		NextAttemptAt: e.OccurredAt,
+	}, nil
+}
+
+type UserPayload struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+type OrderPayload struct {
+	OrderID uint         `json:"order_id"`
+	UserID  uint         `json:"user_id"`
+	Status  string       `json:"status"`
+	Total   models.Money `json:"total"`
+}
+
+type ProductPayload struct {
+	ProductID uint         `json:"product_id"`
+	Name      string       `json:"name"`
+	Status    string       `json:"status"`
+	Price     models.Money `json:"price"`
+	Stock     int          `json:"stock"`
+}
+
+// StockPayload reports a product, or one of its variants, whose stock fell
+// to or below the low stock threshold.
+type StockPayload struct {
+	ProductID uint  `json:"product_id"`
+	VariantID *uint `json:"variant_id,omitempty"`
+	Stock     int   `json:"stock"`
+	Threshold int   `json:"threshold"`
+}
+
+type Handler func(Event)
+
+// LogEvent is a Handler that writes the event to the log.
+func LogEvent(event Event) {
+	payload, _ := json.Marshal(event.Payload)
+	log.Infof("event %s: %s", event.Name, payload)
+}
+
+// Bus delivers events to the handlers subscribed to them, in process and
+// synchronously. A handler that panics doesn't stop the others.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+func (b *Bus) Publish(event Event) {
+	b.mu.RLock()
+	handlers := b.handlers[event.Name]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorf("event handler for %s panicked: %v", event.Name, r)
+				}
+			}()
+			handler(event)
+		}()
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// Message is an outbox event as it goes out to subscribers. ID is the
+// outbox row's ID, so consumers can drop the duplicates that retries may
+// cause.
+type Message struct {
+	ID         uint            `json:"id"`
+	Name       string          `json:"name"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Publisher sends messages to a broker.
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+	Close() error
+}
+
+// AMQPPublisher publishes to a durable topic exchange, routed by event name,
+// and waits for the broker to confirm each message. It connects on first use
+// and reconnects after a failure.
+type AMQPPublisher struct {
+	url      string
+	exchange string
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+}
+
+func NewAMQPPublisher(url, exchange string) *AMQPPublisher {
+	return &AMQPPublisher{url: url, exchange: exchange}
+}
+
+func (p *AMQPPublisher) Publish(ctx context.Context, message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	channel, err := p.connect()
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, p.exchange, message.Name, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    strconv.FormatUint(uint64(message.ID), 10),
+		Type:         message.Name,
+		Timestamp:    message.OccurredAt,
+		Body:         body,
+	})
+	if err != nil {
+		p.disconnect()
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		p.disconnect()
+		return err
+	}
+	if !acked {
+		return errors.New("broker rejected message")
+	}
+	return nil
+}
+
+func (p *AMQPPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.disconnect()
+	return nil
+}
+
+func (p *AMQPPublisher) connect() (*amqp.Channel, error) {
+	if p.channel != nil && !p.channel.IsClosed() {
+		return p.channel, nil
+	}
+	p.disconnect()
+
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		return nil, err
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := channel.ExchangeDeclare(p.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := channel.Confirm(false); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	p.conn, p.channel = conn, channel
+	return channel, nil
+}
+
+func (p *AMQPPublisher) disconnect() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn, p.channel = nil, nil
+}
+
+// LogPublisher logs each message and drops it. It stands in for the broker
+// when none is configured, so events still reach the in-process bus without
+// piling up anywhere.
+type LogPublisher struct{}
+
+func NewLogPublisher() LogPublisher {
+	return LogPublisher{}
+}
+
+func (LogPublisher) Publish(ctx context.Context, message Message) error {
+	log.Debugw("event not sent to a broker", zap.Uint("id", message.ID), zap.String("event", message.Name))
+	return nil
+}
+
+func (LogPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+const (
+	relayBatchSize   = 100
+	relayMaxAttempts = 12
+	relayBaseBackoff = 5 * time.Second
+	relayMaxBackoff  = time.Hour
+)
+
+// Relay moves events from the outbox to the publisher. Delivery is at least
+// once: an event published just before a crash is published again. After
+// publishing, the event is also handed to the in-process bus.
+type Relay struct {
+	outboxRepo repositories.OutboxStore
+	publisher  Publisher
+	bus        *Bus
+}
+
+func NewRelay(outboxRepo repositories.OutboxStore, publisher Publisher, bus *Bus) *Relay {
+	return &Relay{
+		outboxRepo,
+		publisher,
+		bus,
+	}
+}
+
+// Run publishes the events that are due and returns how many went out.
+// Failed events are retried with exponential backoff and marked failed
+// after relayMaxAttempts.
+func (r *Relay) Run(ctx context.Context) (int, error) {
+	due, err := r.outboxRepo.FindDue(time.Now(), relayBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range due {
+		if ctx.Err() != nil {
+			return published, ctx.Err()
+		}
+
+		message := Message{ID: event.ID, Name: event.Name, OccurredAt: event.OccurredAt, Payload: event.Payload}
+		if err := r.publisher.Publish(ctx, message); err != nil {
+			if err := r.retryLater(event, err); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		r.bus.Publish(Event{ID: event.ID, Name: event.Name, OccurredAt: event.OccurredAt, Payload: message.Payload})
+		if err := r.outboxRepo.MarkPublished(event.ID, time.Now()); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+func (r *Relay) retryLater(event models.OutboxEvent, publishErr error) error {
+	attempts := event.Attempts + 1
+	status := models.OutboxStatusPending
+	if attempts >= relayMaxAttempts {
+		status = models.OutboxStatusFailed
+		log.Errorw("giving up on outbox event", zap.Uint("id", event.ID), zap.String("event", event.Name), zap.Error(publishErr))
+	} else {
+		log.Warnw("publishing outbox event failed", zap.Uint("id", event.ID), zap.String("event", event.Name), zap.Error(publishErr))
+	}
+	return r.outboxRepo.MarkFailed(event.ID, status, time.Now().Add(backoff(attempts)), publishErr.Error())
+}
+
+// backoff doubles the wait after each failed attempt, up to relayMaxBackoff.
+func backoff(attempts int) time.Duration {
+	delay := relayBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= relayMaxBackoff {
+			return relayMaxBackoff
+		}
+	}
+	return delay
+}
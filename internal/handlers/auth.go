@@ -33,7 +33,7 @@ func (a *AuthHandler) Login(c *fiber.Ctx) error {
 		err := a.restErr.ServerError(common.ErrSomethingWentWrong)
 		return c.Status(err.StatusCode).JSON(err)
 	}
-	resp, srvErr := a.authSvc.Login(input)
+	resp, srvErr := a.authSvc.Login(c.UserContext(), input)
 	if srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
@@ -59,7 +59,7 @@ func (a *AuthHandler) Signup(c *fiber.Ctx) error {
 		return c.Status(err.StatusCode).JSON(err)
 	}
 
-	resp, err := a.authSvc.Signup(input)
+	resp, err := a.authSvc.Signup(c.UserContext(), input)
 	if err != nil {
 		return c.Status(err.StatusCode).JSON(err)
 	}
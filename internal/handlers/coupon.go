@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/services"
+)
+
+type CouponHandler struct {
+	couponSvc services.CouponClient
+	restErr   *common.RestErr
+}
+
+func NewCouponHandler(couponSvc services.CouponClient,
+	restErr *common.RestErr) *CouponHandler {
+	return &CouponHandler{couponSvc, restErr}
+}
+
+func (h *CouponHandler) CreateCoupon(c *fiber.Ctx) error {
+	input, ok := c.Locals("input").(dtos.CreateCouponRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to CreateCouponRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	coupon, srvErr := h.couponSvc.CreateCoupon(input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Coupon created successfully",
+		"data":    coupon,
+	})
+}
+
+func (h *CouponHandler) ListCoupons(c *fiber.Ctx) error {
+	coupons, srvErr := h.couponSvc.ListCoupons()
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Coupons retrieved successfully",
+		"data":    coupons,
+	})
+}
+
+func (h *CouponHandler) GetCoupon(c *fiber.Ctx) error {
+	couponID, err := c.ParamsInt("couponID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.BadRequest(common.ErrCouponNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	coupon, srvErr := h.couponSvc.GetCoupon(uint(couponID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Coupon retrieved successfully",
+		"data":    coupon,
+	})
+}
+
+func (h *CouponHandler) UpdateCoupon(c *fiber.Ctx) error {
+	couponID, err := c.ParamsInt("couponID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.BadRequest(common.ErrCouponNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.UpdateCouponRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to UpdateCouponRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	coupon, srvErr := h.couponSvc.UpdateCoupon(uint(couponID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Coupon updated successfully",
+		"data":    coupon,
+	})
+}
+
+func (h *CouponHandler) DeleteCoupon(c *fiber.Ctx) error {
+	couponID, err := c.ParamsInt("couponID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.BadRequest(common.ErrCouponNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if srvErr := h.couponSvc.DeleteCoupon(uint(couponID)); srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Coupon deleted successfully",
+	})
+}
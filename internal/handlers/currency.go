@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/services"
+)
+
+type CurrencyHandler struct {
+	currencySvc services.CurrencyClient
+	restErr     *common.RestErr
+}
+
+func NewCurrencyHandler(currencySvc services.CurrencyClient,
+	restErr *common.RestErr) *CurrencyHandler {
+	return &CurrencyHandler{currencySvc, restErr}
+}
+
+func (h *CurrencyHandler) CreateExchangeRate(c *fiber.Ctx) error {
+	input, ok := c.Locals("input").(dtos.CreateExchangeRateRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to CreateExchangeRateRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	rate, srvErr := h.currencySvc.CreateExchangeRate(input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Exchange rate created successfully",
+		"data":    rate,
+	})
+}
+
+func (h *CurrencyHandler) ListExchangeRates(c *fiber.Ctx) error {
+	rates, srvErr := h.currencySvc.ListExchangeRates(c.Query("base"), c.Query("quote"))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Exchange rates retrieved successfully",
+		"data":    rates,
+	})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/services"
+	"instashop/internal/utils"
+)
+
+type FulfillmentHandler struct {
+	fulfillmentSvc services.FulfillmentClient
+	restErr        *common.RestErr
+}
+
+func NewFulfillmentHandler(fulfillmentSvc services.FulfillmentClient,
+	restErr *common.RestErr) *FulfillmentHandler {
+	return &FulfillmentHandler{fulfillmentSvc, restErr}
+}
+
+func (h *FulfillmentHandler) CreateFulfillment(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("orderID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrInvalidOrder)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.CreateFulfillmentRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to CreateFulfillmentRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	shipment, srvErr := h.fulfillmentSvc.CreateFulfillment(c.UserContext(), uint(orderID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Fulfillment created successfully",
+		"data":    shipment,
+	})
+}
+
+func (h *FulfillmentHandler) ListFulfillments(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("orderID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrInvalidOrder)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	shipments, srvErr := h.fulfillmentSvc.ListFulfillments(c.UserContext(), uint(orderID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Fulfillments retrieved successfully",
+		"data":    shipments,
+	})
+}
+
+func (h *FulfillmentHandler) MarkDelivered(c *fiber.Ctx) error {
+	fulfillmentID, err := c.ParamsInt("fulfillmentID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrFulfillmentNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if srvErr := h.fulfillmentSvc.MarkDelivered(c.UserContext(), uint(fulfillmentID)); srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Fulfillment marked as delivered successfully",
+	})
+}
+
+func (h *FulfillmentHandler) GetTracking(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("orderID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrInvalidOrder)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+	userID, err := utils.GetAuthUserIdFromContext(c)
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	tracking, srvErr := h.fulfillmentSvc.GetTracking(c.UserContext(), userID, uint(orderID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Tracking retrieved successfully",
+		"data":    tracking,
+	})
+}
@@ -41,7 +41,7 @@ func (o *OrderHandler) PlaceOrder(c *fiber.Ctx) error {
 		err := o.restErr.ServerError(common.ErrSomethingWentWrong)
 		return c.Status(err.StatusCode).JSON(err)
 	}
-	order, srvErr := o.orderSvc.PlaceOrder(input, userID)
+	order, srvErr := o.orderSvc.PlaceOrder(c.UserContext(), input, userID)
 	if srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
@@ -53,21 +53,87 @@ func (o *OrderHandler) PlaceOrder(c *fiber.Ctx) error {
 	})
 }
 
+func (o *OrderHandler) QuoteShipping(c *fiber.Ctx) error {
+	input, ok := c.Locals("input").(dtos.ShippingQuoteRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to ShippingQuoteRequest"))
+		err := o.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	quotes, srvErr := o.orderSvc.QuoteShipping(c.UserContext(), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Shipping quotes retrieved successfully",
+		"data":    quotes,
+	})
+}
+
+func (o *OrderHandler) GetOrder(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("orderID")
+	if err != nil {
+		err := o.restErr.BadRequest(common.ErrInvalidOrder)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+	userID, err := utils.GetAuthUserIdFromContext(c)
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := o.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	order, srvErr := o.orderSvc.GetOrder(c.UserContext(), userID, uint(orderID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Order retrieved successfully",
+		"data":    order,
+	})
+}
+
 func (o *OrderHandler) ListOrders(c *fiber.Ctx) error {
+	input, ok := c.Locals("input").(dtos.ListOrdersRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to ListOrdersRequest"))
+		err := o.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
 	userID, err := utils.GetAuthUserIdFromContext(c)
 	if err != nil {
 		log.Error(zap.Error(err))
 		err := o.restErr.ServerError(common.ErrSomethingWentWrong)
 		return c.Status(err.StatusCode).JSON(err)
 	}
-	orders, srvErr := o.orderSvc.ListOrders(userID)
+
+	orders, totalCount, srvErr := o.orderSvc.ListOrders(c.UserContext(), userID, input)
 	if srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
+
+	page, pageSize := input.Page, input.PageSize
+	totalPages := (int(totalCount) + pageSize - 1) / pageSize
+	nextPage := page + 1
+	if page >= totalPages {
+		nextPage = 0
+	}
+
 	return c.Status(200).JSON(fiber.Map{
 		"success": true,
 		"message": "Orders retrieved successfully",
 		"data":    orders,
+		"pagination": fiber.Map{
+			"currentPage": page,
+			"pageSize":    pageSize,
+			"totalPages":  totalPages,
+			"totalCount":  totalCount,
+			"nextPage":    nextPage,
+		},
 	})
 }
 
@@ -85,7 +151,7 @@ func (o *OrderHandler) CancelOrder(c *fiber.Ctx) error {
 		return c.Status(err.StatusCode).JSON(err)
 	}
 
-	srvErr := o.orderSvc.CancelOrder(uint(orderID), userID)
+	srvErr := o.orderSvc.CancelOrder(c.UserContext(), userID, uint(orderID))
 	if srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"strconv"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
@@ -9,6 +9,7 @@ import (
 	"instashop/internal/common"
 	"instashop/internal/dtos"
 	"instashop/internal/services"
+	"instashop/models"
 )
 
 type ProductHandler struct {
@@ -29,7 +30,7 @@ func (p *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 		return c.Status(err.StatusCode).JSON(err)
 	}
 
-	product, srvErr := p.productSvc.CreateProducts(input)
+	product, srvErr := p.productSvc.CreateProducts(c.UserContext(), input)
 	if srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
@@ -49,7 +50,26 @@ func (p *ProductHandler) GetProduct(c *fiber.Ctx) error {
 		return c.Status(err.StatusCode).JSON(err)
 	}
 
-	product, srvErr := p.productSvc.GetProduct(uint(productID))
+	product, srvErr := p.productSvc.GetProduct(c.UserContext(), uint(productID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Product retrieved successfully",
+		"data":    product,
+	})
+}
+
+func (p *ProductHandler) GetCatalogProduct(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("productID")
+	if err != nil {
+		err := p.restErr.BadRequest(common.ErrProductNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	product, srvErr := p.productSvc.GetCatalogProduct(c.UserContext(), uint(productID), c.Query("currency"))
 	if srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
@@ -77,7 +97,7 @@ func (p *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	product, srvErr := p.productSvc.UpdateProduct(uint(productID), input)
+	product, srvErr := p.productSvc.UpdateProduct(c.UserContext(), uint(productID), input)
 	if srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
@@ -97,7 +117,7 @@ func (p *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 		return c.Status(err.StatusCode).JSON(err)
 	}
 
-	if srvErr := p.productSvc.DeleteProduct(uint(productID)); srvErr != nil {
+	if srvErr := p.productSvc.DeleteProduct(c.UserContext(), uint(productID)); srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
 
@@ -107,24 +127,69 @@ func (p *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 	})
 }
 
+func (p *ProductHandler) RestoreProduct(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("productID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if srvErr := p.productSvc.RestoreProduct(c.UserContext(), uint(productID)); srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Product restored successfully",
+	})
+}
+
 func (p *ProductHandler) ListProducts(c *fiber.Ctx) error {
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page < 1 {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid page parameter",
-		})
+	input, ok := c.Locals("input").(dtos.ListProductsRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to ListProductsRequest"))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
 	}
 
-	pageSize, err := strconv.Atoi(c.Query("pageSize", "10"))
-	if err != nil || pageSize < 1 {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid pageSize parameter",
+	return p.listProducts(c, input)
+}
+
+// ListCatalogProducts is the customer-facing listing: only active products.
+func (p *ProductHandler) ListCatalogProducts(c *fiber.Ctx) error {
+	input, ok := c.Locals("input").(dtos.ListProductsRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to ListProductsRequest"))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+	input.Status = string(models.ProductStatusActive)
+	input.Deleted = false
+
+	return p.listProducts(c, input)
+}
+
+func (p *ProductHandler) listProducts(c *fiber.Ctx, input dtos.ListProductsRequest) error {
+	if input.Mode == "cursor" {
+		products, nextCursor, srvErr := p.productSvc.ListProductsByCursor(c.UserContext(), input)
+		if srvErr != nil {
+			return c.Status(srvErr.StatusCode).JSON(srvErr)
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"success": true,
+			"message": "Products retrieved successfully",
+			"data":    products,
+			"pagination": fiber.Map{
+				"pageSize":   input.PageSize,
+				"nextCursor": nextCursor,
+			},
 		})
 	}
 
-	products, totalCount, srvErr := p.productSvc.ListProducts(page, pageSize)
+	page, pageSize := input.Page, input.PageSize
+	products, totalCount, srvErr := p.productSvc.ListProducts(c.UserContext(), input)
 	if srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
@@ -148,3 +213,193 @@ func (p *ProductHandler) ListProducts(c *fiber.Ctx) error {
 		},
 	})
 }
+
+func (p *ProductHandler) CreateVariants(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("productID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").([]dtos.CreateVariantRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to []CreateVariantRequest"))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	variants, srvErr := p.productSvc.CreateVariants(c.UserContext(), uint(productID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Variants created successfully",
+		"data":    variants,
+	})
+}
+
+func (p *ProductHandler) ListVariants(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("productID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	variants, srvErr := p.productSvc.ListVariants(c.UserContext(), uint(productID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Variants retrieved successfully",
+		"data":    variants,
+	})
+}
+
+func (p *ProductHandler) UpdateVariant(c *fiber.Ctx) error {
+	variantID, err := c.ParamsInt("variantID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.UpdateVariantRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to UpdateVariantRequest"))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	variant, srvErr := p.productSvc.UpdateVariant(c.UserContext(), uint(variantID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Variant updated successfully",
+		"data":    variant,
+	})
+}
+
+func (p *ProductHandler) DeleteVariant(c *fiber.Ctx) error {
+	variantID, err := c.ParamsInt("variantID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if srvErr := p.productSvc.DeleteVariant(c.UserContext(), uint(variantID)); srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Variant deleted successfully",
+	})
+}
+
+func (p *ProductHandler) ListOptionTypes(c *fiber.Ctx) error {
+	optionTypes, srvErr := p.productSvc.ListOptionTypes(c.UserContext())
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Option types retrieved successfully",
+		"data":    optionTypes,
+	})
+}
+
+func (p *ProductHandler) SearchProducts(c *fiber.Ctx) error {
+	input, ok := c.Locals("input").(dtos.SearchProductsRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to SearchProductsRequest"))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	resp, srvErr := p.productSvc.SearchProducts(c.UserContext(), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Products retrieved successfully",
+		"data":    resp,
+	})
+}
+
+func (p *ProductHandler) SetProductPrices(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("productID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").([]dtos.ProductPriceRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to []ProductPriceRequest"))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	prices, srvErr := p.productSvc.SetProductPrices(c.UserContext(), uint(productID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Product prices updated successfully",
+		"data":    prices,
+	})
+}
+
+func (p *ProductHandler) ListProductPrices(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("productID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	prices, srvErr := p.productSvc.ListProductPrices(c.UserContext(), uint(productID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Product prices retrieved successfully",
+		"data":    prices,
+	})
+}
+
+func (p *ProductHandler) DeleteProductPrice(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("productID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := p.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if srvErr := p.productSvc.DeleteProductPrice(c.UserContext(), uint(productID), c.Params("currency")); srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Product price deleted successfully",
+	})
+}
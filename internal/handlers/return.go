@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/services"
+	"instashop/internal/utils"
+)
+
+type ReturnHandler struct {
+	returnSvc services.ReturnClient
+	restErr   *common.RestErr
+}
+
+func NewReturnHandler(returnSvc services.ReturnClient,
+	restErr *common.RestErr) *ReturnHandler {
+	return &ReturnHandler{returnSvc, restErr}
+}
+
+func (h *ReturnHandler) RequestReturn(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("orderID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrInvalidOrder)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+	userID, err := utils.GetAuthUserIdFromContext(c)
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.CreateReturnRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to CreateReturnRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	request, srvErr := h.returnSvc.RequestReturn(c.UserContext(), userID, uint(orderID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Return requested successfully",
+		"data":    request,
+	})
+}
+
+func (h *ReturnHandler) ListOrderReturns(c *fiber.Ctx) error {
+	orderID, err := c.ParamsInt("orderID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrInvalidOrder)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+	userID, err := utils.GetAuthUserIdFromContext(c)
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	requests, srvErr := h.returnSvc.ListOrderReturns(c.UserContext(), userID, uint(orderID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Returns retrieved successfully",
+		"data":    requests,
+	})
+}
+
+func (h *ReturnHandler) ListReturns(c *fiber.Ctx) error {
+	requests, srvErr := h.returnSvc.ListReturns(c.UserContext(), c.Query("status"))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Returns retrieved successfully",
+		"data":    requests,
+	})
+}
+
+func (h *ReturnHandler) GetReturn(c *fiber.Ctx) error {
+	returnID, err := c.ParamsInt("returnID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrReturnNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	request, srvErr := h.returnSvc.GetReturn(c.UserContext(), uint(returnID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Return retrieved successfully",
+		"data":    request,
+	})
+}
+
+func (h *ReturnHandler) ApproveReturn(c *fiber.Ctx) error {
+	returnID, err := c.ParamsInt("returnID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrReturnNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.ApproveReturnRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to ApproveReturnRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	request, srvErr := h.returnSvc.ApproveReturn(c.UserContext(), uint(returnID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Return approved successfully",
+		"data":    request,
+	})
+}
+
+func (h *ReturnHandler) RejectReturn(c *fiber.Ctx) error {
+	returnID, err := c.ParamsInt("returnID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrReturnNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.RejectReturnRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to RejectReturnRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	request, srvErr := h.returnSvc.RejectReturn(c.UserContext(), uint(returnID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Return rejected successfully",
+		"data":    request,
+	})
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/services"
+)
+
+type ShippingHandler struct {
+	shippingSvc services.ShippingClient
+	restErr     *common.RestErr
+}
+
+func NewShippingHandler(shippingSvc services.ShippingClient,
+	restErr *common.RestErr) *ShippingHandler {
+	return &ShippingHandler{shippingSvc, restErr}
+}
+
+func (h *ShippingHandler) CreateZone(c *fiber.Ctx) error {
+	input, ok := c.Locals("input").(dtos.CreateShippingZoneRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to CreateShippingZoneRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	zone, srvErr := h.shippingSvc.CreateZone(input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Shipping zone created successfully",
+		"data":    zone,
+	})
+}
+
+func (h *ShippingHandler) ListZones(c *fiber.Ctx) error {
+	zones, srvErr := h.shippingSvc.ListZones()
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Shipping zones retrieved successfully",
+		"data":    zones,
+	})
+}
+
+func (h *ShippingHandler) DeleteZone(c *fiber.Ctx) error {
+	zoneID, err := c.ParamsInt("zoneID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.BadRequest(common.ErrShippingZoneNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if srvErr := h.shippingSvc.DeleteZone(uint(zoneID)); srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Shipping zone deleted successfully",
+	})
+}
+
+func (h *ShippingHandler) CreateMethod(c *fiber.Ctx) error {
+	zoneID, err := c.ParamsInt("zoneID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.BadRequest(common.ErrShippingZoneNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.CreateShippingMethodRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to CreateShippingMethodRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	method, srvErr := h.shippingSvc.CreateMethod(uint(zoneID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Shipping method created successfully",
+		"data":    method,
+	})
+}
+
+func (h *ShippingHandler) UpdateMethod(c *fiber.Ctx) error {
+	methodID, err := c.ParamsInt("methodID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.BadRequest(common.ErrShippingMethodNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.UpdateShippingMethodRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to UpdateShippingMethodRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	method, srvErr := h.shippingSvc.UpdateMethod(uint(methodID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Shipping method updated successfully",
+		"data":    method,
+	})
+}
+
+func (h *ShippingHandler) DeleteMethod(c *fiber.Ctx) error {
+	methodID, err := c.ParamsInt("methodID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.BadRequest(common.ErrShippingMethodNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if srvErr := h.shippingSvc.DeleteMethod(uint(methodID)); srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Shipping method deleted successfully",
+	})
+}
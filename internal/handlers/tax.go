@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/services"
+)
+
+type TaxHandler struct {
+	taxSvc  services.TaxClient
+	restErr *common.RestErr
+}
+
+func NewTaxHandler(taxSvc services.TaxClient,
+	restErr *common.RestErr) *TaxHandler {
+	return &TaxHandler{taxSvc, restErr}
+}
+
+func (h *TaxHandler) CreateTaxRule(c *fiber.Ctx) error {
+	input, ok := c.Locals("input").(dtos.TaxRuleRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to TaxRuleRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	rule, srvErr := h.taxSvc.CreateTaxRule(input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Tax rule created successfully",
+		"data":    rule,
+	})
+}
+
+func (h *TaxHandler) ListTaxRules(c *fiber.Ctx) error {
+	rules, srvErr := h.taxSvc.ListTaxRules(c.Query("country"))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Tax rules retrieved successfully",
+		"data":    rules,
+	})
+}
+
+func (h *TaxHandler) UpdateTaxRule(c *fiber.Ctx) error {
+	ruleID, err := c.ParamsInt("ruleID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.BadRequest(common.ErrTaxRuleNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.UpdateTaxRuleRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to UpdateTaxRuleRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	rule, srvErr := h.taxSvc.UpdateTaxRule(uint(ruleID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Tax rule updated successfully",
+		"data":    rule,
+	})
+}
+
+func (h *TaxHandler) DeleteTaxRule(c *fiber.Ctx) error {
+	ruleID, err := c.ParamsInt("ruleID")
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := h.restErr.BadRequest(common.ErrTaxRuleNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if srvErr := h.taxSvc.DeleteTaxRule(uint(ruleID)); srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Tax rule deleted successfully",
+	})
+}
@@ -33,7 +33,7 @@ func (u *UserHandler) GetUserDetails(c *fiber.Ctx) error {
 		return c.Status(err.StatusCode).JSON(err)
 	}
 
-	resp, srvErr := u.userSvc.GetUserDetails(userId)
+	resp, srvErr := u.userSvc.GetUserDetails(c.UserContext(), userId)
 	if srvErr != nil {
 		return c.Status(srvErr.StatusCode).JSON(srvErr)
 	}
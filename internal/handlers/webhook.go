@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/services"
+)
+
+type WebhookHandler struct {
+	webhookSvc services.WebhookClient
+	restErr    *common.RestErr
+}
+
+func NewWebhookHandler(webhookSvc services.WebhookClient,
+	restErr *common.RestErr) *WebhookHandler {
+	return &WebhookHandler{webhookSvc, restErr}
+}
+
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	input, ok := c.Locals("input").(dtos.CreateWebhookRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to CreateWebhookRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	endpoint, srvErr := h.webhookSvc.CreateWebhook(c.UserContext(), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"message": "Webhook created successfully",
+		"data":    endpoint,
+	})
+}
+
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	endpoints, srvErr := h.webhookSvc.ListWebhooks(c.UserContext())
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Webhooks retrieved successfully",
+		"data":    endpoints,
+	})
+}
+
+func (h *WebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	endpointID, err := c.ParamsInt("endpointID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrWebhookNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	endpoint, srvErr := h.webhookSvc.GetWebhook(c.UserContext(), uint(endpointID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Webhook retrieved successfully",
+		"data":    endpoint,
+	})
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	endpointID, err := c.ParamsInt("endpointID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrWebhookNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.UpdateWebhookRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to UpdateWebhookRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	endpoint, srvErr := h.webhookSvc.UpdateWebhook(c.UserContext(), uint(endpointID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Webhook updated successfully",
+		"data":    endpoint,
+	})
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	endpointID, err := c.ParamsInt("endpointID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrWebhookNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if srvErr := h.webhookSvc.DeleteWebhook(c.UserContext(), uint(endpointID)); srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Webhook deleted successfully",
+	})
+}
+
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	endpointID, err := c.ParamsInt("endpointID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrWebhookNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	input, ok := c.Locals("input").(dtos.ListWebhookDeliveriesRequest)
+	if !ok {
+		log.Error(fmt.Errorf("cannot convert validated data to ListWebhookDeliveriesRequest"))
+		err := h.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	deliveries, totalCount, srvErr := h.webhookSvc.ListDeliveries(c.UserContext(), uint(endpointID), input)
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	page, pageSize := input.Page, input.PageSize
+	totalPages := (int(totalCount) + pageSize - 1) / pageSize
+	nextPage := page + 1
+	if page >= totalPages {
+		nextPage = 0
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "Deliveries retrieved successfully",
+		"data":    deliveries,
+		"pagination": fiber.Map{
+			"currentPage": page,
+			"pageSize":    pageSize,
+			"totalPages":  totalPages,
+			"totalCount":  totalCount,
+			"nextPage":    nextPage,
+		},
+	})
+}
+
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	deliveryID, err := c.ParamsInt("deliveryID")
+	if err != nil {
+		err := h.restErr.BadRequest(common.ErrWebhookDeliveryNotFound)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	delivery, srvErr := h.webhookSvc.Redeliver(c.UserContext(), uint(deliveryID))
+	if srvErr != nil {
+		return c.Status(srvErr.StatusCode).JSON(srvErr)
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"success": true,
+		"message": "Delivery queued for redelivery",
+		"data":    delivery,
+	})
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"instashop/internal/cache"
+	"instashop/internal/events"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/internal/utils"
+)
+
+// StartJobs schedules the app's background jobs and runs them until ctx is
+// cancelled. Outbox events are sent to publisher and then to bus.
+func StartJobs(ctx context.Context, db *gorm.DB, bus *events.Bus, publisher events.Publisher) {
+	transactor := repositories.NewTransactor(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	scheduler := NewScheduler(transactor)
+
+	webhooks := services.NewWebhookDispatcher(repositories.NewWebhookRepository(db), outboxRepo)
+	scheduler.Add(Job{
+		Name:     "queue-webhooks",
+		Interval: utils.GetConfig().WebhookDeliveryInterval,
+		Run: func() error {
+			_, err := webhooks.QueueDeliveries(ctx)
+			return err
+		},
+	})
+	scheduler.Add(Job{
+		Name:     "deliver-webhooks",
+		Interval: utils.GetConfig().WebhookDeliveryInterval,
+		Run: func() error {
+			_, err := webhooks.DeliverDue(ctx)
+			return err
+		},
+	})
+
+	relay := events.NewRelay(outboxRepo, publisher, bus)
+	scheduler.Add(Job{
+		Name:     "relay-outbox",
+		Interval: utils.GetConfig().OutboxRelayInterval,
+		Run: func() error {
+			_, err := relay.Run(ctx)
+			return err
+		},
+	})
+
+	orderExpirySvc := services.NewOrderExpiryService(
+		transactor,
+		repositories.NewOrderRepository(db),
+		repositories.NewProductRepository(db),
+		repositories.NewVariantRepository(db),
+		repositories.NewCouponRepository(db),
+		outboxRepo,
+		services.NewProductCache(cache.Default(), utils.GetConfig().CacheTTL),
+	)
+	ttl := utils.GetConfig().PendingOrderTTL
+	scheduler.Add(Job{
+		Name:     "expire-pending-orders",
+		Interval: utils.GetConfig().OrderExpiryInterval,
+		Run: func() error {
+			expired, err := orderExpirySvc.ExpirePendingOrders(ctx, ttl)
+			if expired > 0 {
+				log.Infof("expired %d pending orders", expired)
+			}
+			return err
+		},
+	})
+
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	scheduler.Add(Job{
+		Name:     "purge-idempotency-keys",
+		Interval: utils.GetConfig().IdempotencyPurgeInterval,
+		Run: func() error {
+			deleted, err := idempotencyRepo.WithContext(ctx).DeleteExpired(time.Now())
+			if deleted > 0 {
+				log.Infof("deleted %d expired idempotency keys", deleted)
+			}
+			return err
+		},
+	})
+
+	scheduler.Start(ctx)
+}
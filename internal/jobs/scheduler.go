@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/repositories"
+)
+
+// Job is work the scheduler runs every Interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+// Scheduler runs background jobs on their intervals. Every run takes a
+// Postgres advisory lock derived from the job name, so when several app
+// instances are up each run happens on only one of them.
+type Scheduler struct {
+	transactor repositories.TxRunner
+	jobs       []Job
+}
+
+func NewScheduler(transactor repositories.TxRunner) *Scheduler {
+	return &Scheduler{transactor: transactor}
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs each job in its own goroutine until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(job)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(job Job) {
+	ran, err := s.transactor.WithAdvisoryLock(lockKey(job.Name), job.Run)
+	if err != nil {
+		log.Errorw("scheduled job failed", zap.String("job", job.Name), zap.Error(err))
+		return
+	}
+	if !ran {
+		log.Debugw("scheduled job skipped, another instance holds the lock", zap.String("job", job.Name))
+	}
+}
+
+func lockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte("instashop.jobs." + name))
+	return int64(hash.Sum64())
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/repositories"
+	"instashop/internal/utils"
+	"instashop/models"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyTTL is how long a stored response is replayed for.
+	IdempotencyTTL = 24 * time.Hour
+	// IdempotencyLockTimeout is how long a key stays reserved for a request
+	// that hasn't finished. Past it the request is taken to have died with
+	// its instance and a retry may run it again.
+	IdempotencyLockTimeout = time.Minute
+
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyMiddleware makes unsafe requests carrying an Idempotency-Key
+// header safe to retry. The first request with a key runs normally and its
+// response is stored; repeats with the same body get the stored response
+// back, and reuse of the key for a different request is rejected. Requests
+// without the header pass straight through. It must run after
+// ValidateAuthHeaderToken, since keys are scoped per user.
+type IdempotencyMiddleware struct {
+	idempotencyRepo *repositories.IdempotencyRepository
+	restErr         *common.RestErr
+}
+
+func NewIdempotencyMiddleware(
+	idempotencyRepo *repositories.IdempotencyRepository,
+	restErr *common.RestErr,
+) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		idempotencyRepo,
+		restErr,
+	}
+}
+
+func (i *IdempotencyMiddleware) Handle(c *fiber.Ctx) error {
+	key := c.Get(IdempotencyKeyHeader)
+	if key == "" {
+		return c.Next()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		err := i.restErr.BadRequest(common.ErrInvalidIdempotencyKey)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	userID, err := utils.GetAuthUserIdFromContext(c)
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := i.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	record := models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint(c),
+		ExpiresAt:   time.Now().Add(IdempotencyTTL),
+	}
+	reserved, err := i.reserve(&record)
+	if err != nil {
+		log.Error(zap.Error(err))
+		err := i.restErr.ServerError(common.ErrSomethingWentWrong)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	if !reserved {
+		existing, err := i.idempotencyRepo.Find(userID, key)
+		if err != nil || existing == nil {
+			log.Error(zap.Error(err))
+			err := i.restErr.ServerError(common.ErrSomethingWentWrong)
+			return c.Status(err.StatusCode).JSON(err)
+		}
+		return i.replay(c, existing, record.Fingerprint)
+	}
+
+	if err := c.Next(); err != nil {
+		i.release(record.ID)
+		return err
+	}
+
+	// server errors aren't worth replaying; let the client try again
+	status := c.Response().StatusCode()
+	if status >= fiber.StatusInternalServerError {
+		i.release(record.ID)
+		return nil
+	}
+	if err := i.idempotencyRepo.Complete(record.ID, status, c.Response().Body()); err != nil {
+		log.Error(zap.Error(err))
+	}
+	return nil
+}
+
+// reserve claims the key, first clearing it out if an earlier use expired
+// or was abandoned mid-request.
+func (i *IdempotencyMiddleware) reserve(record *models.IdempotencyKey) (bool, error) {
+	reserved, err := i.idempotencyRepo.Reserve(record)
+	if err != nil || reserved {
+		return reserved, err
+	}
+
+	existing, err := i.idempotencyRepo.Find(record.UserID, record.Key)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	now := time.Now()
+	switch {
+	case !existing.ExpiresAt.After(now):
+		err = i.idempotencyRepo.Delete(existing.ID)
+	case existing.StatusCode == 0 && existing.CreatedAt.Before(now.Add(-IdempotencyLockTimeout)):
+		// only if it is still unfinished, so a response that just landed
+		// isn't thrown away
+		var deleted bool
+		if deleted, err = i.idempotencyRepo.DeleteInProgress(existing.ID); err == nil && !deleted {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return i.idempotencyRepo.Reserve(record)
+}
+
+func (i *IdempotencyMiddleware) replay(c *fiber.Ctx, existing *models.IdempotencyKey, fingerprint string) error {
+	if existing.Fingerprint != fingerprint {
+		err := i.restErr.Conflict(common.ErrIdempotencyKeyReused)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+	if existing.StatusCode == 0 {
+		err := i.restErr.Conflict(common.ErrIdempotencyKeyInProgress)
+		return c.Status(err.StatusCode).JSON(err)
+	}
+
+	c.Set("Idempotent-Replayed", "true")
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Status(existing.StatusCode).Send(existing.ResponseBody)
+}
+
+func (i *IdempotencyMiddleware) release(recordID uint) {
+	if err := i.idempotencyRepo.Delete(recordID); err != nil {
+		log.Error(zap.Error(err))
+	}
+}
+
+// fingerprint identifies a request by its method, path and body.
+func fingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.OriginalURL()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
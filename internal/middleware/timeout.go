@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"instashop/internal/common"
+)
+
+// RequestTimeout gives each request's context a deadline. Services pass it
+// down to their queries, so work for a request that runs too long is
+// cancelled rather than left holding a connection. A server error caused by
+// the deadline is reported as 503 so clients know to retry.
+//
+// fasthttp doesn't report client disconnects, so the deadline is what
+// bounds a request whose client has gone away.
+func RequestTimeout(timeout time.Duration, restErr *common.RestErr) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			timeoutErr := restErr.ServiceUnavailable(common.ErrRequestTimeout)
+			return c.Status(timeoutErr.StatusCode).JSON(timeoutErr)
+		}
+		return err
+	}
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/redis/go-redis/v9"
+	"instashop/internal/utils"
+)
+
+// Limit is a token bucket holding up to Requests tokens, refilled at
+// Requests per Period. A request takes one token, so Requests is both the
+// sustained rate and the largest burst.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit reads a limit written as "<requests>/<period>", e.g. "10/1m".
+func ParseLimit(s string) (Limit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q: want <requests>/<period>", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: requests must be a positive integer", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: period must be a positive duration", s)
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// MustParseLimit is ParseLimit for limits read at startup; it panics if s
+// is malformed.
+func MustParseLimit(s string) Limit {
+	limit, err := ParseLimit(s)
+	if err != nil {
+		panic(err)
+	}
+	return limit
+}
+
+// perSecond is the refill rate in tokens per second.
+func (l Limit) perSecond() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result is the outcome of taking a token. ResetAfter is how long until the
+// bucket is full again and RetryAfter, for a denied request, how long until
+// the next token is available.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.perSecond()
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit.Requests,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(limit.Requests) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+// Store keeps token buckets and takes tokens from them atomically.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+var (
+	shared     Store
+	sharedOnce sync.Once
+)
+
+// Default returns the process-wide store: Redis, shared by every app
+// instance, when REDIS_ADDR is set, otherwise in memory.
+func Default() Store {
+	sharedOnce.Do(func() {
+		config := utils.GetConfig()
+		if config.RedisAddr != "" {
+			shared = NewRedisStore(redis.NewClient(&redis.Options{
+				Addr:     config.RedisAddr,
+				Password: config.RedisPassword,
+				DB:       config.RedisDB,
+			}))
+			return
+		}
+		log.Info("REDIS_ADDR not set, rate limits are kept in memory")
+		shared = NewMemoryStore()
+	})
+	return shared
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"instashop/models"
+)
+
+var (
+	// ErrCouponExhausted is returned by Redeem when the coupon's global usage
+	// limit has been reached.
+	ErrCouponExhausted = errors.New("coupon usage limit reached")
+	// ErrCouponUserLimitReached is returned by Redeem when the user has used
+	// the coupon as many times as its per-user limit allows.
+	ErrCouponUserLimitReached = errors.New("coupon per-user limit reached")
+)
+
+// couponEditableColumns are what Update writes. times_used is left out so
+// saving a coupon read before a redemption can't roll the counter back.
+var couponEditableColumns = []string{
+	"code", "type", "percent_off", "amount_off_amount", "amount_off_currency",
+	"min_spend_amount", "min_spend_currency", "usage_limit", "per_user_limit",
+	"starts_at", "ends_at", "product_ids", "categories", "is_active", "updated_at",
+}
+
+type CouponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) *CouponRepository {
+	return &CouponRepository{db}
+}
+
+func (c *CouponRepository) WithTx(tx Tx) CouponStore {
+	return &CouponRepository{gormTx(tx)}
+}
+
+func (c *CouponRepository) WithContext(ctx context.Context) CouponStore {
+	return &CouponRepository{c.db.WithContext(ctx)}
+}
+
+func (c *CouponRepository) Create(coupon *models.Coupon) error {
+	return c.db.Create(coupon).Error
+}
+
+func (c *CouponRepository) FindByID(couponID uint) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := c.db.First(&coupon, couponID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (c *CouponRepository) FindByCode(code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := c.db.Where("code = ?", code).First(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (c *CouponRepository) List() ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	if err := c.db.Order("id DESC").Find(&coupons).Error; err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (c *CouponRepository) Update(coupon *models.Coupon) error {
+	return c.db.Model(coupon).Select(couponEditableColumns).Updates(coupon).Error
+}
+
+func (c *CouponRepository) Delete(couponID uint) error {
+	return c.db.Delete(&models.Coupon{}, couponID).Error
+}
+
+func (c *CouponRepository) CountUserRedemptions(couponID, userID uint) (int64, error) {
+	var count int64
+	err := c.db.Model(&models.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ?", couponID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// Redeem records one use of the coupon by userID for orderID. It must run in
+// a transaction: the coupon row is locked while both usage limits are
+// checked, so concurrent orders can't push either past its limit.
+func (c *CouponRepository) Redeem(couponID, userID, orderID uint) error {
+	var coupon models.Coupon
+	err := c.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, couponID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCouponExhausted
+	}
+	if err != nil {
+		return err
+	}
+	if coupon.UsageLimit != nil && coupon.TimesUsed >= *coupon.UsageLimit {
+		return ErrCouponExhausted
+	}
+	if coupon.PerUserLimit != nil {
+		used, err := c.CountUserRedemptions(couponID, userID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(*coupon.PerUserLimit) {
+			return ErrCouponUserLimitReached
+		}
+	}
+
+	err = c.db.Model(&coupon).UpdateColumn("times_used", gorm.Expr("times_used + 1")).Error
+	if err != nil {
+		return err
+	}
+	return c.db.Create(&models.CouponRedemption{
+		CouponID: couponID,
+		UserID:   userID,
+		OrderID:  orderID,
+	}).Error
+}
+
+// Unredeem gives back the coupon uses recorded for orderID, for when the
+// order is cancelled before it is paid.
+func (c *CouponRepository) Unredeem(orderID uint) error {
+	var redemptions []models.CouponRedemption
+	if err := c.db.Where("order_id = ?", orderID).Find(&redemptions).Error; err != nil {
+		return err
+	}
+	for _, redemption := range redemptions {
+		err := c.db.Model(&models.Coupon{}).
+			Where("id = ? AND times_used > 0", redemption.CouponID).
+			UpdateColumn("times_used", gorm.Expr("times_used - 1")).Error
+		if err != nil {
+			return err
+		}
+		if err := c.db.Delete(&redemption).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
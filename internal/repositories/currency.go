@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"instashop/models"
+)
+
+type CurrencyRepository struct {
+	db *gorm.DB
+}
+
+func NewCurrencyRepository(db *gorm.DB) *CurrencyRepository {
+	return &CurrencyRepository{db}
+}
+
+func (c *CurrencyRepository) WithTx(tx Tx) CurrencyStore {
+	return &CurrencyRepository{gormTx(tx)}
+}
+
+func (c *CurrencyRepository) WithContext(ctx context.Context) CurrencyStore {
+	return &CurrencyRepository{c.db.WithContext(ctx)}
+}
+
+func (c *CurrencyRepository) CreateExchangeRate(rate *models.ExchangeRate) error {
+	return c.db.Create(rate).Error
+}
+
+func (c *CurrencyRepository) ListExchangeRates(base, quote string) ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	query := c.db.Order("effective_at DESC, id DESC")
+	if base != "" {
+		query = query.Where("base_currency = ?", base)
+	}
+	if quote != "" {
+		query = query.Where("quote_currency = ?", quote)
+	}
+	if err := query.Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// FindEffectiveRate returns the most recent base→quote rate in effect at the
+// given time, or nil if none has been recorded.
+func (c *CurrencyRepository) FindEffectiveRate(base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := c.db.Where("base_currency = ? AND quote_currency = ? AND effective_at <= ?", base, quote, at).
+		Order("effective_at DESC, id DESC").
+		First(&rate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (c *CurrencyRepository) UpsertProductPrices(prices []models.ProductPrice) error {
+	return c.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"amount", "updated_at"}),
+	}).Create(&prices).Error
+}
+
+func (c *CurrencyRepository) FindProductPrices(productID uint) ([]models.ProductPrice, error) {
+	var prices []models.ProductPrice
+	if err := c.db.Where("product_id = ?", productID).Order("currency").Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+// FindProductPricesIn returns the overrides in currency for the given
+// products, keyed by product ID.
+func (c *CurrencyRepository) FindProductPricesIn(productIDs []uint, currency string) (map[uint]models.ProductPrice, error) {
+	var prices []models.ProductPrice
+	if err := c.db.Where("product_id IN ? AND currency = ?", productIDs, currency).Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	byProduct := make(map[uint]models.ProductPrice, len(prices))
+	for _, price := range prices {
+		byProduct[price.ProductID] = price
+	}
+	return byProduct, nil
+}
+
+func (c *CurrencyRepository) DeleteProductPrice(productID uint, currency string) error {
+	return c.db.Where("product_id = ? AND currency = ?", productID, currency).Delete(&models.ProductPrice{}).Error
+}
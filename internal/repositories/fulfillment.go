@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"instashop/models"
+)
+
+type FulfillmentRepository struct {
+	db *gorm.DB
+}
+
+func NewFulfillmentRepository(db *gorm.DB) *FulfillmentRepository {
+	return &FulfillmentRepository{db}
+}
+
+func (f *FulfillmentRepository) WithTx(tx Tx) *FulfillmentRepository {
+	return &FulfillmentRepository{gormTx(tx)}
+}
+
+func (f *FulfillmentRepository) WithContext(ctx context.Context) *FulfillmentRepository {
+	return &FulfillmentRepository{f.db.WithContext(ctx)}
+}
+
+func (f *FulfillmentRepository) Create(fulfillment *models.Fulfillment) error {
+	return f.db.Create(fulfillment).Error
+}
+
+func (f *FulfillmentRepository) FindByID(fulfillmentID uint) (*models.Fulfillment, error) {
+	var fulfillment models.Fulfillment
+	if err := f.db.Preload("Items").First(&fulfillment, fulfillmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &fulfillment, nil
+}
+
+func (f *FulfillmentRepository) FindByOrderID(orderID uint) ([]models.Fulfillment, error) {
+	var fulfillments []models.Fulfillment
+	err := f.db.Preload("Items").Where("order_id = ?", orderID).Order("shipped_at, id").Find(&fulfillments).Error
+	if err != nil {
+		return nil, err
+	}
+	return fulfillments, nil
+}
+
+// FulfilledQuantities returns how many units of each order item have been
+// shipped so far, keyed by order item ID.
+func (f *FulfillmentRepository) FulfilledQuantities(orderID uint) (map[uint]int, error) {
+	var rows []struct {
+		OrderItemID uint
+		Quantity    int
+	}
+	err := f.db.Model(&models.FulfillmentItem{}).
+		Select("fulfillment_items.order_item_id, SUM(fulfillment_items.quantity) AS quantity").
+		Joins("JOIN fulfillments ON fulfillments.id = fulfillment_items.fulfillment_id").
+		Where("fulfillments.order_id = ?", orderID).
+		Group("fulfillment_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		quantities[row.OrderItemID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+func (f *FulfillmentRepository) MarkDelivered(fulfillmentID uint, at time.Time) error {
+	return f.db.Model(&models.Fulfillment{}).Where("id = ?", fulfillmentID).Updates(map[string]interface{}{
+		"status":       models.FulfillmentStatusDelivered,
+		"delivered_at": at,
+	}).Error
+}
+
+// CountUndelivered returns how many of the order's fulfillments are still
+// in transit.
+func (f *FulfillmentRepository) CountUndelivered(orderID uint) (int64, error) {
+	var count int64
+	err := f.db.Model(&models.Fulfillment{}).
+		Where("order_id = ? AND status <> ?", orderID, models.FulfillmentStatusDelivered).
+		Count(&count).Error
+	return count, err
+}
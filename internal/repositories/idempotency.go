@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"instashop/models"
+)
+
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db}
+}
+
+func (i *IdempotencyRepository) WithContext(ctx context.Context) *IdempotencyRepository {
+	return &IdempotencyRepository{i.db.WithContext(ctx)}
+}
+
+// Reserve stores record unless the user already has a record for the key.
+// It reports whether record was stored.
+func (i *IdempotencyRepository) Reserve(record *models.IdempotencyKey) (bool, error) {
+	result := i.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (i *IdempotencyRepository) Find(userID uint, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	if err := i.db.Where("user_id = ? AND key = ?", userID, key).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (i *IdempotencyRepository) Complete(recordID uint, statusCode int, body []byte) error {
+	return i.db.Model(&models.IdempotencyKey{}).Where("id = ?", recordID).Updates(map[string]interface{}{
+		"status_code":   statusCode,
+		"response_body": body,
+	}).Error
+}
+
+func (i *IdempotencyRepository) Delete(recordID uint) error {
+	return i.db.Delete(&models.IdempotencyKey{}, recordID).Error
+}
+
+// DeleteInProgress deletes the record only if its request never completed,
+// reporting whether it did.
+func (i *IdempotencyRepository) DeleteInProgress(recordID uint) (bool, error) {
+	result := i.db.Where("id = ? AND status_code = 0", recordID).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (i *IdempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := i.db.Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+type CouponRepository struct {
+	db *DB
+}
+
+func NewCouponRepository(db *DB) *CouponRepository {
+	return &CouponRepository{db}
+}
+
+func (c *CouponRepository) WithTx(tx repositories.Tx) repositories.CouponStore {
+	c.db.join(tx)
+	return c
+}
+
+func (c *CouponRepository) WithContext(ctx context.Context) repositories.CouponStore {
+	return c
+}
+
+func (c *CouponRepository) Create(coupon *models.Coupon) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, existing := range c.db.t.coupons.rows {
+		if existing.Code == coupon.Code {
+			return fmt.Errorf("duplicate coupon code %q", coupon.Code)
+		}
+	}
+	coupon.ID = c.db.t.coupons.nextID()
+	stamp(&coupon.CreatedAt, &coupon.UpdatedAt)
+	if coupon.IsActive == nil {
+		coupon.IsActive = boolPtr(true)
+	}
+	c.db.t.coupons.put(coupon.ID, *coupon)
+	return nil
+}
+
+func (c *CouponRepository) FindByID(couponID uint) (*models.Coupon, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	coupon, ok := c.db.t.coupons.get(couponID)
+	if !ok {
+		return nil, nil
+	}
+	return &coupon, nil
+}
+
+func (c *CouponRepository) FindByCode(code string) (*models.Coupon, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, coupon := range c.db.t.coupons.rows {
+		if coupon.Code == code {
+			return &coupon, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *CouponRepository) List() ([]models.Coupon, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	all := c.db.t.coupons.all()
+	coupons := make([]models.Coupon, 0, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		coupons = append(coupons, all[i])
+	}
+	return coupons, nil
+}
+
+// Update leaves TimesUsed as stored, like the gorm repository.
+func (c *CouponRepository) Update(coupon *models.Coupon) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	stored, ok := c.db.t.coupons.get(coupon.ID)
+	if !ok {
+		return nil
+	}
+	coupon.UpdatedAt = time.Now()
+	updated := *coupon
+	updated.TimesUsed = stored.TimesUsed
+	c.db.t.coupons.put(coupon.ID, updated)
+	return nil
+}
+
+func (c *CouponRepository) Delete(couponID uint) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.t.coupons.delete(couponID)
+	return nil
+}
+
+func (c *CouponRepository) CountUserRedemptions(couponID, userID uint) (int64, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	return c.db.countRedemptions(couponID, userID), nil
+}
+
+func (c *CouponRepository) Redeem(couponID, userID, orderID uint) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	coupon, ok := c.db.t.coupons.get(couponID)
+	if !ok || coupon.UsageLimit != nil && coupon.TimesUsed >= *coupon.UsageLimit {
+		return repositories.ErrCouponExhausted
+	}
+	if coupon.PerUserLimit != nil && c.db.countRedemptions(couponID, userID) >= int64(*coupon.PerUserLimit) {
+		return repositories.ErrCouponUserLimitReached
+	}
+	coupon.TimesUsed++
+	c.db.t.coupons.put(couponID, coupon)
+
+	redemption := models.CouponRedemption{
+		ID:        c.db.t.redemptions.nextID(),
+		CouponID:  couponID,
+		UserID:    userID,
+		OrderID:   orderID,
+		CreatedAt: time.Now(),
+	}
+	c.db.t.redemptions.put(redemption.ID, redemption)
+	return nil
+}
+
+func (c *CouponRepository) Unredeem(orderID uint) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, redemption := range c.db.t.redemptions.all() {
+		if redemption.OrderID != orderID {
+			continue
+		}
+		if coupon, ok := c.db.t.coupons.get(redemption.CouponID); ok && coupon.TimesUsed > 0 {
+			coupon.TimesUsed--
+			c.db.t.coupons.put(coupon.ID, coupon)
+		}
+		c.db.t.redemptions.delete(redemption.ID)
+	}
+	return nil
+}
+
+// countRedemptions expects db.mu to be held.
+func (d *DB) countRedemptions(couponID, userID uint) int64 {
+	var count int64
+	for _, redemption := range d.t.redemptions.rows {
+		if redemption.CouponID == couponID && redemption.UserID == userID {
+			count++
+		}
+	}
+	return count
+}
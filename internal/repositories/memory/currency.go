@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+type CurrencyRepository struct {
+	db *DB
+}
+
+func NewCurrencyRepository(db *DB) *CurrencyRepository {
+	return &CurrencyRepository{db}
+}
+
+func (c *CurrencyRepository) WithTx(tx repositories.Tx) repositories.CurrencyStore {
+	c.db.join(tx)
+	return c
+}
+
+func (c *CurrencyRepository) WithContext(ctx context.Context) repositories.CurrencyStore {
+	return c
+}
+
+func (c *CurrencyRepository) CreateExchangeRate(rate *models.ExchangeRate) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	rate.ID = c.db.t.exchangeRates.nextID()
+	stamp(&rate.CreatedAt, &rate.UpdatedAt)
+	c.db.t.exchangeRates.put(rate.ID, *rate)
+	return nil
+}
+
+func (c *CurrencyRepository) ListExchangeRates(base, quote string) ([]models.ExchangeRate, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	return c.db.exchangeRates(base, quote), nil
+}
+
+func (c *CurrencyRepository) FindEffectiveRate(base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	return c.db.effectiveRate(base, quote, at), nil
+}
+
+func (c *CurrencyRepository) UpsertProductPrices(prices []models.ProductPrice) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for i := range prices {
+		price := &prices[i]
+		for _, existing := range c.db.t.productPrices.rows {
+			if existing.ProductID == price.ProductID && existing.Currency == price.Currency {
+				price.ID, price.CreatedAt = existing.ID, existing.CreatedAt
+				break
+			}
+		}
+		if price.ID == 0 {
+			price.ID = c.db.t.productPrices.nextID()
+		}
+		price.UpdatedAt = time.Time{}
+		stamp(&price.CreatedAt, &price.UpdatedAt)
+		c.db.t.productPrices.put(price.ID, *price)
+	}
+	return nil
+}
+
+func (c *CurrencyRepository) FindProductPrices(productID uint) ([]models.ProductPrice, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	prices := []models.ProductPrice{}
+	for _, price := range c.db.t.productPrices.all() {
+		if price.ProductID == productID {
+			prices = append(prices, price)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Currency < prices[j].Currency
+	})
+	return prices, nil
+}
+
+func (c *CurrencyRepository) FindProductPricesIn(productIDs []uint, currency string) (map[uint]models.ProductPrice, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	wanted := make(map[uint]bool, len(productIDs))
+	for _, productID := range productIDs {
+		wanted[productID] = true
+	}
+	byProduct := make(map[uint]models.ProductPrice)
+	for _, price := range c.db.t.productPrices.rows {
+		if wanted[price.ProductID] && price.Currency == currency {
+			byProduct[price.ProductID] = price
+		}
+	}
+	return byProduct, nil
+}
+
+func (c *CurrencyRepository) DeleteProductPrice(productID uint, currency string) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, price := range c.db.t.productPrices.all() {
+		if price.ProductID == productID && price.Currency == currency {
+			c.db.t.productPrices.delete(price.ID)
+		}
+	}
+	return nil
+}
+
+// exchangeRates returns the rates for the pair, latest first; an empty
+// currency matches any. It expects db.mu to be held.
+func (d *DB) exchangeRates(base, quote string) []models.ExchangeRate {
+	var rates []models.ExchangeRate
+	for _, rate := range d.t.exchangeRates.all() {
+		if (base == "" || rate.BaseCurrency == base) && (quote == "" || rate.QuoteCurrency == quote) {
+			rates = append(rates, rate)
+		}
+	}
+	sortLatestFirst(rates)
+	return rates
+}
+
+// effectiveRate expects db.mu to be held.
+func (d *DB) effectiveRate(base, quote string, at time.Time) *models.ExchangeRate {
+	for _, rate := range d.exchangeRates(base, quote) {
+		if !rate.EffectiveAt.After(at) {
+			return &rate
+		}
+	}
+	return nil
+}
+
+// localPrice mirrors the gorm repositories' local_price: what product costs
+// in currency, or false when there's no rate to price it at. It expects
+// db.mu to be held.
+func (d *DB) localPrice(product models.Product, currency string) (int64, bool) {
+	if product.Price.Currency == currency {
+		return product.Price.Amount, true
+	}
+	for _, price := range d.t.productPrices.rows {
+		if price.ProductID == product.ID && price.Currency == currency {
+			return price.Amount, true
+		}
+	}
+	if product.Price.Amount == 0 {
+		return 0, true
+	}
+	now := time.Now()
+	if rate := d.effectiveRate(product.Price.Currency, currency, now); rate != nil {
+		return product.Price.Convert(currency, rate.Rate).Amount, true
+	}
+	if rate := d.effectiveRate(currency, product.Price.Currency, now); rate != nil && rate.Rate > 0 {
+		return product.Price.Convert(currency, 1/rate.Rate).Amount, true
+	}
+	return 0, false
+}
+
+func sortLatestFirst(rates []models.ExchangeRate) {
+	sort.Slice(rates, func(i, j int) bool {
+		if !rates[i].EffectiveAt.Equal(rates[j].EffectiveAt) {
+			return rates[i].EffectiveAt.After(rates[j].EffectiveAt)
+		}
+		return rates[i].ID > rates[j].ID
+	})
+}
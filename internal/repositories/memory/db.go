@@ -0,0 +1,162 @@
+// Package memory implements the repository stores in memory, for testing
+// services without Postgres. Repositories built on the same DB share its
+// tables, and Transactor rolls every table back when a transaction fails.
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+// DB holds the tables. Transactions run one at a time, so a failed one can
+// be undone by restoring the tables as they were when it began.
+type DB struct {
+	mu    sync.Mutex
+	txMu  sync.Mutex
+	locks map[int64]bool
+	t     tables
+}
+
+type tables struct {
+	products        table[models.Product]
+	variants        table[models.ProductVariant]
+	optionTypes     table[models.OptionType]
+	optionValues    table[models.OptionValue]
+	orders          table[models.Order]
+	orderItems      table[models.OrderItem]
+	orderDiscounts  table[models.OrderDiscount]
+	users           table[models.User]
+	coupons         table[models.Coupon]
+	redemptions     table[models.CouponRedemption]
+	outbox          table[models.OutboxEvent]
+	exchangeRates   table[models.ExchangeRate]
+	productPrices   table[models.ProductPrice]
+	shippingZones   table[models.ShippingZone]
+	shippingMethods table[models.ShippingMethod]
+}
+
+func NewDB() *DB {
+	return &DB{locks: make(map[int64]bool)}
+}
+
+func (t tables) clone() tables {
+	return tables{
+		products:        t.products.clone(),
+		variants:        t.variants.clone(),
+		optionTypes:     t.optionTypes.clone(),
+		optionValues:    t.optionValues.clone(),
+		orders:          t.orders.clone(),
+		orderItems:      t.orderItems.clone(),
+		orderDiscounts:  t.orderDiscounts.clone(),
+		users:           t.users.clone(),
+		coupons:         t.coupons.clone(),
+		redemptions:     t.redemptions.clone(),
+		outbox:          t.outbox.clone(),
+		exchangeRates:   t.exchangeRates.clone(),
+		productPrices:   t.productPrices.clone(),
+		shippingZones:   t.shippingZones.clone(),
+		shippingMethods: t.shippingMethods.clone(),
+	}
+}
+
+// table is rows keyed by ID. Rows are stored by value and never modified in
+// place, so a shallow copy of the map is a snapshot.
+type table[T any] struct {
+	rows   map[uint]T
+	lastID uint
+}
+
+func (t *table[T]) nextID() uint {
+	t.lastID++
+	return t.lastID
+}
+
+func (t *table[T]) get(id uint) (T, bool) {
+	row, ok := t.rows[id]
+	return row, ok
+}
+
+func (t *table[T]) put(id uint, row T) {
+	if t.rows == nil {
+		t.rows = make(map[uint]T)
+	}
+	t.rows[id] = row
+}
+
+func (t *table[T]) delete(id uint) {
+	delete(t.rows, id)
+}
+
+// all returns the rows in ID order.
+func (t *table[T]) all() []T {
+	ids := make([]uint, 0, len(t.rows))
+	for id := range t.rows {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	rows := make([]T, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, t.rows[id])
+	}
+	return rows
+}
+
+func (t table[T]) clone() table[T] {
+	rows := make(map[uint]T, len(t.rows))
+	for id, row := range t.rows {
+		rows[id] = row
+	}
+	return table[T]{rows: rows, lastID: t.lastID}
+}
+
+// stamp fills in timestamps the way gorm does on create.
+func stamp(createdAt, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt.IsZero() {
+		*updatedAt = now
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func paginate[T any](rows []T, page, pageSize int) []T {
+	offset := (page - 1) * pageSize
+	return window(rows, offset, pageSize)
+}
+
+// window returns up to limit rows from offset; a limit of zero or less
+// means no limit.
+func window[T any](rows []T, offset, limit int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(rows) {
+		return nil
+	}
+	rows = rows[offset:]
+	if limit > 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+var (
+	_ repositories.TxRunner      = (*Transactor)(nil)
+	_ repositories.ProductStore  = (*ProductRepository)(nil)
+	_ repositories.VariantStore  = (*VariantRepository)(nil)
+	_ repositories.OrderStore    = (*OrderRepository)(nil)
+	_ repositories.UserStore     = (*UserRepository)(nil)
+	_ repositories.CouponStore   = (*CouponRepository)(nil)
+	_ repositories.OutboxStore   = (*OutboxRepository)(nil)
+	_ repositories.CurrencyStore = (*CurrencyRepository)(nil)
+	_ repositories.ShippingStore = (*ShippingRepository)(nil)
+)
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+type OrderRepository struct {
+	db *DB
+}
+
+func NewOrderRepository(db *DB) *OrderRepository {
+	return &OrderRepository{db}
+}
+
+func (o *OrderRepository) WithTx(tx repositories.Tx) repositories.OrderStore {
+	o.db.join(tx)
+	return o
+}
+
+func (o *OrderRepository) WithContext(ctx context.Context) repositories.OrderStore {
+	return o
+}
+
+// Create stores the order with its items and discounts, filling in their IDs
+// on the given order as gorm does.
+func (o *OrderRepository) Create(order *models.Order) error {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+
+	order.ID = o.db.t.orders.nextID()
+	stamp(&order.CreatedAt, &order.UpdatedAt)
+	if order.Status == "" {
+		order.Status = models.OrderStatusPending
+	}
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.ID = o.db.t.orderItems.nextID()
+		item.OrderID = order.ID
+		stamp(&item.CreatedAt, &item.UpdatedAt)
+		stored := *item
+		stored.Product = nil
+		o.db.t.orderItems.put(item.ID, stored)
+	}
+	for i := range order.Discounts {
+		discount := &order.Discounts[i]
+		discount.ID = o.db.t.orderDiscounts.nextID()
+		discount.OrderID = order.ID
+		if discount.CreatedAt.IsZero() {
+			discount.CreatedAt = time.Now()
+		}
+		o.db.t.orderDiscounts.put(discount.ID, *discount)
+	}
+
+	stored := *order
+	stored.Items, stored.Discounts = nil, nil
+	o.db.t.orders.put(order.ID, stored)
+	return nil
+}
+
+func (o *OrderRepository) FindByID(orderID uint) (*models.Order, bool, error) {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	order, ok := o.db.t.orders.get(orderID)
+	if !ok {
+		return nil, false, nil
+	}
+	order = o.db.withOrderLines(order)
+	return &order, true, nil
+}
+
+func (o *OrderRepository) LockByID(orderID uint) (*models.Order, error) {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	order, ok := o.db.t.orders.get(orderID)
+	if !ok {
+		return nil, nil
+	}
+	order.Items = o.db.orderItems(orderID, false)
+	return &order, nil
+}
+
+func (o *OrderRepository) FindPendingBefore(before time.Time, limit int) ([]uint, error) {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	var pending []models.Order
+	for _, order := range o.db.t.orders.all() {
+		if order.Status == models.OrderStatusPending && order.CreatedAt.Before(before) {
+			pending = append(pending, order)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	orderIDs := []uint{}
+	for _, order := range window(pending, 0, limit) {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	return orderIDs, nil
+}
+
+func (o *OrderRepository) UpdateStatus(orderID uint, status models.OrderStatus) error {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	if order, ok := o.db.t.orders.get(orderID); ok {
+		order.Status = status
+		order.UpdatedAt = time.Now()
+		o.db.t.orders.put(orderID, order)
+	}
+	return nil
+}
+
+func (o *OrderRepository) Delete(orderID uint) error {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	for _, item := range o.db.t.orderItems.all() {
+		if item.OrderID == orderID {
+			o.db.t.orderItems.delete(item.ID)
+		}
+	}
+	o.db.t.orders.delete(orderID)
+	return nil
+}
+
+func (o *OrderRepository) ListPaginated(filter repositories.OrderListFilter, page, pageSize int) ([]models.Order, int64, error) {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+
+	var orders []models.Order
+	for _, order := range o.db.t.orders.all() {
+		if order.UserID != filter.UserID ||
+			filter.Status != "" && order.Status != filter.Status ||
+			filter.From != nil && order.CreatedAt.Before(*filter.From) ||
+			filter.To != nil && !order.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	field := strings.TrimPrefix(filter.Sort, "-")
+	desc := strings.HasPrefix(filter.Sort, "-")
+	if field != "created_at" && field != "total" {
+		field, desc = "created_at", true
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		a, b := orders[i], orders[j]
+		var cmp int
+		if field == "total" {
+			cmp = compare(a.TotalPrice.Amount, b.TotalPrice.Amount)
+		} else {
+			cmp = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if cmp == 0 {
+			cmp = compare(a.ID, b.ID)
+		}
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	results := paginate(orders, page, pageSize)
+	for i := range results {
+		results[i] = o.db.withOrderLines(results[i])
+	}
+	return results, int64(len(orders)), nil
+}
+
+// withOrderLines loads the order's items and discounts. It expects db.mu to
+// be held.
+func (d *DB) withOrderLines(order models.Order) models.Order {
+	order.Items = d.orderItems(order.ID, true)
+	order.Discounts = []models.OrderDiscount{}
+	for _, discount := range d.t.orderDiscounts.all() {
+		if discount.OrderID == order.ID {
+			order.Discounts = append(order.Discounts, discount)
+		}
+	}
+	return order
+}
+
+// orderItems returns the order's items, with their products, soft-deleted
+// ones included, when withProducts is set. It expects db.mu to be held.
+func (d *DB) orderItems(orderID uint, withProducts bool) []models.OrderItem {
+	items := []models.OrderItem{}
+	for _, item := range d.t.orderItems.all() {
+		if item.OrderID != orderID {
+			continue
+		}
+		if withProducts {
+			if product, ok := d.t.products.get(item.ProductID); ok {
+				item.Product = &product
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
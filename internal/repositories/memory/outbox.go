@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+type OutboxRepository struct {
+	db *DB
+}
+
+func NewOutboxRepository(db *DB) *OutboxRepository {
+	return &OutboxRepository{db}
+}
+
+func (o *OutboxRepository) WithTx(tx repositories.Tx) repositories.OutboxStore {
+	o.db.join(tx)
+	return o
+}
+
+func (o *OutboxRepository) WithContext(ctx context.Context) repositories.OutboxStore {
+	return o
+}
+
+func (o *OutboxRepository) Add(events ...*models.OutboxEvent) error {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	for _, event := range events {
+		event.ID = o.db.t.outbox.nextID()
+		stamp(&event.CreatedAt, &event.UpdatedAt)
+		if event.Status == "" {
+			event.Status = models.OutboxStatusPending
+		}
+		o.db.t.outbox.put(event.ID, *event)
+	}
+	return nil
+}
+
+func (o *OutboxRepository) FindDue(now time.Time, limit int) ([]models.OutboxEvent, error) {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	var due []models.OutboxEvent
+	for _, event := range o.db.t.outbox.all() {
+		if event.Status == models.OutboxStatusPending && !event.NextAttemptAt.After(now) {
+			due = append(due, event)
+		}
+	}
+	return window(due, 0, limit), nil
+}
+
+func (o *OutboxRepository) MarkPublished(eventID uint, at time.Time) error {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	if event, ok := o.db.t.outbox.get(eventID); ok {
+		event.Status = models.OutboxStatusPublished
+		event.PublishedAt = &at
+		event.Attempts++
+		event.LastError = ""
+		o.db.t.outbox.put(eventID, event)
+	}
+	return nil
+}
+
+func (o *OutboxRepository) MarkFailed(eventID uint, status models.OutboxStatus, nextAttemptAt time.Time, lastError string) error {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	if event, ok := o.db.t.outbox.get(eventID); ok {
+		event.Status = status
+		event.NextAttemptAt = nextAttemptAt
+		event.Attempts++
+		event.LastError = lastError
+		o.db.t.outbox.put(eventID, event)
+	}
+	return nil
+}
+
+func (o *OutboxRepository) FindWebhooksUnqueued(limit int) ([]models.OutboxEvent, error) {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	var unqueued []models.OutboxEvent
+	for _, event := range o.db.t.outbox.all() {
+		if event.WebhooksQueuedAt == nil {
+			unqueued = append(unqueued, event)
+		}
+	}
+	return window(unqueued, 0, limit), nil
+}
+
+func (o *OutboxRepository) MarkWebhooksQueued(eventID uint, at time.Time) error {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	if event, ok := o.db.t.outbox.get(eventID); ok {
+		event.WebhooksQueuedAt = &at
+		o.db.t.outbox.put(eventID, event)
+	}
+	return nil
+}
+
+// Events returns every recorded event in the order it was added, whatever
+// its status, so tests can check what a change published.
+func (o *OutboxRepository) Events() []models.OutboxEvent {
+	o.db.mu.Lock()
+	defer o.db.mu.Unlock()
+	return o.db.t.outbox.all()
+}
@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+type ProductRepository struct {
+	db *DB
+}
+
+func NewProductRepository(db *DB) *ProductRepository {
+	return &ProductRepository{db}
+}
+
+func (p *ProductRepository) WithTx(tx repositories.Tx) repositories.ProductStore {
+	p.db.join(tx)
+	return p
+}
+
+func (p *ProductRepository) WithContext(ctx context.Context) repositories.ProductStore {
+	return p
+}
+
+// Create stores the product and, like gorm, any variants it carries.
+func (p *ProductRepository) Create(product *models.Product) error {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	return p.db.createProduct(product)
+}
+
+func (p *ProductRepository) CreateMany(products []models.Product) error {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	for i := range products {
+		if err := p.db.createProduct(&products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ProductRepository) FindByID(productID uint) (*models.Product, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	product, ok := p.db.t.products.get(productID)
+	if !ok || product.DeletedAt.Valid {
+		return nil, nil
+	}
+	product.Variants = p.db.productVariants(productID)
+	return &product, nil
+}
+
+func (p *ProductRepository) FindActiveByID(productID uint) (*models.Product, error) {
+	product, err := p.FindByID(productID)
+	if err != nil || product == nil || product.Status != models.ProductStatusActive {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (p *ProductRepository) FetchByNames(names []string) ([]models.Product, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var products []models.Product
+	for _, product := range p.db.t.products.all() {
+		if !product.DeletedAt.Valid && wanted[product.Name] {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (p *ProductRepository) FindByNameUnscoped(name string) (*models.Product, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	var found *models.Product
+	for _, product := range p.db.t.products.all() {
+		if product.Name != name {
+			continue
+		}
+		if found == nil || found.DeletedAt.Valid && !product.DeletedAt.Valid {
+			product := product
+			found = &product
+		}
+	}
+	return found, nil
+}
+
+// Update saves the product's own fields; variants are left alone.
+func (p *ProductRepository) Update(product *models.Product) error {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	product.UpdatedAt = time.Now()
+	stored := *product
+	stored.Variants = nil
+	p.db.t.products.put(product.ID, stored)
+	return nil
+}
+
+func (p *ProductRepository) Delete(productID uint) (bool, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	product, ok := p.db.t.products.get(productID)
+	if !ok || product.DeletedAt.Valid {
+		return false, nil
+	}
+	product.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	p.db.t.products.put(productID, product)
+	return true, nil
+}
+
+func (p *ProductRepository) Restore(productID uint) (bool, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	product, ok := p.db.t.products.get(productID)
+	if !ok || !product.DeletedAt.Valid {
+		return false, nil
+	}
+	product.DeletedAt = gorm.DeletedAt{}
+	p.db.t.products.put(productID, product)
+	return true, nil
+}
+
+func (p *ProductRepository) ListAll() ([]models.Product, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	var products []models.Product
+	for _, product := range p.db.t.products.all() {
+		if !product.DeletedAt.Valid {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (p *ProductRepository) DecreaseStock(productID uint, quantity int) (int, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	product, ok := p.db.t.products.get(productID)
+	if !ok || product.DeletedAt.Valid || product.Stock < quantity {
+		return 0, repositories.ErrInsufficientStock
+	}
+	product.Stock -= quantity
+	p.db.t.products.put(productID, product)
+	return product.Stock, nil
+}
+
+// IncreaseStock includes soft-deleted products, as the gorm repository does.
+func (p *ProductRepository) IncreaseStock(productID uint, quantity int) error {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	if product, ok := p.db.t.products.get(productID); ok {
+		product.Stock += quantity
+		p.db.t.products.put(productID, product)
+	}
+	return nil
+}
+
+// createProduct expects db.mu to be held.
+func (d *DB) createProduct(product *models.Product) error {
+	product.ID = d.t.products.nextID()
+	stamp(&product.CreatedAt, &product.UpdatedAt)
+	if product.Status == "" {
+		product.Status = models.ProductStatusActive
+	}
+	if product.TaxClass == "" {
+		product.TaxClass = "standard"
+	}
+	for i := range product.Variants {
+		product.Variants[i].ProductID = product.ID
+		if err := d.createVariant(&product.Variants[i]); err != nil {
+			return err
+		}
+	}
+	stored := *product
+	stored.Variants = nil
+	d.t.products.put(product.ID, stored)
+	return nil
+}
+
+// productVariants expects db.mu to be held.
+func (d *DB) productVariants(productID uint) []models.ProductVariant {
+	variants := []models.ProductVariant{}
+	for _, variant := range d.t.variants.all() {
+		if variant.ProductID == productID && !variant.DeletedAt.Valid {
+			variants = append(variants, d.withOptionValues(variant))
+		}
+	}
+	return variants
+}
+
+// inStock mirrors the in-stock filter: the product or any of its variants
+// has units left. It expects db.mu to be held.
+func (d *DB) inStock(product models.Product) bool {
+	if product.Stock > 0 {
+		return true
+	}
+	for _, variant := range d.t.variants.rows {
+		if variant.ProductID == product.ID && !variant.DeletedAt.Valid && variant.Stock > 0 {
+			return true
+		}
+	}
+	return false
+}
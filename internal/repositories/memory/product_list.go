@@ -0,0 +1,285 @@
+package memory
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+func (p *ProductRepository) ListPaginated(filter repositories.ProductListFilter, page, pageSize int) ([]models.Product, int64, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	listed := p.db.listProducts(filter)
+	return productsOf(paginate(listed, page, pageSize)), int64(len(listed)), nil
+}
+
+func (p *ProductRepository) ListAfterCursor(filter repositories.ProductListFilter, cursor *repositories.ProductCursor, limit int) ([]models.Product, *repositories.ProductCursor, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+	listed := p.db.listProducts(filter)
+
+	start := 0
+	if cursor != nil {
+		if !cursor.Matches(filter) {
+			return nil, nil, repositories.ErrInvalidCursor
+		}
+
+		// stand-in for the last product of the previous page
+		last := listedProduct{Product: models.Product{ID: cursor.ID}}
+		var err error
+		switch field, _ := sortField(filter.Sort); field {
+		case "price":
+			err = json.Unmarshal(cursor.Value, &last.price)
+		case "name":
+			err = json.Unmarshal(cursor.Value, &last.Name)
+		default:
+			err = json.Unmarshal(cursor.Value, &last.CreatedAt)
+		}
+		if err != nil {
+			return nil, nil, repositories.ErrInvalidCursor
+		}
+
+		less := productLess(filter.Sort)
+		start = sort.Search(len(listed), func(i int) bool {
+			return less(last, listed[i])
+		})
+	}
+
+	page := window(listed, start, limit+1)
+	if len(page) <= limit {
+		return productsOf(page), nil, nil
+	}
+	page = page[:limit]
+	last := page[len(page)-1]
+	next, err := repositories.NewProductCursor(filter, last.Product, last.price)
+	if err != nil {
+		return nil, nil, err
+	}
+	return productsOf(page), next, nil
+}
+
+// SearchFullText matches products where every query term is a prefix of a
+// word in the name or description, ranked by how many words matched.
+func (p *ProductRepository) SearchFullText(filter repositories.ProductSearchFilter) ([]repositories.ProductSearchHit, int64, error) {
+	return p.search(filter, false)
+}
+
+// SearchTrigram has no similarity measure to work with, so it matches any
+// query term appearing anywhere in the name or description.
+func (p *ProductRepository) SearchTrigram(filter repositories.ProductSearchFilter) ([]repositories.ProductSearchHit, int64, error) {
+	return p.search(filter, true)
+}
+
+func (p *ProductRepository) SearchFacets(filter repositories.ProductSearchFilter, fuzzy bool) (*repositories.ProductSearchFacets, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+
+	counts := make(map[string]int64)
+	var inStock, outOfStock int64
+	for _, product := range p.db.t.products.all() {
+		if !catalogVisible(product) {
+			continue
+		}
+		if _, ok := textRank(product, filter.Query, fuzzy); !ok {
+			continue
+		}
+		available := p.db.inStock(product)
+		if amount, ok := p.db.localPrice(product, filter.Currency); ok && (!filter.InStock || available) {
+			if label, ok := priceBucket(amount, filter.Currency); ok {
+				counts[label]++
+			}
+		}
+		if p.db.inPriceRange(product, filter.Currency, filter.MinPrice, filter.MaxPrice) {
+			if available {
+				inStock++
+			} else {
+				outOfStock++
+			}
+		}
+	}
+
+	facets := &repositories.ProductSearchFacets{
+		Availability: []repositories.FacetCount{
+			{Value: "in_stock", Count: inStock},
+			{Value: "out_of_stock", Count: outOfStock},
+		},
+	}
+	for _, bucket := range repositories.PriceBuckets {
+		facets.PriceRanges = append(facets.PriceRanges, repositories.FacetCount{Value: bucket.Label, Count: counts[bucket.Label]})
+	}
+	return facets, nil
+}
+
+func (p *ProductRepository) search(filter repositories.ProductSearchFilter, fuzzy bool) ([]repositories.ProductSearchHit, int64, error) {
+	p.db.mu.Lock()
+	defer p.db.mu.Unlock()
+
+	var hits []repositories.ProductSearchHit
+	for _, product := range p.db.t.products.all() {
+		if !catalogVisible(product) || !p.db.inPriceRange(product, filter.Currency, filter.MinPrice, filter.MaxPrice) {
+			continue
+		}
+		if filter.InStock && !p.db.inStock(product) {
+			continue
+		}
+		if rank, ok := textRank(product, filter.Query, fuzzy); ok {
+			hits = append(hits, repositories.ProductSearchHit{Product: product, Rank: rank})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Rank > hits[j].Rank
+	})
+	return window(hits, filter.Offset, filter.Limit), int64(len(hits)), nil
+}
+
+// listedProduct is a product in a listing along with what it costs in the
+// listing's currency, when the listing sorts by price.
+type listedProduct struct {
+	models.Product
+	price int64
+}
+
+func productsOf(listed []listedProduct) []models.Product {
+	products := make([]models.Product, len(listed))
+	for i, l := range listed {
+		products[i] = l.Product
+	}
+	return products
+}
+
+// listProducts returns the products matching filter in its sort order. It
+// expects db.mu to be held.
+func (d *DB) listProducts(filter repositories.ProductListFilter) []listedProduct {
+	field, _ := sortField(filter.Sort)
+	var products []listedProduct
+	for _, product := range d.t.products.all() {
+		if product.DeletedAt.Valid != filter.OnlyDeleted {
+			continue
+		}
+		if filter.Status != "" && product.Status != filter.Status {
+			continue
+		}
+		if !d.inPriceRange(product, filter.Currency, filter.MinPrice, filter.MaxPrice) {
+			continue
+		}
+		if filter.InStock && !d.inStock(product) {
+			continue
+		}
+		listed := listedProduct{Product: product}
+		if field == "price" {
+			price, ok := d.localPrice(product, filter.Currency)
+			if !ok {
+				continue
+			}
+			listed.price = price
+		}
+		products = append(products, listed)
+	}
+	less := productLess(filter.Sort)
+	sort.Slice(products, func(i, j int) bool {
+		return less(products[i], products[j])
+	})
+	return products
+}
+
+// sortField mirrors the gorm repository: unknown sorts fall back to newest
+// first.
+func sortField(sortBy string) (string, bool) {
+	field := strings.TrimPrefix(sortBy, "-")
+	switch field {
+	case "price", "name", "created_at":
+		return field, strings.HasPrefix(sortBy, "-")
+	}
+	return "created_at", true
+}
+
+func productLess(sortBy string) func(a, b listedProduct) bool {
+	field, desc := sortField(sortBy)
+	return func(a, b listedProduct) bool {
+		var cmp int
+		switch field {
+		case "price":
+			cmp = compare(a.price, b.price)
+		case "name":
+			cmp = strings.Compare(a.Name, b.Name)
+		default:
+			cmp = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if cmp == 0 {
+			cmp = compare(a.ID, b.ID)
+		}
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+}
+
+func compare[T int64 | uint](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func catalogVisible(product models.Product) bool {
+	return !product.DeletedAt.Valid && product.Status == models.ProductStatusActive
+}
+
+// inPriceRange compares what product costs in currency; products that can't
+// be priced in it are in no range. It expects db.mu to be held.
+func (d *DB) inPriceRange(product models.Product, currency string, minPrice, maxPrice *int64) bool {
+	if minPrice == nil && maxPrice == nil {
+		return true
+	}
+	amount, ok := d.localPrice(product, currency)
+	return ok && (minPrice == nil || amount >= *minPrice) && (maxPrice == nil || amount <= *maxPrice)
+}
+
+func priceBucket(amount int64, currency string) (string, bool) {
+	for _, bucket := range repositories.PriceBuckets {
+		minAmount, maxAmount := bucket.MinorBounds(currency)
+		if amount >= minAmount && (maxAmount == nil || amount < *maxAmount) {
+			return bucket.Label, true
+		}
+	}
+	return "", false
+}
+
+// textRank reports whether product matches the query and how well. An empty
+// query matches everything with rank 0.
+func textRank(product models.Product, q string, fuzzy bool) (float64, bool) {
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		return 0, !fuzzy
+	}
+	words := searchTerms(product.Name + " " + product.Description)
+
+	var rank float64
+	for _, term := range terms {
+		matched := 0
+		for _, word := range words {
+			if fuzzy && strings.Contains(word, term) || !fuzzy && strings.HasPrefix(word, term) {
+				matched++
+			}
+		}
+		if matched == 0 && !fuzzy {
+			return 0, false
+		}
+		rank += float64(matched)
+	}
+	return rank, rank > 0
+}
+
+func searchTerms(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
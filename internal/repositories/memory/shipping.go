@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+type ShippingRepository struct {
+	db *DB
+}
+
+func NewShippingRepository(db *DB) *ShippingRepository {
+	return &ShippingRepository{db}
+}
+
+func (s *ShippingRepository) WithContext(ctx context.Context) repositories.ShippingStore {
+	return s
+}
+
+// CreateZone stores the zone and, like gorm, any methods it carries.
+func (s *ShippingRepository) CreateZone(zone *models.ShippingZone) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	for _, existing := range s.db.t.shippingZones.rows {
+		if existing.Name == zone.Name {
+			return fmt.Errorf("duplicate shipping zone %q", zone.Name)
+		}
+	}
+	zone.ID = s.db.t.shippingZones.nextID()
+	stamp(&zone.CreatedAt, &zone.UpdatedAt)
+	for i := range zone.Methods {
+		zone.Methods[i].ZoneID = zone.ID
+		s.db.createMethod(&zone.Methods[i])
+	}
+	stored := *zone
+	stored.Methods = nil
+	s.db.t.shippingZones.put(zone.ID, stored)
+	return nil
+}
+
+func (s *ShippingRepository) FindZoneByID(zoneID uint) (*models.ShippingZone, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	zone, ok := s.db.t.shippingZones.get(zoneID)
+	if !ok {
+		return nil, nil
+	}
+	zone.Methods = s.db.zoneMethods(zoneID)
+	return &zone, nil
+}
+
+func (s *ShippingRepository) FindZoneByName(name string) (*models.ShippingZone, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	for _, zone := range s.db.t.shippingZones.all() {
+		if zone.Name == name {
+			return &zone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *ShippingRepository) ListZones() ([]models.ShippingZone, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	zones := s.db.t.shippingZones.all()
+	for i := range zones {
+		zones[i].Methods = s.db.zoneMethods(zones[i].ID)
+	}
+	return zones, nil
+}
+
+func (s *ShippingRepository) DeleteZone(zoneID uint) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	for _, method := range s.db.zoneMethods(zoneID) {
+		s.db.t.shippingMethods.delete(method.ID)
+	}
+	s.db.t.shippingZones.delete(zoneID)
+	return nil
+}
+
+func (s *ShippingRepository) CreateMethod(method *models.ShippingMethod) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.createMethod(method)
+	return nil
+}
+
+func (s *ShippingRepository) FindMethodByID(methodID uint) (*models.ShippingMethod, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	method, ok := s.db.t.shippingMethods.get(methodID)
+	if !ok {
+		return nil, nil
+	}
+	return &method, nil
+}
+
+func (s *ShippingRepository) UpdateMethod(method *models.ShippingMethod) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.t.shippingMethods.put(method.ID, *method)
+	return nil
+}
+
+func (s *ShippingRepository) DeleteMethod(methodID uint) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.t.shippingMethods.delete(methodID)
+	return nil
+}
+
+// createMethod expects db.mu to be held.
+func (d *DB) createMethod(method *models.ShippingMethod) {
+	method.ID = d.t.shippingMethods.nextID()
+	stamp(&method.CreatedAt, &method.UpdatedAt)
+	if method.IsActive == nil {
+		method.IsActive = boolPtr(true)
+	}
+	d.t.shippingMethods.put(method.ID, *method)
+}
+
+// zoneMethods expects db.mu to be held.
+func (d *DB) zoneMethods(zoneID uint) []models.ShippingMethod {
+	methods := []models.ShippingMethod{}
+	for _, method := range d.t.shippingMethods.all() {
+		if method.ZoneID == zoneID {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"instashop/internal/repositories"
+)
+
+type Transactor struct {
+	db *DB
+}
+
+func NewTransactor(db *DB) *Transactor {
+	return &Transactor{db}
+}
+
+func (t *Transactor) WithContext(ctx context.Context) repositories.TxRunner {
+	return t
+}
+
+// txScope is what the Tx of a memory transaction holds. done is set when
+// the transaction ends, so a handle kept past it can't be joined.
+type txScope struct {
+	db   *DB
+	done bool
+}
+
+// Transaction calls fn with a Tx the repositories on the same DB can join.
+// If fn fails or panics every table is restored.
+func (t *Transactor) Transaction(fn func(tx repositories.Tx) error) error {
+	t.db.txMu.Lock()
+	defer t.db.txMu.Unlock()
+
+	t.db.mu.Lock()
+	snapshot := t.db.t.clone()
+	t.db.mu.Unlock()
+
+	scope := &txScope{db: t.db}
+	committed := false
+	defer func() {
+		scope.done = true
+		if !committed {
+			t.db.mu.Lock()
+			t.db.t = snapshot
+			t.db.mu.Unlock()
+		}
+	}()
+
+	if err := fn(repositories.NewTx(scope)); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+func (t *Transactor) WithAdvisoryLock(key int64, fn func() error) (bool, error) {
+	t.db.mu.Lock()
+	if t.db.locks[key] {
+		t.db.mu.Unlock()
+		return false, nil
+	}
+	t.db.locks[key] = true
+	t.db.mu.Unlock()
+
+	defer func() {
+		t.db.mu.Lock()
+		delete(t.db.locks, key)
+		t.db.mu.Unlock()
+	}()
+	return true, fn()
+}
+
+// join checks that tx is a running transaction on d. The repositories all
+// share d's tables, so joining changes nothing, but a missing, foreign or
+// finished handle is a bug the gorm repositories would turn into writes
+// outside the transaction, so it panics as they do.
+func (d *DB) join(tx repositories.Tx) {
+	scope, ok := tx.Scope().(*txScope)
+	if !ok || scope == nil || scope.db != d {
+		panic(fmt.Sprintf("memory: %T is not a transaction on this DB", tx.Scope()))
+	}
+	if scope.done {
+		panic("memory: transaction has already ended")
+	}
+}
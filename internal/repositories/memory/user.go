@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+type UserRepository struct {
+	db *DB
+}
+
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db}
+}
+
+func (u *UserRepository) WithTx(tx repositories.Tx) repositories.UserStore {
+	u.db.join(tx)
+	return u
+}
+
+func (u *UserRepository) WithContext(ctx context.Context) repositories.UserStore {
+	return u
+}
+
+func (u *UserRepository) Create(user *models.User) error {
+	u.db.mu.Lock()
+	defer u.db.mu.Unlock()
+	user.ID = u.db.t.users.nextID()
+	stamp(&user.CreatedAt, &user.UpdatedAt)
+	if user.IsVerified == nil {
+		user.IsVerified = boolPtr(false)
+	}
+	if user.IsAdmin == nil {
+		user.IsAdmin = boolPtr(false)
+	}
+	u.db.t.users.put(user.ID, *user)
+	return nil
+}
+
+// FetchOne returns the first user matching the non-zero identifying fields
+// of filter, like gorm's struct conditions.
+func (u *UserRepository) FetchOne(filter models.User) (*models.User, bool, error) {
+	u.db.mu.Lock()
+	defer u.db.mu.Unlock()
+	for _, user := range u.db.t.users.all() {
+		if filter.ID != 0 && user.ID != filter.ID ||
+			filter.Email != "" && user.Email != filter.Email ||
+			filter.PhoneNumber != "" && user.PhoneNumber != filter.PhoneNumber {
+			continue
+		}
+		return &user, true, nil
+	}
+	return nil, false, nil
+}
+
+func (u *UserRepository) UpdatePassword(userID uint, hashedPassword string) error {
+	u.db.mu.Lock()
+	defer u.db.mu.Unlock()
+	if user, ok := u.db.t.users.get(userID); ok {
+		user.Password = hashedPassword
+		user.UpdatedAt = time.Now()
+		u.db.t.users.put(userID, user)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+type VariantRepository struct {
+	db *DB
+}
+
+func NewVariantRepository(db *DB) *VariantRepository {
+	return &VariantRepository{db}
+}
+
+func (v *VariantRepository) WithTx(tx repositories.Tx) repositories.VariantStore {
+	v.db.join(tx)
+	return v
+}
+
+func (v *VariantRepository) WithContext(ctx context.Context) repositories.VariantStore {
+	return v
+}
+
+func (v *VariantRepository) Create(variant *models.ProductVariant) error {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	return v.db.createVariant(variant)
+}
+
+func (v *VariantRepository) FindByID(variantID uint) (*models.ProductVariant, error) {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	variant, ok := v.db.t.variants.get(variantID)
+	if !ok || variant.DeletedAt.Valid {
+		return nil, nil
+	}
+	variant = v.db.withOptionValues(variant)
+	return &variant, nil
+}
+
+func (v *VariantRepository) FindByProductID(productID uint) ([]models.ProductVariant, error) {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	return v.db.productVariants(productID), nil
+}
+
+func (v *VariantRepository) FetchBySKUs(skus []string) ([]models.ProductVariant, error) {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	wanted := make(map[string]bool, len(skus))
+	for _, sku := range skus {
+		wanted[sku] = true
+	}
+	var variants []models.ProductVariant
+	for _, variant := range v.db.t.variants.all() {
+		if !variant.DeletedAt.Valid && wanted[variant.SKU] {
+			variants = append(variants, variant)
+		}
+	}
+	return variants, nil
+}
+
+// Update saves the variant's own fields; its option values are left alone.
+func (v *VariantRepository) Update(variant *models.ProductVariant) error {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	stored, ok := v.db.t.variants.get(variant.ID)
+	if !ok {
+		return nil
+	}
+	for _, other := range v.db.t.variants.rows {
+		if other.ID != variant.ID && !other.DeletedAt.Valid && other.SKU == variant.SKU {
+			return fmt.Errorf("%w: %s", repositories.ErrSKUInUse, variant.SKU)
+		}
+	}
+	variant.UpdatedAt = time.Now()
+	optionValues := stored.OptionValues
+	stored = *variant
+	stored.OptionValues = optionValues
+	v.db.t.variants.put(variant.ID, stored)
+	return nil
+}
+
+func (v *VariantRepository) SetOptionValues(variantID uint, optionValues []models.OptionValue) error {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	variant, ok := v.db.t.variants.get(variantID)
+	if !ok {
+		return nil
+	}
+	variant.OptionValues = make([]models.OptionValue, 0, len(optionValues))
+	for _, value := range optionValues {
+		variant.OptionValues = append(variant.OptionValues, models.OptionValue{ID: value.ID})
+	}
+	v.db.t.variants.put(variantID, variant)
+	return nil
+}
+
+func (v *VariantRepository) Delete(variantID uint) error {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	variant, ok := v.db.t.variants.get(variantID)
+	if ok && !variant.DeletedAt.Valid {
+		variant.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		v.db.t.variants.put(variantID, variant)
+	}
+	return nil
+}
+
+func (v *VariantRepository) DecreaseStock(variantID uint, quantity int) (int, error) {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	variant, ok := v.db.t.variants.get(variantID)
+	if !ok || variant.DeletedAt.Valid || variant.Stock < quantity {
+		return 0, repositories.ErrInsufficientStock
+	}
+	variant.Stock -= quantity
+	v.db.t.variants.put(variantID, variant)
+	return variant.Stock, nil
+}
+
+func (v *VariantRepository) IncreaseStock(variantID uint, quantity int) error {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	if variant, ok := v.db.t.variants.get(variantID); ok {
+		variant.Stock += quantity
+		v.db.t.variants.put(variantID, variant)
+	}
+	return nil
+}
+
+func (v *VariantRepository) FindOrCreateOptionValue(typeName, value string) (*models.OptionValue, error) {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+
+	var optionType *models.OptionType
+	for _, existing := range v.db.t.optionTypes.rows {
+		if existing.Name == typeName {
+			optionType = &existing
+			break
+		}
+	}
+	if optionType == nil {
+		optionType = &models.OptionType{ID: v.db.t.optionTypes.nextID(), Name: typeName}
+		stamp(&optionType.CreatedAt, &optionType.UpdatedAt)
+		v.db.t.optionTypes.put(optionType.ID, *optionType)
+	}
+
+	for _, existing := range v.db.t.optionValues.rows {
+		if existing.OptionTypeID == optionType.ID && existing.Value == value {
+			return &existing, nil
+		}
+	}
+	optionValue := models.OptionValue{ID: v.db.t.optionValues.nextID(), OptionTypeID: optionType.ID, Value: value}
+	stamp(&optionValue.CreatedAt, &optionValue.UpdatedAt)
+	v.db.t.optionValues.put(optionValue.ID, optionValue)
+	return &optionValue, nil
+}
+
+func (v *VariantRepository) ListOptionTypes() ([]models.OptionType, error) {
+	v.db.mu.Lock()
+	defer v.db.mu.Unlock()
+	optionTypes := v.db.t.optionTypes.all()
+	sort.Slice(optionTypes, func(i, j int) bool {
+		return optionTypes[i].Name < optionTypes[j].Name
+	})
+	for i := range optionTypes {
+		optionTypes[i].Values = nil
+		for _, value := range v.db.t.optionValues.all() {
+			if value.OptionTypeID == optionTypes[i].ID {
+				optionTypes[i].Values = append(optionTypes[i].Values, value)
+			}
+		}
+	}
+	return optionTypes, nil
+}
+
+// createVariant enforces the unique SKU index on live variants and keeps
+// only the IDs of the variant's option values, standing in for the join
+// table. It expects db.mu to be held.
+func (d *DB) createVariant(variant *models.ProductVariant) error {
+	for _, existing := range d.t.variants.rows {
+		if !existing.DeletedAt.Valid && existing.SKU == variant.SKU {
+			return fmt.Errorf("%w: %s", repositories.ErrSKUInUse, variant.SKU)
+		}
+	}
+	variant.ID = d.t.variants.nextID()
+	stamp(&variant.CreatedAt, &variant.UpdatedAt)
+
+	stored := *variant
+	stored.OptionValues = make([]models.OptionValue, 0, len(variant.OptionValues))
+	for _, value := range variant.OptionValues {
+		stored.OptionValues = append(stored.OptionValues, models.OptionValue{ID: value.ID})
+	}
+	d.t.variants.put(variant.ID, stored)
+	return nil
+}
+
+// withOptionValues loads the variant's option values and their types. It
+// expects db.mu to be held.
+func (d *DB) withOptionValues(variant models.ProductVariant) models.ProductVariant {
+	values := make([]models.OptionValue, 0, len(variant.OptionValues))
+	for _, ref := range variant.OptionValues {
+		value, ok := d.t.optionValues.get(ref.ID)
+		if !ok {
+			continue
+		}
+		if optionType, ok := d.t.optionTypes.get(value.OptionTypeID); ok {
+			value.OptionType = &optionType
+		}
+		values = append(values, value)
+	}
+	variant.OptionValues = values
+	return variant
+}
@@ -1,9 +1,12 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"instashop/models"
 )
 
@@ -15,14 +18,28 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db}
 }
 
+func (o *OrderRepository) WithTx(tx Tx) OrderStore {
+	return &OrderRepository{gormTx(tx)}
+}
+
+func (o *OrderRepository) WithContext(ctx context.Context) OrderStore {
+	return &OrderRepository{o.db.WithContext(ctx)}
+}
+
 func (o *OrderRepository) Create(order *models.Order) error {
 	return o.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(order).Error; err != nil {
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
 			return err
 		}
-		for _, item := range order.Items {
-			item.OrderID = order.ID
-			if err := tx.Create(&item).Error; err != nil {
+		for i := range order.Items {
+			order.Items[i].OrderID = order.ID
+			if err := tx.Omit(clause.Associations).Create(&order.Items[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range order.Discounts {
+			order.Discounts[i].OrderID = order.ID
+			if err := tx.Create(&order.Discounts[i]).Error; err != nil {
 				return err
 			}
 		}
@@ -32,7 +49,7 @@ func (o *OrderRepository) Create(order *models.Order) error {
 
 func (o *OrderRepository) FindByID(orderID uint) (*models.Order, bool, error) {
 	var order models.Order
-	if err := o.db.Preload("Items").First(&order, orderID).Error; err != nil {
+	if err := o.db.Preload("Items.Product", withDeleted).Preload("Discounts").First(&order, orderID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, false, nil
 		}
@@ -41,12 +58,32 @@ func (o *OrderRepository) FindByID(orderID uint) (*models.Order, bool, error) {
 	return &order, true, nil
 }
 
-func (o *OrderRepository) FindByUserID(userID uint) ([]models.Order, error) {
-	var orders []models.Order
-	if err := o.db.Preload("Items").Where("user_id = ?", userID).Find(&orders).Error; err != nil {
+// LockByID loads the order and its items with the order row locked for
+// update, so callers in a transaction can change it without racing.
+func (o *OrderRepository) LockByID(orderID uint) (*models.Order, error) {
+	var order models.Order
+	err := o.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := o.db.Where("order_id = ?", orderID).Order("id").Find(&order.Items).Error; err != nil {
 		return nil, err
 	}
-	return orders, nil
+	return &order, nil
+}
+
+// FindPendingBefore returns up to limit IDs of orders still pending that
+// were placed before the given time, oldest first.
+func (o *OrderRepository) FindPendingBefore(before time.Time, limit int) ([]uint, error) {
+	var orderIDs []uint
+	err := o.db.Model(&models.Order{}).
+		Where("status = ? AND created_at < ?", models.OrderStatusPending, before).
+		Order("created_at").Limit(limit).
+		Pluck("id", &orderIDs).Error
+	return orderIDs, err
 }
 
 func (o *OrderRepository) UpdateStatus(orderID uint, status models.OrderStatus) error {
@@ -64,3 +101,8 @@ func (o *OrderRepository) Delete(orderID uint) error {
 		return nil
 	})
 }
+
+// withDeleted keeps soft-deleted products resolvable from order history.
+func withDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"instashop/models"
+)
+
+var orderSortColumns = map[string]string{
+	"created_at": "orders.created_at",
+	"total":      "orders.total_price_amount",
+}
+
+// OrderListFilter describes one user's order history listing. From is
+// inclusive and To exclusive. Sort is a column name from orderSortColumns,
+// prefixed with "-" for descending order; the default is newest first.
+type OrderListFilter struct {
+	UserID uint
+	Status models.OrderStatus
+	From   *time.Time
+	To     *time.Time
+	Sort   string
+}
+
+func (f OrderListFilter) order() string {
+	column, ok := orderSortColumns[strings.TrimPrefix(f.Sort, "-")]
+	if !ok {
+		return "orders.created_at DESC, orders.id DESC"
+	}
+	if strings.HasPrefix(f.Sort, "-") {
+		return fmt.Sprintf("%s DESC, orders.id DESC", column)
+	}
+	return fmt.Sprintf("%s ASC, orders.id ASC", column)
+}
+
+func (o *OrderRepository) ListPaginated(filter OrderListFilter, page, pageSize int) ([]models.Order, int64, error) {
+	var orders []models.Order
+	var totalCount int64
+
+	query := o.db.Model(&models.Order{}).Where("orders.user_id = ?", filter.UserID)
+	if filter.Status != "" {
+		query = query.Where("orders.status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("orders.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("orders.created_at < ?", *filter.To)
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("Items.Product", withDeleted).Preload("Discounts").
+		Order(filter.order()).Limit(pageSize).Offset(offset).
+		Find(&orders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, totalCount, nil
+}
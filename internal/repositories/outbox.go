@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"instashop/models"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db}
+}
+
+func (o *OutboxRepository) WithTx(tx Tx) OutboxStore {
+	return &OutboxRepository{gormTx(tx)}
+}
+
+func (o *OutboxRepository) WithContext(ctx context.Context) OutboxStore {
+	return &OutboxRepository{o.db.WithContext(ctx)}
+}
+
+func (o *OutboxRepository) Add(events ...*models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return o.db.Create(events).Error
+}
+
+// FindDue returns pending events whose next attempt is due, oldest first.
+func (o *OutboxRepository) FindDue(now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := o.db.
+		Where("status = ? AND next_attempt_at <= ?", models.OutboxStatusPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (o *OutboxRepository) MarkPublished(eventID uint, at time.Time) error {
+	return o.db.Model(&models.OutboxEvent{}).
+		Where("id = ?", eventID).
+		Updates(map[string]interface{}{
+			"status":       models.OutboxStatusPublished,
+			"published_at": at,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   "",
+		}).Error
+}
+
+// MarkFailed records a failed attempt. The event is retried at nextAttemptAt
+// unless status moves it out of pending.
+func (o *OutboxRepository) MarkFailed(eventID uint, status models.OutboxStatus, nextAttemptAt time.Time, lastError string) error {
+	return o.db.Model(&models.OutboxEvent{}).
+		Where("id = ?", eventID).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"next_attempt_at": nextAttemptAt,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastError,
+		}).Error
+}
+
+// FindWebhooksUnqueued returns events whose webhook deliveries haven't been
+// created yet, oldest first, whether or not they have been published.
+func (o *OutboxRepository) FindWebhooksUnqueued(limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := o.db.
+		Where("webhooks_queued_at IS NULL").
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (o *OutboxRepository) MarkWebhooksQueued(eventID uint, at time.Time) error {
+	return o.db.Model(&models.OutboxEvent{}).
+		Where("id = ?", eventID).
+		Update("webhooks_queued_at", at).Error
+}
@@ -1,12 +1,18 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"instashop/models"
 )
 
+// ErrInsufficientStock is returned by DecreaseStock when fewer units remain
+// than were asked for; stock is left untouched.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
 type ProductRepository struct {
 	db *gorm.DB
 }
@@ -15,6 +21,14 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	return &ProductRepository{db}
 }
 
+func (p *ProductRepository) WithTx(tx Tx) ProductStore {
+	return &ProductRepository{gormTx(tx)}
+}
+
+func (p *ProductRepository) WithContext(ctx context.Context) ProductStore {
+	return &ProductRepository{p.db.WithContext(ctx)}
+}
+
 func (p *ProductRepository) Create(product *models.Product) error {
 	return p.db.Create(product).Error
 }
@@ -24,7 +38,20 @@ func (p *ProductRepository) CreateMany(products []models.Product) error {
 }
 func (p *ProductRepository) FindByID(productID uint) (*models.Product, error) {
 	var product models.Product
-	if err := p.db.First(&product, productID).Error; err != nil {
+	if err := p.db.Preload("Variants.OptionValues.OptionType").First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (p *ProductRepository) FindActiveByID(productID uint) (*models.Product, error) {
+	var product models.Product
+	if err := p.db.Preload("Variants.OptionValues.OptionType").
+		Where("status = ?", models.ProductStatusActive).
+		First(&product, productID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -41,28 +68,46 @@ func (p *ProductRepository) FetchByNames(names []string) ([]models.Product, erro
 	return products, nil
 }
 
-func (p *ProductRepository) Update(product *models.Product) error {
-	return p.db.Save(product).Error
+// FindByNameUnscoped finds a product by name whether or not it is deleted,
+// preferring one that isn't.
+func (p *ProductRepository) FindByNameUnscoped(name string) (*models.Product, error) {
+	var product models.Product
+	if err := p.db.Unscoped().
+		Where("name = ?", name).
+		Order("deleted_at IS NOT NULL, id").
+		First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &product, nil
 }
 
-func (p *ProductRepository) Delete(productID uint) error {
-	return p.db.Delete(&models.Product{}, productID).Error
+func (p *ProductRepository) Update(product *models.Product) error {
+	return p.db.Omit(clause.Associations).Save(product).Error
 }
 
-func (p *ProductRepository) ListPaginated(page, pageSize int) ([]models.Product, int64, error) {
-	var products []models.Product
-	var totalCount int64
-
-	if err := p.db.Model(&models.Product{}).Count(&totalCount).Error; err != nil {
-		return nil, 0, err
+// Delete soft-deletes the product so order items referencing it still resolve.
+// Delete soft-deletes the product, reporting whether a live product was
+// found.
+func (p *ProductRepository) Delete(productID uint) (bool, error) {
+	result := p.db.Delete(&models.Product{}, productID)
+	if result.Error != nil {
+		return false, result.Error
 	}
+	return result.RowsAffected > 0, nil
+}
 
-	offset := (page - 1) * pageSize
-	if err := p.db.Limit(pageSize).Offset(offset).Find(&products).Error; err != nil {
-		return nil, 0, err
+// Restore undoes a soft delete, reporting whether a deleted product was found.
+func (p *ProductRepository) Restore(productID uint) (bool, error) {
+	result := p.db.Unscoped().Model(&models.Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", productID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return false, result.Error
 	}
-
-	return products, totalCount, nil
+	return result.RowsAffected > 0, nil
 }
 
 func (p *ProductRepository) ListAll() ([]models.Product, error) {
@@ -73,9 +118,27 @@ func (p *ProductRepository) ListAll() ([]models.Product, error) {
 	return products, nil
 }
 
-func (p *ProductRepository) DecreaseStock(productID uint, quantity int) error {
-	return p.db.Model(&models.Product{}).
+// DecreaseStock takes quantity units out of stock and returns how many are
+// left.
+func (p *ProductRepository) DecreaseStock(productID uint, quantity int) (int, error) {
+	var product models.Product
+	result := p.db.Model(&product).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "stock"}}}).
+		Where("id = ? AND stock >= ?", productID, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrInsufficientStock
+	}
+	return product.Stock, nil
+}
+
+// IncreaseStock puts units back, including on soft-deleted products so
+// returns of discontinued items still balance.
+func (p *ProductRepository) IncreaseStock(productID uint, quantity int) error {
+	return p.db.Unscoped().Model(&models.Product{}).
 		Where("id = ?", productID).
-		Update("stock", gorm.Expr("stock - ?", quantity)).
-		Error
+		Update("stock", gorm.Expr("stock + ?", quantity)).Error
 }
@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"instashop/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+var productSortColumns = map[string]string{
+	"price":      "local_price.amount",
+	"created_at": "products.created_at",
+	"name":       "products.name",
+}
+
+// ProductListFilter describes a catalog listing. Sort is a column name from
+// productSortColumns, prefixed with "-" for descending order. OnlyDeleted
+// lists soft-deleted products instead of live ones. MinPrice, MaxPrice and
+// the price sort are in Currency's minor units and compare what products
+// cost in Currency; sorting by price leaves out products with no rate into
+// it.
+type ProductListFilter struct {
+	Sort        string
+	Status      models.ProductStatus
+	Currency    string
+	MinPrice    *int64
+	MaxPrice    *int64
+	InStock     bool
+	OnlyDeleted bool
+}
+
+func (f ProductListFilter) sortColumn() (string, bool) {
+	field := strings.TrimPrefix(f.Sort, "-")
+	column, ok := productSortColumns[field]
+	if !ok {
+		return productSortColumns["created_at"], true
+	}
+	return column, strings.HasPrefix(f.Sort, "-")
+}
+
+func (f ProductListFilter) order() string {
+	column, desc := f.sortColumn()
+	if desc {
+		return fmt.Sprintf("%s DESC, products.id DESC", column)
+	}
+	return fmt.Sprintf("%s ASC, products.id ASC", column)
+}
+
+func (f ProductListFilter) sortsByPrice() bool {
+	return strings.TrimPrefix(f.Sort, "-") == "price"
+}
+
+func (f ProductListFilter) scopes() []func(*gorm.DB) *gorm.DB {
+	scopes := []func(*gorm.DB) *gorm.DB{
+		statusFilter(f.Status, f.OnlyDeleted),
+		stockFilter(f.InStock),
+	}
+	if f.MinPrice != nil || f.MaxPrice != nil || f.sortsByPrice() {
+		scopes = append(scopes, withLocalPrice(f.Currency), priceFilter(f.MinPrice, f.MaxPrice))
+	}
+	if f.sortsByPrice() {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB {
+			return db.Where("local_price.amount IS NOT NULL")
+		})
+	}
+	return scopes
+}
+
+func statusFilter(status models.ProductStatus, onlyDeleted bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if onlyDeleted {
+			db = db.Unscoped().Where("products.deleted_at IS NOT NULL")
+		}
+		if status != "" {
+			db = db.Where("products.status = ?", status)
+		}
+		return db
+	}
+}
+
+// ProductCursor is the keyset position of the last product on a page: its
+// value in the sort column plus its ID as a tie-breaker. Price values are in
+// Currency.
+type ProductCursor struct {
+	Sort     string          `json:"sort"`
+	Currency string          `json:"currency,omitempty"`
+	Value    json.RawMessage `json:"value"`
+	ID       uint            `json:"id"`
+}
+
+// NewProductCursor returns the position of product in the listing, where
+// price is what it costs in filter.Currency.
+func NewProductCursor(filter ProductListFilter, product models.Product, price int64) (*ProductCursor, error) {
+	cursor := &ProductCursor{Sort: filter.Sort, ID: product.ID}
+	var value interface{}
+	switch strings.TrimPrefix(filter.Sort, "-") {
+	case "price":
+		cursor.Currency = filter.Currency
+		value = price
+	case "name":
+		value = product.Name
+	default:
+		value = product.CreatedAt
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	cursor.Value = raw
+	return cursor, nil
+}
+
+func (c *ProductCursor) Encode() (string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func DecodeProductCursor(encoded string) (*ProductCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor ProductCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// Matches reports whether the cursor came from a listing like filter's.
+func (c *ProductCursor) Matches(filter ProductListFilter) bool {
+	return c.Sort == filter.Sort && (!filter.sortsByPrice() || c.Currency == filter.Currency)
+}
+
+func (c *ProductCursor) value() (interface{}, error) {
+	var err error
+	switch strings.TrimPrefix(c.Sort, "-") {
+	case "price":
+		var amount int64
+		err = json.Unmarshal(c.Value, &amount)
+		return amount, err
+	case "name":
+		var name string
+		err = json.Unmarshal(c.Value, &name)
+		return name, err
+	default:
+		var createdAt time.Time
+		err = json.Unmarshal(c.Value, &createdAt)
+		return createdAt, err
+	}
+}
+
+func (p *ProductRepository) ListPaginated(filter ProductListFilter, page, pageSize int) ([]models.Product, int64, error) {
+	var products []models.Product
+	var totalCount int64
+
+	query := p.db.Model(&models.Product{}).Scopes(filter.scopes()...).Session(&gorm.Session{})
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order(filter.order()).Limit(pageSize).Offset(offset).Find(&products).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return products, totalCount, nil
+}
+
+// ListAfterCursor returns up to limit products that sort after cursor, and
+// the cursor to continue from when more follow. A nil cursor starts from the
+// beginning.
+func (p *ProductRepository) ListAfterCursor(filter ProductListFilter, cursor *ProductCursor, limit int) ([]models.Product, *ProductCursor, error) {
+	var products []models.Product
+
+	query := p.db.Model(&models.Product{}).Scopes(filter.scopes()...)
+	if cursor != nil {
+		if !cursor.Matches(filter) {
+			return nil, nil, ErrInvalidCursor
+		}
+		value, err := cursor.value()
+		if err != nil {
+			return nil, nil, ErrInvalidCursor
+		}
+		column, desc := filter.sortColumn()
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%s, products.id) %s (?, ?)", column, op), value, cursor.ID)
+	}
+
+	// fetch one extra row to learn whether another page follows
+	if err := query.Order(filter.order()).Limit(limit + 1).Find(&products).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(products) <= limit {
+		return products, nil, nil
+	}
+	products = products[:limit]
+
+	last := products[len(products)-1]
+	var price int64
+	if filter.sortsByPrice() {
+		var prices []int64
+		err := p.db.Table("products").Scopes(withLocalPrice(filter.Currency)).
+			Where("products.id = ?", last.ID).
+			Pluck("local_price.amount", &prices).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(prices) == 1 {
+			price = prices[0]
+		}
+	}
+	next, err := NewProductCursor(filter, last, price)
+	if err != nil {
+		return nil, nil, err
+	}
+	return products, next, nil
+}
@@ -0,0 +1,300 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"instashop/models"
+)
+
+// trigramThreshold is the minimum pg_trgm similarity for a fuzzy match.
+const trigramThreshold = 0.2
+
+// localPriceJoin prices each product in @currency the way Pricer does: its
+// own price when it's in that currency, else its override in the currency,
+// else its price converted at the latest effective rate, or at the inverse of
+// the reverse pair's. local_price.amount is NULL when there's no rate.
+const localPriceJoin = `LEFT JOIN LATERAL (SELECT CASE
+	WHEN products.price_currency = @currency THEN products.price_amount
+	ELSE COALESCE(
+		(SELECT pp.amount FROM product_prices pp WHERE pp.product_id = products.id AND pp.currency = @currency),
+		CASE WHEN products.price_amount = 0 THEN 0 END,
+		ROUND(products.price_amount * COALESCE(
+			(SELECT er.rate FROM exchange_rates er
+			WHERE er.base_currency = products.price_currency AND er.quote_currency = @currency AND er.effective_at <= now()
+			ORDER BY er.effective_at DESC, er.id DESC LIMIT 1),
+			(SELECT 1 / NULLIF(er.rate, 0) FROM exchange_rates er
+			WHERE er.base_currency = @currency AND er.quote_currency = products.price_currency AND er.effective_at <= now()
+			ORDER BY er.effective_at DESC, er.id DESC LIMIT 1)
+		) * power(10::numeric, @minor_units - CASE WHEN products.price_currency IN @zero_decimal THEN 0 ELSE 2 END))::bigint)
+	END AS amount) local_price ON true`
+
+const inStockExpr = `(products.stock > 0 OR EXISTS (
+	SELECT 1 FROM product_variants pv
+	WHERE pv.product_id = products.id AND pv.stock > 0 AND pv.deleted_at IS NULL))`
+
+// ProductSearchFilter describes a catalog search. MinPrice, MaxPrice and the
+// price facets are in Currency's minor units, and compare what products cost
+// in Currency; products that can't be priced in it match no price range.
+type ProductSearchFilter struct {
+	Query    string
+	Currency string
+	MinPrice *int64
+	MaxPrice *int64
+	InStock  bool
+	Limit    int
+	Offset   int
+}
+
+type ProductSearchHit struct {
+	Product models.Product
+	Rank    float64
+}
+
+// PriceBucket bounds and labels are in major units of the currency searched
+// in.
+type PriceBucket struct {
+	Label string
+	Min   int64
+	Max   *int64
+}
+
+var PriceBuckets = []PriceBucket{
+	{Label: "0-10", Min: 0, Max: int64Ptr(10)},
+	{Label: "10-50", Min: 10, Max: int64Ptr(50)},
+	{Label: "50-100", Min: 50, Max: int64Ptr(100)},
+	{Label: "100-500", Min: 100, Max: int64Ptr(500)},
+	{Label: "500+", Min: 500},
+}
+
+// MinorBounds returns the bucket's bounds in currency's minor units.
+func (b PriceBucket) MinorBounds(currency string) (int64, *int64) {
+	scale := int64(1)
+	for i := 0; i < models.MinorUnits(currency); i++ {
+		scale *= 10
+	}
+	if b.Max == nil {
+		return b.Min * scale, nil
+	}
+	return b.Min * scale, int64Ptr(*b.Max * scale)
+}
+
+type FacetCount struct {
+	Value string
+	Count int64
+}
+
+type ProductSearchFacets struct {
+	PriceRanges  []FacetCount
+	Availability []FacetCount
+}
+
+// SearchFullText ranks products whose name or description match every term of
+// the query, treating each term as a prefix.
+func (p *ProductRepository) SearchFullText(filter ProductSearchFilter) ([]ProductSearchHit, int64, error) {
+	return p.search(filter, false)
+}
+
+// SearchTrigram is the typo-tolerant fallback used when full-text search finds
+// nothing. It ranks by trigram similarity instead of lexeme matches.
+func (p *ProductRepository) SearchTrigram(filter ProductSearchFilter) ([]ProductSearchHit, int64, error) {
+	return p.search(filter, true)
+}
+
+func (p *ProductRepository) SearchFacets(filter ProductSearchFilter, fuzzy bool) (*ProductSearchFacets, error) {
+	facets := &ProductSearchFacets{}
+
+	var cases []string
+	var args []interface{}
+	for _, bucket := range PriceBuckets {
+		minAmount, maxAmount := bucket.MinorBounds(filter.Currency)
+		if maxAmount == nil {
+			cases = append(cases, "WHEN local_price.amount >= ? THEN ?")
+			args = append(args, minAmount, bucket.Label)
+			continue
+		}
+		cases = append(cases, "WHEN local_price.amount >= ? AND local_price.amount < ? THEN ?")
+		args = append(args, minAmount, *maxAmount, bucket.Label)
+	}
+	bucketExpr := fmt.Sprintf("CASE %s END", strings.Join(cases, " "))
+
+	var priceRows []struct {
+		Value string
+		Count int64
+	}
+	priceQuery := p.db.Table("products").
+		Scopes(catalogVisible, withLocalPrice(filter.Currency), textMatch(filter.Query, fuzzy), stockFilter(filter.InStock)).
+		Select(bucketExpr+" AS value, count(*) AS count", args...).
+		Group("value")
+	if err := priceQuery.Scan(&priceRows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64)
+	for _, row := range priceRows {
+		counts[row.Value] = row.Count
+	}
+	for _, bucket := range PriceBuckets {
+		facets.PriceRanges = append(facets.PriceRanges, FacetCount{Value: bucket.Label, Count: counts[bucket.Label]})
+	}
+
+	var availability struct {
+		InStock    int64
+		OutOfStock int64
+	}
+	availabilityQuery := p.db.Table("products").
+		Scopes(catalogVisible, textMatch(filter.Query, fuzzy), filter.priceFilter()).
+		Select(fmt.Sprintf("count(*) FILTER (WHERE %[1]s) AS in_stock, count(*) FILTER (WHERE NOT %[1]s) AS out_of_stock", inStockExpr))
+	if err := availabilityQuery.Scan(&availability).Error; err != nil {
+		return nil, err
+	}
+	facets.Availability = []FacetCount{
+		{Value: "in_stock", Count: availability.InStock},
+		{Value: "out_of_stock", Count: availability.OutOfStock},
+	}
+
+	return facets, nil
+}
+
+func (p *ProductRepository) search(filter ProductSearchFilter, fuzzy bool) ([]ProductSearchHit, int64, error) {
+	query := p.db.Table("products").Scopes(
+		catalogVisible,
+		textMatch(filter.Query, fuzzy),
+		filter.priceFilter(),
+		stockFilter(filter.InStock),
+	).Session(&gorm.Session{})
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	if totalCount == 0 {
+		return nil, 0, nil
+	}
+
+	var ranked []struct {
+		ID   uint
+		Rank float64
+	}
+	rankExpr, rankArgs := rankExpression(filter.Query, fuzzy)
+	if err := query.Select("products.id, "+rankExpr+" AS rank", rankArgs...).
+		Order("rank DESC, products.id").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Scan(&ranked).Error; err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uint, 0, len(ranked))
+	for _, r := range ranked {
+		ids = append(ids, r.ID)
+	}
+	var products []models.Product
+	if err := p.db.Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	hits := make([]ProductSearchHit, 0, len(ranked))
+	for _, r := range ranked {
+		if product, ok := byID[r.ID]; ok {
+			hits = append(hits, ProductSearchHit{Product: product, Rank: r.Rank})
+		}
+	}
+
+	return hits, totalCount, nil
+}
+
+// catalogVisible limits raw product queries to live, active products. Queries
+// through models.Product get the deleted_at check from gorm already.
+func catalogVisible(db *gorm.DB) *gorm.DB {
+	return db.Where("products.deleted_at IS NULL AND products.status = ?", models.ProductStatusActive)
+}
+
+func textMatch(q string, fuzzy bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if fuzzy {
+			return db.Where("similarity(products.name, ?) > ? OR word_similarity(?, products.description) > ?",
+				q, trigramThreshold, q, trigramThreshold)
+		}
+		tsQuery := prefixTSQuery(q)
+		if tsQuery == "" {
+			return db
+		}
+		return db.Where("products.search_vector @@ to_tsquery('english', ?)", tsQuery)
+	}
+}
+
+func rankExpression(q string, fuzzy bool) (string, []interface{}) {
+	if fuzzy {
+		return "GREATEST(similarity(products.name, ?), word_similarity(?, products.description))", []interface{}{q, q}
+	}
+	tsQuery := prefixTSQuery(q)
+	if tsQuery == "" {
+		return "0", nil
+	}
+	return "ts_rank(products.search_vector, to_tsquery('english', ?))", []interface{}{tsQuery}
+}
+
+func (f ProductSearchFilter) priceFilter() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if f.MinPrice == nil && f.MaxPrice == nil {
+			return db
+		}
+		return db.Scopes(withLocalPrice(f.Currency), priceFilter(f.MinPrice, f.MaxPrice))
+	}
+}
+
+// withLocalPrice joins local_price.amount, what each product costs in
+// currency, for price filters, facets and sorting to compare.
+func withLocalPrice(currency string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins(localPriceJoin,
+			sql.Named("currency", currency),
+			sql.Named("minor_units", models.MinorUnits(currency)),
+			sql.Named("zero_decimal", models.ZeroDecimalCurrencies()))
+	}
+}
+
+// priceFilter bounds local_price.amount, so it needs withLocalPrice.
+func priceFilter(minPrice, maxPrice *int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if minPrice != nil {
+			db = db.Where("local_price.amount >= ?", *minPrice)
+		}
+		if maxPrice != nil {
+			db = db.Where("local_price.amount <= ?", *maxPrice)
+		}
+		return db
+	}
+}
+
+func stockFilter(inStock bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if inStock {
+			return db.Where(inStockExpr)
+		}
+		return db
+	}
+}
+
+// prefixTSQuery turns free text into a tsquery that ANDs every term as a
+// prefix match, e.g. "red sho" becomes "red:* & sho:*".
+func prefixTSQuery(q string) string {
+	terms := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
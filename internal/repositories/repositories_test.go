@@ -0,0 +1,288 @@
+package repositories_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	database "instashop/database"
+	"instashop/internal/repositories"
+	"instashop/internal/repositories/memory"
+	"instashop/models"
+)
+
+// testDB opens TEST_DATABASE_URL with a single connection whose search_path
+// starts at a throwaway schema, and migrates it. Tests that need it are
+// skipped when the variable isn't set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	if err := db.Exec(fmt.Sprintf("SET search_path TO %s, public", schema)).Error; err != nil {
+		t.Fatalf("setting search_path: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+
+	if err := database.Migrate(db, "USD"); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	return db
+}
+
+func createProduct(t *testing.T, productRepo repositories.ProductStore, name string, stock int) *models.Product {
+	t.Helper()
+	return createProductIn(t, productRepo, name, models.NewMoney(2000, "USD"), stock)
+}
+
+func createProductIn(t *testing.T, productRepo repositories.ProductStore, name string, price models.Money, stock int) *models.Product {
+	t.Helper()
+	product := &models.Product{
+		Name:        name,
+		Description: name + " description",
+		Price:       price,
+		Stock:       stock,
+		Status:      models.ProductStatusActive,
+		TaxClass:    models.DefaultTaxClass,
+	}
+	if err := productRepo.Create(product); err != nil {
+		t.Fatalf("creating product: %v", err)
+	}
+	return product
+}
+
+// createMixedCurrencyCatalog adds a 20 USD mug, a 1500 JPY teapot that costs
+// 10 USD, and a 40 EUR lamp with a 55 USD override.
+func createMixedCurrencyCatalog(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	productRepo, currencyRepo := repositories.NewProductRepository(db), repositories.NewCurrencyRepository(db)
+	for _, rate := range []models.ExchangeRate{
+		{BaseCurrency: "USD", QuoteCurrency: "JPY", Rate: 150},
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.1},
+	} {
+		rate.EffectiveAt = time.Now().Add(-time.Hour)
+		if err := currencyRepo.CreateExchangeRate(&rate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createProductIn(t, productRepo, "Mug", models.NewMoney(2000, "USD"), 5)
+	createProductIn(t, productRepo, "Teapot", models.NewMoney(1500, "JPY"), 5)
+	lamp := createProductIn(t, productRepo, "Lamp", models.NewMoney(4000, "EUR"), 5)
+	if err := currencyRepo.UpsertProductPrices([]models.ProductPrice{{ProductID: lamp.ID, Currency: "USD", Amount: 5500}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchComparesLocalPrices(t *testing.T) {
+	db := testDB(t)
+	createMixedCurrencyCatalog(t, db)
+	productRepo := repositories.NewProductRepository(db)
+
+	minPrice := int64(1500)
+	hits, total, err := productRepo.SearchFullText(repositories.ProductSearchFilter{Currency: "USD", MinPrice: &minPrice, Limit: 10})
+	if err != nil || total != 2 {
+		t.Fatalf("got %d hits, %v from 15 USD up; want 2", total, err)
+	}
+	for _, hit := range hits {
+		if hit.Product.Name == "Teapot" {
+			t.Fatal("the 10 USD teapot matched from 15 USD up")
+		}
+	}
+
+	facets, err := productRepo.SearchFacets(repositories.ProductSearchFilter{Currency: "USD"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int64{}
+	for _, facet := range facets.PriceRanges {
+		counts[facet.Value] = facet.Count
+	}
+	if counts["10-50"] != 2 || counts["50-100"] != 1 {
+		t.Fatalf("got USD price facets %v", counts)
+	}
+
+	// The mug costs 3000 yen, and the lamp has no rate into yen.
+	maxPrice := int64(2000)
+	hits, _, err = productRepo.SearchFullText(repositories.ProductSearchFilter{Currency: "JPY", MaxPrice: &maxPrice, Limit: 10})
+	if err != nil || len(hits) != 1 || hits[0].Product.Name != "Teapot" {
+		t.Fatalf("got %+v, %v up to 2000 JPY; want the teapot", hits, err)
+	}
+}
+
+func TestListSortsByLocalPrice(t *testing.T) {
+	db := testDB(t)
+	createMixedCurrencyCatalog(t, db)
+	productRepo := repositories.NewProductRepository(db)
+
+	filter := repositories.ProductListFilter{Sort: "price", Currency: "USD"}
+	products, total, err := productRepo.ListPaginated(filter, 1, 10)
+	if err != nil || total != 3 {
+		t.Fatalf("got %d products, %v; want 3", total, err)
+	}
+	if names := []string{products[0].Name, products[1].Name, products[2].Name}; names[0] != "Teapot" || names[1] != "Mug" || names[2] != "Lamp" {
+		t.Fatalf("got %v by USD price", names)
+	}
+
+	// Walk yen order a product at a time; the lamp has no rate into yen.
+	filter = repositories.ProductListFilter{Sort: "-price", Currency: "JPY"}
+	var names []string
+	var cursor *repositories.ProductCursor
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("cursor never ran out")
+		}
+		products, next, err := productRepo.ListAfterCursor(filter, cursor, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, product := range products {
+			names = append(names, product.Name)
+		}
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+	if len(names) != 2 || names[0] != "Mug" || names[1] != "Teapot" {
+		t.Fatalf("got %v by JPY price", names)
+	}
+}
+
+func TestProductDecreaseStock(t *testing.T) {
+	productRepo := repositories.NewProductRepository(testDB(t))
+	product := createProduct(t, productRepo, "Mug", 5)
+
+	// The remaining stock comes back through RETURNING.
+	left, err := productRepo.DecreaseStock(product.ID, 2)
+	if err != nil || left != 3 {
+		t.Fatalf("DecreaseStock = %d, %v; want 3, nil", left, err)
+	}
+	if _, err := productRepo.DecreaseStock(product.ID, 4); !errors.Is(err, repositories.ErrInsufficientStock) {
+		t.Fatalf("DecreaseStock past zero = %v, want ErrInsufficientStock", err)
+	}
+	stored, err := productRepo.FindByID(product.ID)
+	if err != nil || stored.Stock != 3 {
+		t.Fatalf("got stock %d, %v after a refused decrease; want 3", stored.Stock, err)
+	}
+}
+
+func TestVariantSoftDelete(t *testing.T) {
+	db := testDB(t)
+	productRepo, variantRepo := repositories.NewProductRepository(db), repositories.NewVariantRepository(db)
+	product := createProduct(t, productRepo, "Shirt", 0)
+	variant := &models.ProductVariant{ProductID: product.ID, SKU: "SHIRT-M", Stock: 2}
+	if err := variantRepo.Create(variant); err != nil {
+		t.Fatal(err)
+	}
+
+	if left, err := variantRepo.DecreaseStock(variant.ID, 1); err != nil || left != 1 {
+		t.Fatalf("DecreaseStock = %d, %v; want 1, nil", left, err)
+	}
+	if err := variantRepo.Delete(variant.ID); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := variantRepo.FindByID(variant.ID); err != nil || found != nil {
+		t.Fatalf("FindByID after delete = %+v, %v; want nothing", found, err)
+	}
+	if _, err := variantRepo.DecreaseStock(variant.ID, 1); !errors.Is(err, repositories.ErrInsufficientStock) {
+		t.Fatalf("DecreaseStock on a deleted variant = %v, want ErrInsufficientStock", err)
+	}
+
+	// Returned units still reach the deleted variant.
+	if err := variantRepo.IncreaseStock(variant.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	var stock int
+	if err := db.Raw("SELECT stock FROM product_variants WHERE id = ?", variant.ID).Scan(&stock).Error; err != nil || stock != 2 {
+		t.Fatalf("got stock %d, %v; want 2", stock, err)
+	}
+
+	// Only live variants hold on to their SKU.
+	if err := variantRepo.Create(&models.ProductVariant{ProductID: product.ID, SKU: "SHIRT-M", Stock: 1}); err != nil {
+		t.Fatalf("reusing a deleted variant's SKU: %v", err)
+	}
+	if err := variantRepo.Create(&models.ProductVariant{ProductID: product.ID, SKU: "SHIRT-M"}); !errors.Is(err, repositories.ErrSKUInUse) {
+		t.Fatalf("duplicating a live SKU = %v, want ErrSKUInUse", err)
+	}
+}
+
+func TestTransactionRollsBack(t *testing.T) {
+	db := testDB(t)
+	productRepo := repositories.NewProductRepository(db)
+	var product *models.Product
+
+	errFailed := errors.New("failed")
+	err := repositories.NewTransactor(db).Transaction(func(tx repositories.Tx) error {
+		product = createProduct(t, productRepo.WithTx(tx), "Lamp", 1)
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Transaction = %v, want errFailed", err)
+	}
+	if found, err := productRepo.FindByID(product.ID); err != nil || found != nil {
+		t.Fatalf("got %+v, %v after rolling back; want nothing", found, err)
+	}
+}
+
+func TestWithTxRejectsForeignHandles(t *testing.T) {
+	assertPanics := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s didn't panic", name)
+			}
+		}()
+		fn()
+	}
+
+	for _, tx := range []repositories.Tx{{}, repositories.NewTx("not a transaction")} {
+		assertPanics(fmt.Sprintf("gorm WithTx(%v)", tx.Scope()), func() {
+			repositories.NewProductRepository(nil).WithTx(tx)
+		})
+		assertPanics(fmt.Sprintf("memory WithTx(%v)", tx.Scope()), func() {
+			memory.NewProductRepository(memory.NewDB()).WithTx(tx)
+		})
+	}
+
+	// A memory transaction can only be joined on its own DB while it runs.
+	mine, other := memory.NewDB(), memory.NewDB()
+	var kept repositories.Tx
+	err := memory.NewTransactor(mine).Transaction(func(tx repositories.Tx) error {
+		kept = tx
+		memory.NewProductRepository(mine).WithTx(tx)
+		assertPanics("joining another DB's transaction", func() {
+			memory.NewProductRepository(other).WithTx(tx)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPanics("joining a finished transaction", func() {
+		memory.NewProductRepository(mine).WithTx(kept)
+	})
+}
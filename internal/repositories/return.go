@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"instashop/models"
+)
+
+type ReturnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) *ReturnRepository {
+	return &ReturnRepository{db}
+}
+
+func (r *ReturnRepository) WithTx(tx Tx) *ReturnRepository {
+	return &ReturnRepository{gormTx(tx)}
+}
+
+func (r *ReturnRepository) WithContext(ctx context.Context) *ReturnRepository {
+	return &ReturnRepository{r.db.WithContext(ctx)}
+}
+
+func (r *ReturnRepository) Create(request *models.ReturnRequest) error {
+	return r.db.Create(request).Error
+}
+
+func (r *ReturnRepository) FindByID(returnID uint) (*models.ReturnRequest, error) {
+	var request models.ReturnRequest
+	if err := r.db.Preload("Items").First(&request, returnID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// LockByID loads the return with its row locked for update.
+func (r *ReturnRepository) LockByID(returnID uint) (*models.ReturnRequest, error) {
+	var request models.ReturnRequest
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&request, returnID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *ReturnRepository) FindByOrderID(orderID uint) ([]models.ReturnRequest, error) {
+	var requests []models.ReturnRequest
+	if err := r.db.Preload("Items").Where("order_id = ?", orderID).Order("id").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (r *ReturnRepository) List(status models.ReturnStatus) ([]models.ReturnRequest, error) {
+	var requests []models.ReturnRequest
+	query := r.db.Preload("Items").Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// ReturnedQuantities returns, per order item, how many units are in returns
+// that haven't been rejected.
+func (r *ReturnRepository) ReturnedQuantities(orderID uint) (map[uint]int, error) {
+	var rows []struct {
+		OrderItemID uint
+		Quantity    int
+	}
+	err := r.db.Model(&models.ReturnItem{}).
+		Select("return_items.order_item_id, SUM(return_items.quantity) AS quantity").
+		Joins("JOIN return_requests ON return_requests.id = return_items.return_request_id").
+		Where("return_requests.order_id = ? AND return_requests.status <> ?", orderID, models.ReturnStatusRejected).
+		Group("return_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		quantities[row.OrderItemID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+// RefundedTotal sums the refunds recorded on the order's approved returns,
+// in minor units of the order's currency.
+func (r *ReturnRepository) RefundedTotal(orderID uint) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.ReturnRequest{}).
+		Select("COALESCE(SUM(refund_amount), 0)").
+		Where("order_id = ? AND status = ?", orderID, models.ReturnStatusApproved).
+		Scan(&total).Error
+	return total, err
+}
+
+// Resolve saves the outcome of a return along with each item's restock choice.
+func (r *ReturnRepository) Resolve(request *models.ReturnRequest) error {
+	if err := r.db.Omit(clause.Associations).Save(request).Error; err != nil {
+		return err
+	}
+	for _, item := range request.Items {
+		if err := r.db.Model(&item).Update("restock", item.Restock).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"instashop/models"
+)
+
+type ShippingRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingRepository(db *gorm.DB) *ShippingRepository {
+	return &ShippingRepository{db}
+}
+
+func (s *ShippingRepository) WithContext(ctx context.Context) ShippingStore {
+	return &ShippingRepository{s.db.WithContext(ctx)}
+}
+
+func (s *ShippingRepository) CreateZone(zone *models.ShippingZone) error {
+	return s.db.Create(zone).Error
+}
+
+func (s *ShippingRepository) FindZoneByID(zoneID uint) (*models.ShippingZone, error) {
+	var zone models.ShippingZone
+	if err := s.db.Preload("Methods").First(&zone, zoneID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func (s *ShippingRepository) FindZoneByName(name string) (*models.ShippingZone, error) {
+	var zone models.ShippingZone
+	if err := s.db.Where("name = ?", name).First(&zone).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func (s *ShippingRepository) ListZones() ([]models.ShippingZone, error) {
+	var zones []models.ShippingZone
+	err := s.db.Preload("Methods", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Order("id").Find(&zones).Error
+	if err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// DeleteZone removes the zone along with its methods.
+func (s *ShippingRepository) DeleteZone(zoneID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("zone_id = ?", zoneID).Delete(&models.ShippingMethod{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ShippingZone{}, zoneID).Error
+	})
+}
+
+func (s *ShippingRepository) CreateMethod(method *models.ShippingMethod) error {
+	return s.db.Create(method).Error
+}
+
+func (s *ShippingRepository) FindMethodByID(methodID uint) (*models.ShippingMethod, error) {
+	var method models.ShippingMethod
+	if err := s.db.First(&method, methodID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &method, nil
+}
+
+func (s *ShippingRepository) UpdateMethod(method *models.ShippingMethod) error {
+	return s.db.Save(method).Error
+}
+
+func (s *ShippingRepository) DeleteMethod(methodID uint) error {
+	return s.db.Delete(&models.ShippingMethod{}, methodID).Error
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"instashop/models"
+)
+
+// The Store interfaces are what services depend on, so they can run against
+// something other than Postgres in tests. The gorm repositories in this
+// package implement them; WithTx and WithContext return the same interface
+// so scoped copies can be used interchangeably. WithTx joins a Tx begun by
+// the matching TxRunner, which keeps gorm out of the services.
+
+type TxRunner interface {
+	WithContext(ctx context.Context) TxRunner
+	Transaction(fn func(tx Tx) error) error
+	WithAdvisoryLock(key int64, fn func() error) (bool, error)
+}
+
+type ProductStore interface {
+	WithTx(tx Tx) ProductStore
+	WithContext(ctx context.Context) ProductStore
+	Create(product *models.Product) error
+	CreateMany(products []models.Product) error
+	FindByID(productID uint) (*models.Product, error)
+	FindActiveByID(productID uint) (*models.Product, error)
+	FetchByNames(names []string) ([]models.Product, error)
+	FindByNameUnscoped(name string) (*models.Product, error)
+	Update(product *models.Product) error
+	Delete(productID uint) (bool, error)
+	Restore(productID uint) (bool, error)
+	ListAll() ([]models.Product, error)
+	DecreaseStock(productID uint, quantity int) (int, error)
+	IncreaseStock(productID uint, quantity int) error
+	ListPaginated(filter ProductListFilter, page, pageSize int) ([]models.Product, int64, error)
+	ListAfterCursor(filter ProductListFilter, cursor *ProductCursor, limit int) ([]models.Product, *ProductCursor, error)
+	SearchFullText(filter ProductSearchFilter) ([]ProductSearchHit, int64, error)
+	SearchTrigram(filter ProductSearchFilter) ([]ProductSearchHit, int64, error)
+	SearchFacets(filter ProductSearchFilter, fuzzy bool) (*ProductSearchFacets, error)
+}
+
+type VariantStore interface {
+	WithTx(tx Tx) VariantStore
+	WithContext(ctx context.Context) VariantStore
+	Create(variant *models.ProductVariant) error
+	FindByID(variantID uint) (*models.ProductVariant, error)
+	FindByProductID(productID uint) ([]models.ProductVariant, error)
+	FetchBySKUs(skus []string) ([]models.ProductVariant, error)
+	Update(variant *models.ProductVariant) error
+	SetOptionValues(variantID uint, optionValues []models.OptionValue) error
+	Delete(variantID uint) error
+	DecreaseStock(variantID uint, quantity int) (int, error)
+	IncreaseStock(variantID uint, quantity int) error
+	FindOrCreateOptionValue(typeName, value string) (*models.OptionValue, error)
+	ListOptionTypes() ([]models.OptionType, error)
+}
+
+type OrderStore interface {
+	WithTx(tx Tx) OrderStore
+	WithContext(ctx context.Context) OrderStore
+	Create(order *models.Order) error
+	FindByID(orderID uint) (*models.Order, bool, error)
+	LockByID(orderID uint) (*models.Order, error)
+	FindPendingBefore(before time.Time, limit int) ([]uint, error)
+	UpdateStatus(orderID uint, status models.OrderStatus) error
+	Delete(orderID uint) error
+	ListPaginated(filter OrderListFilter, page, pageSize int) ([]models.Order, int64, error)
+}
+
+type UserStore interface {
+	WithTx(tx Tx) UserStore
+	WithContext(ctx context.Context) UserStore
+	Create(user *models.User) error
+	FetchOne(filter models.User) (*models.User, bool, error)
+	UpdatePassword(userID uint, hashedPassword string) error
+}
+
+type CouponStore interface {
+	WithTx(tx Tx) CouponStore
+	WithContext(ctx context.Context) CouponStore
+	Create(coupon *models.Coupon) error
+	FindByID(couponID uint) (*models.Coupon, error)
+	FindByCode(code string) (*models.Coupon, error)
+	List() ([]models.Coupon, error)
+	Update(coupon *models.Coupon) error
+	Delete(couponID uint) error
+	CountUserRedemptions(couponID, userID uint) (int64, error)
+	Redeem(couponID, userID, orderID uint) error
+	Unredeem(orderID uint) error
+}
+
+type OutboxStore interface {
+	WithTx(tx Tx) OutboxStore
+	WithContext(ctx context.Context) OutboxStore
+	Add(events ...*models.OutboxEvent) error
+	FindDue(now time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkPublished(eventID uint, at time.Time) error
+	MarkFailed(eventID uint, status models.OutboxStatus, nextAttemptAt time.Time, lastError string) error
+	FindWebhooksUnqueued(limit int) ([]models.OutboxEvent, error)
+	MarkWebhooksQueued(eventID uint, at time.Time) error
+}
+
+type CurrencyStore interface {
+	WithTx(tx Tx) CurrencyStore
+	WithContext(ctx context.Context) CurrencyStore
+	CreateExchangeRate(rate *models.ExchangeRate) error
+	ListExchangeRates(base, quote string) ([]models.ExchangeRate, error)
+	FindEffectiveRate(base, quote string, at time.Time) (*models.ExchangeRate, error)
+	UpsertProductPrices(prices []models.ProductPrice) error
+	FindProductPrices(productID uint) ([]models.ProductPrice, error)
+	FindProductPricesIn(productIDs []uint, currency string) (map[uint]models.ProductPrice, error)
+	DeleteProductPrice(productID uint, currency string) error
+}
+
+type ShippingStore interface {
+	WithContext(ctx context.Context) ShippingStore
+	CreateZone(zone *models.ShippingZone) error
+	FindZoneByID(zoneID uint) (*models.ShippingZone, error)
+	FindZoneByName(name string) (*models.ShippingZone, error)
+	ListZones() ([]models.ShippingZone, error)
+	DeleteZone(zoneID uint) error
+	CreateMethod(method *models.ShippingMethod) error
+	FindMethodByID(methodID uint) (*models.ShippingMethod, error)
+	UpdateMethod(method *models.ShippingMethod) error
+	DeleteMethod(methodID uint) error
+}
+
+var (
+	_ TxRunner      = (*Transactor)(nil)
+	_ ProductStore  = (*ProductRepository)(nil)
+	_ VariantStore  = (*VariantRepository)(nil)
+	_ OrderStore    = (*OrderRepository)(nil)
+	_ UserStore     = (*UserRepository)(nil)
+	_ CouponStore   = (*CouponRepository)(nil)
+	_ OutboxStore   = (*OutboxRepository)(nil)
+	_ CurrencyStore = (*CurrencyRepository)(nil)
+	_ ShippingStore = (*ShippingRepository)(nil)
+)
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"instashop/models"
+)
+
+type TaxRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxRepository(db *gorm.DB) *TaxRepository {
+	return &TaxRepository{db}
+}
+
+func (t *TaxRepository) WithContext(ctx context.Context) *TaxRepository {
+	return &TaxRepository{t.db.WithContext(ctx)}
+}
+
+func (t *TaxRepository) Create(rule *models.TaxRule) error {
+	return t.db.Create(rule).Error
+}
+
+func (t *TaxRepository) FindByID(ruleID uint) (*models.TaxRule, error) {
+	var rule models.TaxRule
+	if err := t.db.First(&rule, ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindByScope returns the rule for exactly this country, region and class.
+func (t *TaxRepository) FindByScope(country, region, taxClass string) (*models.TaxRule, error) {
+	var rule models.TaxRule
+	err := t.db.Where("country = ? AND region = ? AND tax_class = ?", country, region, taxClass).
+		First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (t *TaxRepository) List(country string) ([]models.TaxRule, error) {
+	var rules []models.TaxRule
+	query := t.db.Order("country, region, tax_class")
+	if country != "" {
+		query = query.Where("country = ?", country)
+	}
+	if err := query.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// FindApplicable returns the country-wide and region-specific rules that
+// could apply to an address in country and region.
+func (t *TaxRepository) FindApplicable(country, region string) ([]models.TaxRule, error) {
+	var rules []models.TaxRule
+	err := t.db.Where("country = ? AND region IN ?", country, []string{"", region}).
+		Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (t *TaxRepository) Update(rule *models.TaxRule) error {
+	return t.db.Save(rule).Error
+}
+
+func (t *TaxRepository) Delete(ruleID uint) error {
+	return t.db.Delete(&models.TaxRule{}, ruleID).Error
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Tx is the transaction a TxRunner hands to its unit of work. Stores join
+// it through WithTx. What it holds is up to the TxRunner that began it, so
+// callers can pass it around without depending on the database library,
+// and only that implementation's stores can join it.
+type Tx struct {
+	scope interface{}
+}
+
+// NewTx wraps what an implementation's stores need to join a transaction.
+func NewTx(scope interface{}) Tx {
+	return Tx{scope}
+}
+
+// Scope returns what NewTx wrapped.
+func (t Tx) Scope() interface{} {
+	return t.scope
+}
+
+// gormTx returns the gorm transaction tx holds. A handle from another
+// implementation, or none at all, is a programming error that would
+// otherwise run outside the transaction, so it panics.
+func gormTx(tx Tx) *gorm.DB {
+	db, ok := tx.Scope().(*gorm.DB)
+	if !ok || db == nil {
+		panic(fmt.Sprintf("repositories: %T is not a gorm transaction", tx.Scope()))
+	}
+	return db
+}
+
+// Transactor runs a unit of work in a single database transaction.
+// Repositories join it through their WithTx method.
+type Transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db}
+}
+
+// WithContext returns a Transactor whose transactions run under ctx, so
+// they are rolled back if it is cancelled.
+func (t *Transactor) WithContext(ctx context.Context) TxRunner {
+	return &Transactor{t.db.WithContext(ctx)}
+}
+
+func (t *Transactor) Transaction(fn func(tx Tx) error) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewTx(tx))
+	})
+}
+
+// WithAdvisoryLock runs fn while holding the Postgres advisory lock key, so
+// that only one app instance does the work at a time. If another session
+// holds the lock fn is skipped and false is returned. The lock is tied to a
+// transaction and is released when fn returns or the connection drops.
+func (t *Transactor) WithAdvisoryLock(key int64, fn func() error) (bool, error) {
+	acquired := false
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", key).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		return fn()
+	})
+	return acquired, err
+}
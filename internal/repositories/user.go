@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"gorm.io/gorm"
 	"instashop/models"
@@ -16,6 +17,14 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	}
 }
 
+func (a *UserRepository) WithTx(tx Tx) UserStore {
+	return &UserRepository{gormTx(tx)}
+}
+
+func (a *UserRepository) WithContext(ctx context.Context) UserStore {
+	return &UserRepository{a.db.WithContext(ctx)}
+}
+
 func (a *UserRepository) Create(input *models.User) error {
 	return a.db.Create(input).Error
 }
@@ -30,3 +39,7 @@ func (a *UserRepository) FetchOne(filter models.User) (*models.User, bool, error
 	}
 	return &user, true, nil
 }
+
+func (a *UserRepository) UpdatePassword(userID uint, hashedPassword string) error {
+	return a.db.Model(&models.User{}).Where("id = ?", userID).Update("password", hashedPassword).Error
+}
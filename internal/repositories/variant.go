@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"instashop/models"
+)
+
+type VariantRepository struct {
+	db *gorm.DB
+}
+
+func NewVariantRepository(db *gorm.DB) *VariantRepository {
+	return &VariantRepository{db}
+}
+
+func (v *VariantRepository) WithTx(tx Tx) VariantStore {
+	return &VariantRepository{gormTx(tx)}
+}
+
+func (v *VariantRepository) WithContext(ctx context.Context) VariantStore {
+	return &VariantRepository{v.db.WithContext(ctx)}
+}
+
+// ErrSKUInUse is returned when a live variant already has the SKU.
+var ErrSKUInUse = errors.New("sku already in use")
+
+func (v *VariantRepository) Create(variant *models.ProductVariant) error {
+	return v.skuErr(v.db.Create(variant).Error)
+}
+
+func (v *VariantRepository) FindByID(variantID uint) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	if err := v.db.Preload("OptionValues.OptionType").First(&variant, variantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (v *VariantRepository) FindByProductID(productID uint) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if err := v.db.Preload("OptionValues.OptionType").
+		Where("product_id = ?", productID).
+		Order("id").
+		Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (v *VariantRepository) FetchBySKUs(skus []string) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if err := v.db.Where("sku IN ?", skus).Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (v *VariantRepository) Update(variant *models.ProductVariant) error {
+	return v.skuErr(v.db.Omit(clause.Associations).Save(variant).Error)
+}
+
+// skuErr turns a violation of the unique SKU index, the only one on
+// product_variants, into ErrSKUInUse.
+func (v *VariantRepository) skuErr(err error) error {
+	if translator, ok := v.db.Dialector.(gorm.ErrorTranslator); ok && err != nil {
+		if errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+			return ErrSKUInUse
+		}
+	}
+	return err
+}
+
+// SetOptionValues replaces the variant's option values.
+func (v *VariantRepository) SetOptionValues(variantID uint, optionValues []models.OptionValue) error {
+	return v.db.Model(&models.ProductVariant{ID: variantID}).Association("OptionValues").Replace(optionValues)
+}
+
+// Delete soft-deletes the variant so order items referencing it still
+// resolve. Its option values are kept with it.
+func (v *VariantRepository) Delete(variantID uint) error {
+	return v.db.Delete(&models.ProductVariant{}, variantID).Error
+}
+
+func (v *VariantRepository) DecreaseStock(variantID uint, quantity int) (int, error) {
+	var variant models.ProductVariant
+	result := v.db.Model(&variant).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "stock"}}}).
+		Where("id = ? AND stock >= ?", variantID, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrInsufficientStock
+	}
+	return variant.Stock, nil
+}
+
+// IncreaseStock puts units back, including on soft-deleted variants so
+// returns of discontinued items still balance.
+func (v *VariantRepository) IncreaseStock(variantID uint, quantity int) error {
+	return v.db.Unscoped().Model(&models.ProductVariant{}).
+		Where("id = ?", variantID).
+		Update("stock", gorm.Expr("stock + ?", quantity)).Error
+}
+
+// FindOrCreateOptionValue returns the value for the named option type,
+// creating the type and value on first use.
+func (v *VariantRepository) FindOrCreateOptionValue(typeName, value string) (*models.OptionValue, error) {
+	var optionValue models.OptionValue
+	err := v.db.Transaction(func(tx *gorm.DB) error {
+		optionType := models.OptionType{Name: typeName}
+		if err := tx.Where(models.OptionType{Name: typeName}).FirstOrCreate(&optionType).Error; err != nil {
+			return err
+		}
+		return tx.Where(models.OptionValue{OptionTypeID: optionType.ID, Value: value}).
+			FirstOrCreate(&optionValue).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &optionValue, nil
+}
+
+func (v *VariantRepository) ListOptionTypes() ([]models.OptionType, error) {
+	var optionTypes []models.OptionType
+	if err := v.db.Preload("Values").Order("name").Find(&optionTypes).Error; err != nil {
+		return nil, err
+	}
+	return optionTypes, nil
+}
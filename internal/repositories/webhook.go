@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"instashop/models"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db}
+}
+
+func (w *WebhookRepository) WithContext(ctx context.Context) *WebhookRepository {
+	return &WebhookRepository{w.db.WithContext(ctx)}
+}
+
+func (w *WebhookRepository) CreateEndpoint(endpoint *models.WebhookEndpoint) error {
+	return w.db.Create(endpoint).Error
+}
+
+func (w *WebhookRepository) FindEndpointByID(endpointID uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := w.db.First(&endpoint, endpointID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (w *WebhookRepository) ListEndpoints() ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := w.db.Order("id").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (w *WebhookRepository) ListActiveEndpoints() ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := w.db.Where("is_active = ?", true).Order("id").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (w *WebhookRepository) UpdateEndpoint(endpoint *models.WebhookEndpoint) error {
+	return w.db.Save(endpoint).Error
+}
+
+// DeleteEndpoint removes the endpoint along with its delivery log.
+func (w *WebhookRepository) DeleteEndpoint(endpointID uint) error {
+	return w.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("endpoint_id = ?", endpointID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookEndpoint{}, endpointID).Error
+	})
+}
+
+// AddDeliveries stores deliveries, skipping any for an endpoint and event
+// that already has one.
+func (w *WebhookRepository) AddDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return w.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func (w *WebhookRepository) FindDeliveryByID(deliveryID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := w.db.First(&delivery, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindDueDeliveries returns pending deliveries whose next attempt is due,
+// oldest first.
+func (w *WebhookRepository) FindDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := w.db.
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (w *WebhookRepository) ListDeliveries(endpointID uint, status models.WebhookDeliveryStatus, page, pageSize int) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var totalCount int64
+
+	query := w.db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Limit(pageSize).Offset(offset).Find(&deliveries).Error
+	return deliveries, totalCount, err
+}
+
+// SaveAttempt records the outcome of a delivery attempt.
+func (w *WebhookRepository) SaveAttempt(delivery *models.WebhookDelivery) error {
+	return w.db.Model(delivery).Select(
+		"status", "attempts", "next_attempt_at", "response_status",
+		"response_body", "last_error", "delivered_at",
+	).Updates(delivery).Error
+}
@@ -5,19 +5,38 @@ import (
 	"gorm.io/gorm"
 	"instashop/internal/common"
 	"instashop/internal/handlers"
+	"instashop/internal/middleware"
+	"instashop/internal/ratelimit"
 	"instashop/internal/repositories"
 	"instashop/internal/services"
+	"instashop/internal/utils"
 	"instashop/internal/validators"
 )
 
 func RegisterAuthRoutes(router fiber.Router, db *gorm.DB) {
 	restErr := common.NewRestErr()
 	userRepo := repositories.NewUserRepository(db)
-	authSvc := services.NewAuthService(userRepo, restErr)
+	authSvc := services.NewAuthService(
+		repositories.NewTransactor(db),
+		userRepo,
+		repositories.NewOutboxRepository(db),
+		restErr,
+	)
 	validator := validators.NewAuthValidator(userRepo, restErr)
 	handler := handlers.NewAuthHandler(authSvc, restErr)
+	rateLimiter := middleware.NewRateLimiter(ratelimit.Default(), restErr)
+	loginLimit := rateLimiter.Limit(middleware.RateLimitPolicy{
+		Name:  "login",
+		Limit: ratelimit.MustParseLimit(utils.GetConfig().RateLimitLogin),
+		Key:   middleware.KeyByIP,
+	})
+	signupLimit := rateLimiter.Limit(middleware.RateLimitPolicy{
+		Name:  "signup",
+		Limit: ratelimit.MustParseLimit(utils.GetConfig().RateLimitSignup),
+		Key:   middleware.KeyByIP,
+	})
 
 	userRouter := router.Group("auth")
-	userRouter.Post("/login", validator.ValidateLogin, handler.Login)
-	userRouter.Post("/signup", validator.ValidateSignup, handler.Signup)
+	userRouter.Post("/login", loginLimit, validator.ValidateLogin, handler.Login)
+	userRouter.Post("/signup", signupLimit, validator.ValidateSignup, handler.Signup)
 }
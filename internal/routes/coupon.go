@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"instashop/internal/common"
+	"instashop/internal/handlers"
+	"instashop/internal/middleware"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/internal/validators"
+)
+
+func RegisterCouponRoutes(router fiber.Router, db *gorm.DB) {
+	restErr := common.NewRestErr()
+	authMiddleware := middleware.NewAuthMiddleware(restErr)
+	couponRepo := repositories.NewCouponRepository(db)
+	couponSvc := services.NewCouponService(couponRepo, restErr)
+	couponValidator := validators.NewCouponValidator()
+	couponHandler := handlers.NewCouponHandler(couponSvc, restErr)
+
+	couponRouter := router.Group("coupon")
+	couponRouter.Use(authMiddleware.ValidateAuthHeaderToken)
+	couponRouter.Use(middleware.AdminOnly)
+
+	couponRouter.Post("/", couponValidator.ValidateCreateCoupon, couponHandler.CreateCoupon)
+	couponRouter.Get("/", couponHandler.ListCoupons)
+	couponRouter.Get("/:couponID", couponHandler.GetCoupon)
+	couponRouter.Patch("/:couponID", couponValidator.ValidateUpdateCoupon, couponHandler.UpdateCoupon)
+	couponRouter.Delete("/:couponID", couponHandler.DeleteCoupon)
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"instashop/internal/common"
+	"instashop/internal/handlers"
+	"instashop/internal/middleware"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/internal/validators"
+)
+
+func RegisterCurrencyRoutes(router fiber.Router, db *gorm.DB) {
+	restErr := common.NewRestErr()
+	authMiddleware := middleware.NewAuthMiddleware(restErr)
+	currencyRepo := repositories.NewCurrencyRepository(db)
+	currencySvc := services.NewCurrencyService(currencyRepo, restErr)
+	currencyValidator := validators.NewCurrencyValidator()
+	currencyHandler := handlers.NewCurrencyHandler(currencySvc, restErr)
+
+	currencyRouter := router.Group("exchange-rate")
+	currencyRouter.Use(authMiddleware.ValidateAuthHeaderToken)
+	currencyRouter.Use(middleware.AdminOnly)
+
+	currencyRouter.Post("/", currencyValidator.ValidateCreateExchangeRate, currencyHandler.CreateExchangeRate)
+	currencyRouter.Get("/", currencyHandler.ListExchangeRates)
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"instashop/internal/common"
+	"instashop/internal/handlers"
+	"instashop/internal/middleware"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/internal/validators"
+)
+
+func RegisterFulfillmentRoutes(router fiber.Router, db *gorm.DB) {
+	restErr := common.NewRestErr()
+	authMiddleware := middleware.NewAuthMiddleware(restErr)
+	fulfillmentSvc := services.NewFulfillmentService(
+		repositories.NewTransactor(db),
+		repositories.NewOrderRepository(db),
+		repositories.NewFulfillmentRepository(db),
+		repositories.NewOutboxRepository(db),
+		restErr,
+	)
+	fulfillmentValidator := validators.NewFulfillmentValidator()
+	fulfillmentHandler := handlers.NewFulfillmentHandler(fulfillmentSvc, restErr)
+
+	fulfillmentRouter := router.Group("fulfillment")
+	fulfillmentRouter.Use(authMiddleware.ValidateAuthHeaderToken)
+	fulfillmentRouter.Use(middleware.AdminOnly)
+
+	fulfillmentRouter.Post("/orders/:orderID", fulfillmentValidator.ValidateCreateFulfillment, fulfillmentHandler.CreateFulfillment)
+	fulfillmentRouter.Get("/orders/:orderID", fulfillmentHandler.ListFulfillments)
+	fulfillmentRouter.Patch("/:fulfillmentID/delivered", fulfillmentHandler.MarkDelivered)
+}
@@ -3,27 +3,86 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"instashop/internal/cache"
 	"instashop/internal/common"
 	"instashop/internal/handlers"
 	"instashop/internal/middleware"
+	"instashop/internal/ratelimit"
 	"instashop/internal/repositories"
 	"instashop/internal/services"
+	"instashop/internal/utils"
 	"instashop/internal/validators"
+	"instashop/models"
 )
 
 func RegisterOrderRoutes(router fiber.Router, db *gorm.DB) {
 	restErr := common.NewRestErr()
 	authMiddleware := middleware.NewAuthMiddleware(restErr)
+	idempotency := middleware.NewIdempotencyMiddleware(repositories.NewIdempotencyRepository(db), restErr)
 	orderRepo := repositories.NewOrderRepository(db)
 	productRepo := repositories.NewProductRepository(db)
-	orderSvc := services.NewOrderService(orderRepo, productRepo, restErr)
+	variantRepo := repositories.NewVariantRepository(db)
+	currencyRepo := repositories.NewCurrencyRepository(db)
+	couponRepo := repositories.NewCouponRepository(db)
+	pricer := services.NewPricer(currencyRepo)
+	productCache := services.NewProductCache(cache.Default(), utils.GetConfig().CacheTTL)
+	discounts := services.NewDiscountEngine(couponRepo, pricer, restErr)
+	tax := services.NewRuleTaxCalculator(
+		repositories.NewTaxRepository(db),
+		models.TaxMode(utils.GetConfig().TaxMode),
+	)
+	shipping := services.NewShippingCalculator(repositories.NewShippingRepository(db), pricer)
+	orderSvc := services.NewOrderService(
+		repositories.NewTransactor(db),
+		orderRepo,
+		productRepo,
+		variantRepo,
+		couponRepo,
+		repositories.NewOutboxRepository(db),
+		pricer,
+		productCache,
+		discounts,
+		tax,
+		shipping,
+		restErr,
+	)
+	fulfillmentSvc := services.NewFulfillmentService(
+		repositories.NewTransactor(db),
+		orderRepo,
+		repositories.NewFulfillmentRepository(db),
+		repositories.NewOutboxRepository(db),
+		restErr,
+	)
+	returnSvc := services.NewReturnService(
+		repositories.NewTransactor(db),
+		orderRepo,
+		repositories.NewReturnRepository(db),
+		productRepo,
+		variantRepo,
+		productCache,
+		restErr,
+	)
 	orderValidator := validators.NewOrderValidator()
+	returnValidator := validators.NewReturnValidator()
 	orderHandler := handlers.NewOrderHandler(orderSvc, restErr)
+	fulfillmentHandler := handlers.NewFulfillmentHandler(fulfillmentSvc, restErr)
+	returnHandler := handlers.NewReturnHandler(returnSvc, restErr)
+
+	placeOrderLimit := middleware.NewRateLimiter(ratelimit.Default(), restErr).Limit(middleware.RateLimitPolicy{
+		Name:  "place-order",
+		Limit: ratelimit.MustParseLimit(utils.GetConfig().RateLimitPlaceOrder),
+		Key:   middleware.KeyByUser,
+	})
 
 	orderRouter := router.Group("order")
 	orderRouter.Use(authMiddleware.ValidateAuthHeaderToken)
 
-	orderRouter.Post("/place-order", orderValidator.ValidatePlaceOrder, orderHandler.PlaceOrder)
-	orderRouter.Get("/list-order", orderHandler.ListOrders)
+	orderRouter.Post("/place-order", placeOrderLimit, idempotency.Handle, orderValidator.ValidatePlaceOrder, orderHandler.PlaceOrder)
+	orderRouter.Post("/shipping-quote", orderValidator.ValidateShippingQuote, orderHandler.QuoteShipping)
+	orderRouter.Get("/list-order", orderValidator.ValidateListOrders, orderHandler.ListOrders)
+	orderRouter.Get("/:orderID", orderHandler.GetOrder)
 	orderRouter.Patch("/:orderID/cancel", orderHandler.CancelOrder)
+	orderRouter.Get("/:orderID/tracking", fulfillmentHandler.GetTracking)
+	orderRouter.Post("/:orderID/returns", idempotency.Handle, returnValidator.ValidateCreateReturn, returnHandler.RequestReturn)
+	orderRouter.Get("/:orderID/returns", returnHandler.ListOrderReturns)
 }
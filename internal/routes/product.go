@@ -3,11 +3,13 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"instashop/internal/cache"
 	"instashop/internal/common"
 	"instashop/internal/handlers"
 	"instashop/internal/middleware"
 	"instashop/internal/repositories"
 	"instashop/internal/services"
+	"instashop/internal/utils"
 	"instashop/internal/validators"
 )
 
@@ -15,8 +17,21 @@ func RegisterProductRoutes(router fiber.Router, db *gorm.DB) {
 	restErr := common.NewRestErr()
 	authMiddleware := middleware.NewAuthMiddleware(restErr)
 	productRepo := repositories.NewProductRepository(db)
-	productSvc := services.NewProductService(productRepo, restErr)
+	variantRepo := repositories.NewVariantRepository(db)
+	currencyRepo := repositories.NewCurrencyRepository(db)
+	pricer := services.NewPricer(currencyRepo)
+	productSvc := services.NewProductService(
+		repositories.NewTransactor(db),
+		productRepo,
+		variantRepo,
+		currencyRepo,
+		repositories.NewOutboxRepository(db),
+		pricer,
+		services.NewProductCache(cache.Default(), utils.GetConfig().CacheTTL),
+		restErr,
+	)
 	productValidator := validators.NewProductValidator()
+	currencyValidator := validators.NewCurrencyValidator()
 	productHandler := handlers.NewProductHandler(productSvc, restErr)
 
 	productRouter := router.Group("product")
@@ -24,8 +39,22 @@ func RegisterProductRoutes(router fiber.Router, db *gorm.DB) {
 	productRouter.Use(middleware.AdminOnly)
 
 	productRouter.Post("/create-product", productValidator.ValidateCreateProduct, productHandler.CreateProduct)
-	productRouter.Get("/list-products", productHandler.ListProducts)
+	productRouter.Get("/list-products", productValidator.ValidateListProducts, productHandler.ListProducts)
+	productRouter.Get("/option-types", productHandler.ListOptionTypes)
+	productRouter.Patch("/variants/:variantID", productValidator.ValidateUpdateVariant, productHandler.UpdateVariant)
+	productRouter.Delete("/variants/:variantID", productHandler.DeleteVariant)
+	productRouter.Post("/:productID/variants", productValidator.ValidateCreateVariants, productHandler.CreateVariants)
+	productRouter.Get("/:productID/variants", productHandler.ListVariants)
+	productRouter.Put("/:productID/prices", currencyValidator.ValidateSetProductPrices, productHandler.SetProductPrices)
+	productRouter.Get("/:productID/prices", productHandler.ListProductPrices)
+	productRouter.Delete("/:productID/prices/:currency", productHandler.DeleteProductPrice)
 	productRouter.Get("/:productID", productHandler.GetProduct)
 	productRouter.Patch("/:productID", productValidator.ValidateUpdateProduct, productHandler.UpdateProduct)
 	productRouter.Delete("/:productID", productHandler.DeleteProduct)
+	productRouter.Patch("/:productID/restore", productHandler.RestoreProduct)
+
+	catalogRouter := router.Group("catalog")
+	catalogRouter.Get("/search", productValidator.ValidateSearchProducts, productHandler.SearchProducts)
+	catalogRouter.Get("/products", productValidator.ValidateListProducts, productHandler.ListCatalogProducts)
+	catalogRouter.Get("/products/:productID", productHandler.GetCatalogProduct)
 }
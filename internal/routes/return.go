@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"instashop/internal/cache"
+	"instashop/internal/common"
+	"instashop/internal/handlers"
+	"instashop/internal/middleware"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/internal/utils"
+	"instashop/internal/validators"
+)
+
+func RegisterReturnRoutes(router fiber.Router, db *gorm.DB) {
+	restErr := common.NewRestErr()
+	authMiddleware := middleware.NewAuthMiddleware(restErr)
+	returnSvc := services.NewReturnService(
+		repositories.NewTransactor(db),
+		repositories.NewOrderRepository(db),
+		repositories.NewReturnRepository(db),
+		repositories.NewProductRepository(db),
+		repositories.NewVariantRepository(db),
+		services.NewProductCache(cache.Default(), utils.GetConfig().CacheTTL),
+		restErr,
+	)
+	returnValidator := validators.NewReturnValidator()
+	returnHandler := handlers.NewReturnHandler(returnSvc, restErr)
+
+	returnRouter := router.Group("returns")
+	returnRouter.Use(authMiddleware.ValidateAuthHeaderToken)
+	returnRouter.Use(middleware.AdminOnly)
+
+	returnRouter.Get("/", returnHandler.ListReturns)
+	returnRouter.Get("/:returnID", returnHandler.GetReturn)
+	returnRouter.Patch("/:returnID/approve", returnValidator.ValidateApproveReturn, returnHandler.ApproveReturn)
+	returnRouter.Patch("/:returnID/reject", returnValidator.ValidateRejectReturn, returnHandler.RejectReturn)
+}
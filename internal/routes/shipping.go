@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"instashop/internal/common"
+	"instashop/internal/handlers"
+	"instashop/internal/middleware"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/internal/validators"
+)
+
+func RegisterShippingRoutes(router fiber.Router, db *gorm.DB) {
+	restErr := common.NewRestErr()
+	authMiddleware := middleware.NewAuthMiddleware(restErr)
+	shippingRepo := repositories.NewShippingRepository(db)
+	shippingSvc := services.NewShippingService(shippingRepo, restErr)
+	shippingValidator := validators.NewShippingValidator()
+	shippingHandler := handlers.NewShippingHandler(shippingSvc, restErr)
+
+	shippingRouter := router.Group("shipping")
+	shippingRouter.Use(authMiddleware.ValidateAuthHeaderToken)
+	shippingRouter.Use(middleware.AdminOnly)
+
+	shippingRouter.Post("/zones", shippingValidator.ValidateCreateZone, shippingHandler.CreateZone)
+	shippingRouter.Get("/zones", shippingHandler.ListZones)
+	shippingRouter.Delete("/zones/:zoneID", shippingHandler.DeleteZone)
+	shippingRouter.Post("/zones/:zoneID/methods", shippingValidator.ValidateCreateMethod, shippingHandler.CreateMethod)
+	shippingRouter.Patch("/methods/:methodID", shippingValidator.ValidateUpdateMethod, shippingHandler.UpdateMethod)
+	shippingRouter.Delete("/methods/:methodID", shippingHandler.DeleteMethod)
+}
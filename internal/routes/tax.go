@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"instashop/internal/common"
+	"instashop/internal/handlers"
+	"instashop/internal/middleware"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/internal/validators"
+)
+
+func RegisterTaxRoutes(router fiber.Router, db *gorm.DB) {
+	restErr := common.NewRestErr()
+	authMiddleware := middleware.NewAuthMiddleware(restErr)
+	taxRepo := repositories.NewTaxRepository(db)
+	taxSvc := services.NewTaxService(taxRepo, restErr)
+	taxValidator := validators.NewTaxValidator()
+	taxHandler := handlers.NewTaxHandler(taxSvc, restErr)
+
+	taxRouter := router.Group("tax-rule")
+	taxRouter.Use(authMiddleware.ValidateAuthHeaderToken)
+	taxRouter.Use(middleware.AdminOnly)
+
+	taxRouter.Post("/", taxValidator.ValidateCreateTaxRule, taxHandler.CreateTaxRule)
+	taxRouter.Get("/", taxHandler.ListTaxRules)
+	taxRouter.Patch("/:ruleID", taxValidator.ValidateUpdateTaxRule, taxHandler.UpdateTaxRule)
+	taxRouter.Delete("/:ruleID", taxHandler.DeleteTaxRule)
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"instashop/internal/common"
+	"instashop/internal/handlers"
+	"instashop/internal/middleware"
+	"instashop/internal/repositories"
+	"instashop/internal/services"
+	"instashop/internal/validators"
+)
+
+func RegisterWebhookRoutes(router fiber.Router, db *gorm.DB) {
+	restErr := common.NewRestErr()
+	authMiddleware := middleware.NewAuthMiddleware(restErr)
+	webhookSvc := services.NewWebhookService(repositories.NewWebhookRepository(db), restErr)
+	webhookValidator := validators.NewWebhookValidator()
+	webhookHandler := handlers.NewWebhookHandler(webhookSvc, restErr)
+
+	webhookRouter := router.Group("webhook")
+	webhookRouter.Use(authMiddleware.ValidateAuthHeaderToken)
+	webhookRouter.Use(middleware.AdminOnly)
+
+	webhookRouter.Post("/", webhookValidator.ValidateCreateWebhook, webhookHandler.CreateWebhook)
+	webhookRouter.Get("/", webhookHandler.ListWebhooks)
+	webhookRouter.Get("/:endpointID", webhookHandler.GetWebhook)
+	webhookRouter.Patch("/:endpointID", webhookValidator.ValidateUpdateWebhook, webhookHandler.UpdateWebhook)
+	webhookRouter.Delete("/:endpointID", webhookHandler.DeleteWebhook)
+	webhookRouter.Get("/:endpointID/deliveries", webhookValidator.ValidateListDeliveries, webhookHandler.ListDeliveries)
+	webhookRouter.Post("/deliveries/:deliveryID/redeliver", webhookHandler.Redeliver)
+}
@@ -1,37 +1,46 @@
 package services
 
 import (
+	"context"
+
 	"github.com/gofiber/fiber/v2/log"
 	"go.uber.org/zap"
 	"instashop/internal/common"
 	"instashop/internal/dtos"
+	"instashop/internal/events"
 	"instashop/internal/repositories"
 	"instashop/internal/utils"
 	"instashop/models"
 )
 
 type AuthClient interface {
-	Login(input dtos.LoginDTO) (*dtos.LoginResp, *common.RestErr)
-	Signup(input dtos.SignUpDTO) (*models.GetUser, *common.RestErr)
+	Login(ctx context.Context, input dtos.LoginDTO) (*dtos.LoginResp, *common.RestErr)
+	Signup(ctx context.Context, input dtos.SignUpDTO) (*models.GetUser, *common.RestErr)
 }
 
 type AuthService struct {
-	userRepo *repositories.UserRepository
-	restErr  *common.RestErr
+	transactor repositories.TxRunner
+	userRepo   repositories.UserStore
+	outboxRepo repositories.OutboxStore
+	restErr    *common.RestErr
 }
 
 func NewAuthService(
-	userRepo *repositories.UserRepository,
+	transactor repositories.TxRunner,
+	userRepo repositories.UserStore,
+	outboxRepo repositories.OutboxStore,
 	restErr *common.RestErr,
 ) AuthClient {
 	return &AuthService{
+		transactor,
 		userRepo,
+		outboxRepo,
 		restErr,
 	}
 }
 
-func (a *AuthService) Login(input dtos.LoginDTO) (*dtos.LoginResp, *common.RestErr) {
-	user, exist, err := a.userRepo.FetchOne(models.User{Email: input.Email})
+func (a *AuthService) Login(ctx context.Context, input dtos.LoginDTO) (*dtos.LoginResp, *common.RestErr) {
+	user, exist, err := a.userRepo.WithContext(ctx).FetchOne(models.User{Email: input.Email})
 	if err != nil {
 		log.Error(zap.Error(err))
 		return nil, a.restErr.ServerError(common.ErrSomethingWentWrong)
@@ -65,8 +74,8 @@ func (a *AuthService) Login(input dtos.LoginDTO) (*dtos.LoginResp, *common.RestE
 	return &dtos.LoginResp{Token: token}, nil
 }
 
-func (a *AuthService) Signup(input dtos.SignUpDTO) (*models.GetUser, *common.RestErr) {
-	_, exist, err := a.userRepo.FetchOne(models.User{Email: input.Email})
+func (a *AuthService) Signup(ctx context.Context, input dtos.SignUpDTO) (*models.GetUser, *common.RestErr) {
+	_, exist, err := a.userRepo.WithContext(ctx).FetchOne(models.User{Email: input.Email})
 	if err != nil {
 		log.Error(zap.Error(err))
 		return nil, a.restErr.ServerError(common.ErrSomethingWentWrong)
@@ -90,7 +99,15 @@ func (a *AuthService) Signup(input dtos.SignUpDTO) (*models.GetUser, *common.Res
 		PhoneNumber: input.PhoneNumber,
 	}
 
-	err = a.userRepo.Create(&newUser)
+	err = a.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		if err := a.userRepo.WithTx(tx).Create(&newUser); err != nil {
+			return err
+		}
+		return recordEvents(a.outboxRepo.WithTx(tx), events.New(events.UserSignedUp, events.UserPayload{
+			UserID: newUser.ID,
+			Email:  newUser.Email,
+		}))
+	})
 	if err != nil {
 		log.Error(zap.Error(err))
 		return nil, a.restErr.ServerError(common.ErrSomethingWentWrong)
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/events"
+	"instashop/internal/utils"
+	"instashop/models"
+)
+
+func signupRequest(email string) dtos.SignUpDTO {
+	return dtos.SignUpDTO{
+		Email:       email,
+		FirstName:   "Ada",
+		LastName:    "Obi",
+		Password:    "password123",
+		PhoneNumber: "+2348000000000",
+	}
+}
+
+func TestSignup(t *testing.T) {
+	env := newTestEnv(t)
+
+	user, srvErr := env.authService.Signup(env.ctx, signupRequest("ada@example.com"))
+	assertNoRestErr(t, srvErr)
+
+	if user.ID == 0 || user.Email != "ada@example.com" || user.IsVerified == nil || *user.IsVerified {
+		t.Fatalf("got user %+v", user)
+	}
+	stored, exists, err := env.users.FetchOne(models.User{Email: "ada@example.com"})
+	if err != nil || !exists {
+		t.Fatalf("user not stored: %v", err)
+	}
+	if stored.Password == "password123" {
+		t.Fatal("password stored in plain text")
+	}
+	if names := env.eventNames(); !reflect.DeepEqual(names, []string{events.UserSignedUp}) {
+		t.Fatalf("got events %v", names)
+	}
+}
+
+func TestSignupDuplicateEmail(t *testing.T) {
+	env := newTestEnv(t)
+	_, srvErr := env.authService.Signup(env.ctx, signupRequest("ada@example.com"))
+	assertNoRestErr(t, srvErr)
+
+	_, srvErr = env.authService.Signup(env.ctx, signupRequest("ada@example.com"))
+	assertRestErr(t, srvErr, common.ErrEmailAlreadyInUse)
+	if len(env.outbox.Events()) != 1 {
+		t.Fatalf("got %d events, want 1", len(env.outbox.Events()))
+	}
+}
+
+func TestLogin(t *testing.T) {
+	env := newTestEnv(t)
+	user, srvErr := env.authService.Signup(env.ctx, signupRequest("ada@example.com"))
+	assertNoRestErr(t, srvErr)
+
+	resp, srvErr := env.authService.Login(env.ctx, dtos.LoginDTO{Email: "ada@example.com", Password: "password123"})
+	assertNoRestErr(t, srvErr)
+
+	claims, err := utils.ValidateAuthToken(resp.Token, utils.GetConfig().JWTSecretKey)
+	if err != nil {
+		t.Fatalf("invalid token: %v", err)
+	}
+	if claims.ID != user.ID || claims.Email != "ada@example.com" || claims.Role != "user" {
+		t.Fatalf("got claims %+v", claims)
+	}
+}
+
+func TestLoginAdminRole(t *testing.T) {
+	env := newTestEnv(t)
+	hash, err := utils.HashPassword("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	isAdmin := true
+	if err := env.users.Create(&models.User{Email: "admin@example.com", Password: hash, IsAdmin: &isAdmin}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, srvErr := env.authService.Login(env.ctx, dtos.LoginDTO{Email: "admin@example.com", Password: "password123"})
+	assertNoRestErr(t, srvErr)
+
+	claims, err := utils.ValidateAuthToken(resp.Token, utils.GetConfig().JWTSecretKey)
+	if err != nil {
+		t.Fatalf("invalid token: %v", err)
+	}
+	if claims.Role != "admin" {
+		t.Fatalf("got role %q, want admin", claims.Role)
+	}
+}
+
+func TestLoginRejects(t *testing.T) {
+	env := newTestEnv(t)
+	_, srvErr := env.authService.Signup(env.ctx, signupRequest("ada@example.com"))
+	assertNoRestErr(t, srvErr)
+
+	_, srvErr = env.authService.Login(env.ctx, dtos.LoginDTO{Email: "ada@example.com", Password: "wrong-password"})
+	assertRestErr(t, srvErr, common.ErrInvalidPassword)
+
+	_, srvErr = env.authService.Login(env.ctx, dtos.LoginDTO{Email: "bob@example.com", Password: "password123"})
+	assertRestErr(t, srvErr, common.ErrUserWithEmailNotFound)
+}
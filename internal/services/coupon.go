@@ -0,0 +1,147 @@
+package services
+
+import (
+	"math"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/repositories"
+	"instashop/internal/utils"
+	"instashop/models"
+)
+
+type CouponClient interface {
+	CreateCoupon(input dtos.CreateCouponRequest) (*models.Coupon, *common.RestErr)
+	GetCoupon(couponID uint) (*models.Coupon, *common.RestErr)
+	ListCoupons() ([]models.Coupon, *common.RestErr)
+	UpdateCoupon(couponID uint, input dtos.UpdateCouponRequest) (*models.Coupon, *common.RestErr)
+	DeleteCoupon(couponID uint) *common.RestErr
+}
+
+type CouponService struct {
+	couponRepo repositories.CouponStore
+	restErr    *common.RestErr
+}
+
+func NewCouponService(
+	couponRepo repositories.CouponStore,
+	restErr *common.RestErr,
+) CouponClient {
+	return &CouponService{
+		couponRepo,
+		restErr,
+	}
+}
+
+func (c *CouponService) CreateCoupon(input dtos.CreateCouponRequest) (*models.Coupon, *common.RestErr) {
+	couponType := models.CouponType(input.Type)
+	if couponType == models.CouponTypePercentage && input.PercentOff == 0 ||
+		couponType == models.CouponTypeFixed && input.AmountOff == 0 {
+		return nil, c.restErr.BadRequest(common.ErrCouponInvalid)
+	}
+	if input.StartsAt != nil && input.EndsAt != nil && !input.EndsAt.After(*input.StartsAt) {
+		return nil, c.restErr.BadRequest(common.ErrBadRequest)
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(input.Code))
+	existing, err := c.couponRepo.FindByCode(code)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, c.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if existing != nil {
+		return nil, c.restErr.BadRequest(common.ErrCouponCodeInUse)
+	}
+
+	currency := storeCurrencyOr(input.Currency)
+	coupon := models.Coupon{
+		Code:         code,
+		Type:         couponType,
+		AmountOff:    models.NewMoney(input.AmountOff, currency),
+		MinSpend:     models.NewMoney(input.MinSpend, currency),
+		UsageLimit:   input.UsageLimit,
+		PerUserLimit: input.PerUserLimit,
+		StartsAt:     input.StartsAt,
+		EndsAt:       input.EndsAt,
+		ProductIDs:   input.ProductIDs,
+		IsActive:     utils.BoolPointer(true),
+	}
+	if couponType == models.CouponTypePercentage {
+		coupon.PercentOff = int(math.Round(input.PercentOff * 100))
+	}
+	for _, category := range input.Categories {
+		coupon.Categories = append(coupon.Categories, strings.ToLower(category))
+	}
+
+	if err := c.couponRepo.Create(&coupon); err != nil {
+		log.Error(zap.Error(err))
+		return nil, c.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return &coupon, nil
+}
+
+func (c *CouponService) GetCoupon(couponID uint) (*models.Coupon, *common.RestErr) {
+	coupon, err := c.couponRepo.FindByID(couponID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, c.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if coupon == nil {
+		return nil, c.restErr.NotFound(common.ErrCouponNotFound)
+	}
+
+	return coupon, nil
+}
+
+func (c *CouponService) ListCoupons() ([]models.Coupon, *common.RestErr) {
+	coupons, err := c.couponRepo.List()
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, c.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return coupons, nil
+}
+
+func (c *CouponService) UpdateCoupon(couponID uint, input dtos.UpdateCouponRequest) (*models.Coupon, *common.RestErr) {
+	coupon, srvErr := c.GetCoupon(couponID)
+	if srvErr != nil {
+		return nil, srvErr
+	}
+
+	if input.IsActive != nil {
+		coupon.IsActive = input.IsActive
+	}
+	if input.UsageLimit != nil {
+		coupon.UsageLimit = input.UsageLimit
+	}
+	if input.PerUserLimit != nil {
+		coupon.PerUserLimit = input.PerUserLimit
+	}
+	if input.StartsAt != nil {
+		coupon.StartsAt = input.StartsAt
+	}
+	if input.EndsAt != nil {
+		coupon.EndsAt = input.EndsAt
+	}
+
+	if err := c.couponRepo.Update(coupon); err != nil {
+		log.Error(zap.Error(err))
+		return nil, c.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return coupon, nil
+}
+
+func (c *CouponService) DeleteCoupon(couponID uint) *common.RestErr {
+	if err := c.couponRepo.Delete(couponID); err != nil {
+		log.Error(zap.Error(err))
+		return c.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return nil
+}
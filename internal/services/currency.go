@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+type CurrencyClient interface {
+	CreateExchangeRate(input dtos.CreateExchangeRateRequest) (*models.ExchangeRate, *common.RestErr)
+	ListExchangeRates(base, quote string) ([]models.ExchangeRate, *common.RestErr)
+}
+
+type CurrencyService struct {
+	currencyRepo repositories.CurrencyStore
+	restErr      *common.RestErr
+}
+
+func NewCurrencyService(
+	currencyRepo repositories.CurrencyStore,
+	restErr *common.RestErr,
+) CurrencyClient {
+	return &CurrencyService{
+		currencyRepo,
+		restErr,
+	}
+}
+
+func (c *CurrencyService) CreateExchangeRate(input dtos.CreateExchangeRateRequest) (*models.ExchangeRate, *common.RestErr) {
+	base, quote := strings.ToUpper(input.BaseCurrency), strings.ToUpper(input.QuoteCurrency)
+	if base == quote {
+		return nil, c.restErr.BadRequest(common.ErrSameCurrencyPair)
+	}
+
+	effectiveAt := time.Now()
+	if input.EffectiveAt != nil {
+		effectiveAt = *input.EffectiveAt
+	}
+
+	rate := models.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          input.Rate,
+		EffectiveAt:   effectiveAt,
+	}
+	if err := c.currencyRepo.CreateExchangeRate(&rate); err != nil {
+		log.Error(zap.Error(err))
+		return nil, c.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return &rate, nil
+}
+
+func (c *CurrencyService) ListExchangeRates(base, quote string) ([]models.ExchangeRate, *common.RestErr) {
+	rates, err := c.currencyRepo.ListExchangeRates(strings.ToUpper(base), strings.ToUpper(quote))
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, c.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return rates, nil
+}
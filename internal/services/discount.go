@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+// DiscountLine is an order line as the discount engine sees it.
+type DiscountLine struct {
+	ProductID uint
+	Category  string
+	Total     models.Money
+}
+
+// AppliedCoupon is the outcome of applying a coupon to an order. Amount is
+// in the order's currency and never exceeds the eligible subtotal.
+// Allocations splits Amount across the order's lines, in line order, in
+// proportion to each eligible line's total.
+type AppliedCoupon struct {
+	Coupon       *models.Coupon
+	Amount       models.Money
+	Allocations  []models.Money
+	FreeShipping bool
+}
+
+// DiscountEngine checks a coupon against an order and works out the
+// discount it grants. Redemption happens separately, when the order is
+// written, so that usage limits are enforced transactionally.
+type DiscountEngine struct {
+	couponRepo repositories.CouponStore
+	pricer     *Pricer
+	restErr    *common.RestErr
+}
+
+func NewDiscountEngine(
+	couponRepo repositories.CouponStore,
+	pricer *Pricer,
+	restErr *common.RestErr,
+) *DiscountEngine {
+	return &DiscountEngine{
+		couponRepo,
+		pricer,
+		restErr,
+	}
+}
+
+func (d *DiscountEngine) Apply(ctx context.Context, code string, userID uint, lines []DiscountLine, subtotal models.Money) (*AppliedCoupon, *common.RestErr) {
+	couponRepo := d.couponRepo.WithContext(ctx)
+	coupon, err := couponRepo.FindByCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, d.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if coupon == nil {
+		return nil, d.restErr.BadRequest(common.ErrCouponNotFound)
+	}
+
+	now := time.Now()
+	if coupon.IsActive != nil && !*coupon.IsActive ||
+		coupon.StartsAt != nil && now.Before(*coupon.StartsAt) ||
+		coupon.EndsAt != nil && !now.Before(*coupon.EndsAt) {
+		return nil, d.restErr.BadRequest(common.ErrCouponNotActive)
+	}
+
+	if coupon.UsageLimit != nil && coupon.TimesUsed >= *coupon.UsageLimit {
+		return nil, d.restErr.BadRequest(common.ErrCouponUsageLimitReached)
+	}
+	if coupon.PerUserLimit != nil {
+		used, err := couponRepo.CountUserRedemptions(coupon.ID, userID)
+		if err != nil {
+			log.Error(zap.Error(err))
+			return nil, d.restErr.ServerError(common.ErrSomethingWentWrong)
+		}
+		if used >= int64(*coupon.PerUserLimit) {
+			return nil, d.restErr.BadRequest(common.ErrCouponUsageLimitReached)
+		}
+	}
+
+	if !coupon.MinSpend.IsZero() {
+		minSpend, err := d.pricer.Convert(ctx, coupon.MinSpend, subtotal.Currency)
+		if err != nil {
+			return nil, pricingErr(d.restErr, err)
+		}
+		if subtotal.Amount < minSpend.Amount {
+			return nil, d.restErr.BadRequest(common.ErrCouponMinSpendNotMet)
+		}
+	}
+
+	eligible := models.NewMoney(0, subtotal.Currency)
+	for _, line := range lines {
+		if !couponCovers(coupon, line) {
+			continue
+		}
+		if eligible, err = eligible.Add(line.Total); err != nil {
+			return nil, d.restErr.ServerError(common.ErrSomethingWentWrong)
+		}
+	}
+	if eligible.IsZero() {
+		return nil, d.restErr.BadRequest(common.ErrCouponNotApplicable)
+	}
+
+	applied := &AppliedCoupon{Coupon: coupon, Amount: models.NewMoney(0, subtotal.Currency)}
+	switch coupon.Type {
+	case models.CouponTypePercentage:
+		applied.Amount = eligible.Percent(int64(coupon.PercentOff))
+	case models.CouponTypeFixed:
+		amountOff, err := d.pricer.Convert(ctx, coupon.AmountOff, subtotal.Currency)
+		if err != nil {
+			return nil, pricingErr(d.restErr, err)
+		}
+		applied.Amount = amountOff
+	case models.CouponTypeFreeShipping:
+		applied.FreeShipping = true
+	}
+	if applied.Amount.Amount > eligible.Amount {
+		applied.Amount = eligible
+	}
+	applied.Allocations = allocate(coupon, lines, applied.Amount, eligible)
+
+	return applied, nil
+}
+
+// allocate spreads amount over the lines the coupon covers. Rounding
+// leftovers go to the last covered line so the parts sum to amount.
+func allocate(coupon *models.Coupon, lines []DiscountLine, amount, eligible models.Money) []models.Money {
+	allocations := make([]models.Money, len(lines))
+	remaining, last := amount.Amount, -1
+	for i, line := range lines {
+		allocations[i] = models.NewMoney(0, amount.Currency)
+		if !couponCovers(coupon, line) {
+			continue
+		}
+		share := amount.Amount * line.Total.Amount / eligible.Amount
+		allocations[i].Amount = share
+		remaining -= share
+		last = i
+	}
+	if last >= 0 {
+		allocations[last].Amount += remaining
+	}
+	return allocations
+}
+
+// redeemErr maps a failed redemption to the response for the customer.
+func (d *DiscountEngine) redeemErr(err error) *common.RestErr {
+	if errors.Is(err, repositories.ErrCouponExhausted) || errors.Is(err, repositories.ErrCouponUserLimitReached) {
+		return d.restErr.BadRequest(common.ErrCouponUsageLimitReached)
+	}
+	log.Error(zap.Error(err))
+	return d.restErr.ServerError(common.ErrSomethingWentWrong)
+}
+
+func couponCovers(coupon *models.Coupon, line DiscountLine) bool {
+	if len(coupon.ProductIDs) == 0 && len(coupon.Categories) == 0 {
+		return true
+	}
+	for _, productID := range coupon.ProductIDs {
+		if productID == line.ProductID {
+			return true
+		}
+	}
+	for _, category := range coupon.Categories {
+		if strings.EqualFold(category, line.Category) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"instashop/internal/events"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+// recordEvents writes events to the outbox. outboxRepo should be bound to the
+// transaction making the change, so the events commit or roll back with it.
+func recordEvents(outboxRepo repositories.OutboxStore, evts ...events.Event) error {
+	records := make([]*models.OutboxEvent, len(evts))
+	for i, event := range evts {
+		record, err := event.Record()
+		if err != nil {
+			return err
+		}
+		records[i] = record
+	}
+	return outboxRepo.Add(records...)
+}
+
+func orderEvent(name string, order *models.Order) events.Event {
+	return events.New(name, events.OrderPayload{
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Status:  string(order.Status),
+		Total:   order.TotalPrice,
+	})
+}
+
+func productEvent(name string, product *models.Product) events.Event {
+	return events.New(name, events.ProductPayload{
+		ProductID: product.ID,
+		Name:      product.Name,
+		Status:    string(product.Status),
+		Price:     product.Price,
+		Stock:     product.Stock,
+	})
+}
+
+// stockLowEvent returns a product.stock_low event if taking quantity units
+// brought stock from above threshold to at or below it.
+func stockLowEvent(item models.OrderItem, stock, threshold int) (events.Event, bool) {
+	if stock > threshold || stock+item.Quantity <= threshold {
+		return events.Event{}, false
+	}
+	return events.New(events.ProductStockLow, events.StockPayload{
+		ProductID: item.ProductID,
+		VariantID: item.VariantID,
+		Stock:     stock,
+		Threshold: threshold,
+	}), true
+}
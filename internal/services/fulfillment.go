@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/events"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+var (
+	errOrderNotFound          = errors.New("order not found")
+	errOrderNotFulfillable    = errors.New("order not fulfillable")
+	errInvalidFulfillmentItem = errors.New("invalid fulfillment item")
+	errNothingToFulfill       = errors.New("nothing to fulfill")
+)
+
+type FulfillmentClient interface {
+	CreateFulfillment(ctx context.Context, orderID uint, input dtos.CreateFulfillmentRequest) (*dtos.ShipmentDetail, *common.RestErr)
+	ListFulfillments(ctx context.Context, orderID uint) ([]dtos.ShipmentDetail, *common.RestErr)
+	MarkDelivered(ctx context.Context, fulfillmentID uint) *common.RestErr
+	GetTracking(ctx context.Context, userID, orderID uint) (*dtos.TrackingResponse, *common.RestErr)
+}
+
+type FulfillmentService struct {
+	transactor      repositories.TxRunner
+	orderRepo       repositories.OrderStore
+	fulfillmentRepo *repositories.FulfillmentRepository
+	outboxRepo      repositories.OutboxStore
+	restErr         *common.RestErr
+}
+
+func NewFulfillmentService(
+	transactor repositories.TxRunner,
+	orderRepo repositories.OrderStore,
+	fulfillmentRepo *repositories.FulfillmentRepository,
+	outboxRepo repositories.OutboxStore,
+	restErr *common.RestErr,
+) FulfillmentClient {
+	return &FulfillmentService{
+		transactor,
+		orderRepo,
+		fulfillmentRepo,
+		outboxRepo,
+		restErr,
+	}
+}
+
+// CreateFulfillment records a shipment for an order. With no items given it
+// ships everything not yet shipped. The order moves to partially_shipped or
+// shipped depending on what remains.
+func (f *FulfillmentService) CreateFulfillment(ctx context.Context, orderID uint, input dtos.CreateFulfillmentRequest) (*dtos.ShipmentDetail, *common.RestErr) {
+	fulfillment := models.Fulfillment{
+		OrderID:        orderID,
+		Carrier:        input.Carrier,
+		TrackingNumber: input.TrackingNumber,
+		TrackingURL:    input.TrackingURL,
+		Status:         models.FulfillmentStatusShipped,
+		ShippedAt:      time.Now(),
+	}
+	var order *models.Order
+
+	err := f.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		orderRepo, fulfillmentRepo := f.orderRepo.WithTx(tx), f.fulfillmentRepo.WithTx(tx)
+
+		var err error
+		order, err = orderRepo.LockByID(orderID)
+		if err != nil {
+			return err
+		}
+		if order == nil {
+			return errOrderNotFound
+		}
+		if order.Status != models.OrderStatusPending && order.Status != models.OrderStatusPartiallyShipped {
+			return errOrderNotFulfillable
+		}
+
+		shipped, err := fulfillmentRepo.FulfilledQuantities(orderID)
+		if err != nil {
+			return err
+		}
+		remaining := make(map[uint]int, len(order.Items))
+		for _, item := range order.Items {
+			remaining[item.ID] = item.Quantity - shipped[item.ID]
+		}
+
+		if len(input.Items) == 0 {
+			for _, item := range order.Items {
+				if remaining[item.ID] > 0 {
+					fulfillment.Items = append(fulfillment.Items, models.FulfillmentItem{
+						OrderItemID: item.ID,
+						Quantity:    remaining[item.ID],
+					})
+					remaining[item.ID] = 0
+				}
+			}
+		}
+		for _, item := range input.Items {
+			left, ok := remaining[item.OrderItemID]
+			if !ok || item.Quantity > left {
+				return errInvalidFulfillmentItem
+			}
+			remaining[item.OrderItemID] -= item.Quantity
+			fulfillment.Items = append(fulfillment.Items, models.FulfillmentItem{
+				OrderItemID: item.OrderItemID,
+				Quantity:    item.Quantity,
+			})
+		}
+		if len(fulfillment.Items) == 0 {
+			return errNothingToFulfill
+		}
+
+		if err := fulfillmentRepo.Create(&fulfillment); err != nil {
+			return err
+		}
+
+		status := models.OrderStatusShipped
+		for _, left := range remaining {
+			if left > 0 {
+				status = models.OrderStatusPartiallyShipped
+			}
+		}
+		if err := orderRepo.UpdateStatus(orderID, status); err != nil {
+			return err
+		}
+
+		order.Status = status
+		return recordEvents(f.outboxRepo.WithTx(tx), orderEvent(events.OrderShipped, order))
+	})
+	if err != nil {
+		return nil, f.fulfillmentErr(err)
+	}
+
+	shipment := toShipmentDetail(fulfillment, order.Items)
+	return &shipment, nil
+}
+
+func (f *FulfillmentService) ListFulfillments(ctx context.Context, orderID uint) ([]dtos.ShipmentDetail, *common.RestErr) {
+	order, exists, err := f.orderRepo.WithContext(ctx).FindByID(orderID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, f.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if !exists {
+		return nil, f.restErr.NotFound(common.ErrOrderNotFound)
+	}
+
+	return f.shipments(ctx, order)
+}
+
+// MarkDelivered records that a shipment arrived. Once every shipment of a
+// fully shipped order has arrived the order becomes delivered.
+func (f *FulfillmentService) MarkDelivered(ctx context.Context, fulfillmentID uint) *common.RestErr {
+	fulfillment, err := f.fulfillmentRepo.WithContext(ctx).FindByID(fulfillmentID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return f.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if fulfillment == nil {
+		return f.restErr.NotFound(common.ErrFulfillmentNotFound)
+	}
+	if fulfillment.Status == models.FulfillmentStatusDelivered {
+		return nil
+	}
+
+	err = f.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		orderRepo, fulfillmentRepo := f.orderRepo.WithTx(tx), f.fulfillmentRepo.WithTx(tx)
+
+		order, err := orderRepo.LockByID(fulfillment.OrderID)
+		if err != nil {
+			return err
+		}
+		if order == nil {
+			return errOrderNotFound
+		}
+		if err := fulfillmentRepo.MarkDelivered(fulfillmentID, time.Now()); err != nil {
+			return err
+		}
+
+		if order.Status != models.OrderStatusShipped {
+			return nil
+		}
+		undelivered, err := fulfillmentRepo.CountUndelivered(order.ID)
+		if err != nil {
+			return err
+		}
+		if undelivered > 0 {
+			return nil
+		}
+		if err := orderRepo.UpdateStatus(order.ID, models.OrderStatusDelivered); err != nil {
+			return err
+		}
+
+		order.Status = models.OrderStatusDelivered
+		return recordEvents(f.outboxRepo.WithTx(tx), orderEvent(events.OrderDelivered, order))
+	})
+	if err != nil {
+		return f.fulfillmentErr(err)
+	}
+
+	return nil
+}
+
+func (f *FulfillmentService) GetTracking(ctx context.Context, userID, orderID uint) (*dtos.TrackingResponse, *common.RestErr) {
+	order, exists, err := f.orderRepo.WithContext(ctx).FindByID(orderID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, f.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if !exists || order.UserID != userID {
+		return nil, f.restErr.NotFound(common.ErrOrderNotFound)
+	}
+
+	shipments, srvErr := f.shipments(ctx, order)
+	if srvErr != nil {
+		return nil, srvErr
+	}
+
+	return &dtos.TrackingResponse{
+		OrderID:   order.ID,
+		Status:    string(order.Status),
+		Shipments: shipments,
+	}, nil
+}
+
+func (f *FulfillmentService) shipments(ctx context.Context, order *models.Order) ([]dtos.ShipmentDetail, *common.RestErr) {
+	fulfillments, err := f.fulfillmentRepo.WithContext(ctx).FindByOrderID(order.ID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, f.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	shipments := make([]dtos.ShipmentDetail, 0, len(fulfillments))
+	for _, fulfillment := range fulfillments {
+		shipments = append(shipments, toShipmentDetail(fulfillment, order.Items))
+	}
+	return shipments, nil
+}
+
+func (f *FulfillmentService) fulfillmentErr(err error) *common.RestErr {
+	switch {
+	case errors.Is(err, errOrderNotFound):
+		return f.restErr.NotFound(common.ErrOrderNotFound)
+	case errors.Is(err, errOrderNotFulfillable):
+		return f.restErr.BadRequest(common.ErrOrderNotFulfillable)
+	case errors.Is(err, errInvalidFulfillmentItem):
+		return f.restErr.BadRequest(common.ErrInvalidFulfillmentItem)
+	case errors.Is(err, errNothingToFulfill):
+		return f.restErr.BadRequest(common.ErrNothingToFulfill)
+	}
+	log.Error(zap.Error(err))
+	return f.restErr.ServerError(common.ErrSomethingWentWrong)
+}
+
+func toShipmentDetail(fulfillment models.Fulfillment, orderItems []models.OrderItem) dtos.ShipmentDetail {
+	byID := make(map[uint]models.OrderItem, len(orderItems))
+	for _, item := range orderItems {
+		byID[item.ID] = item
+	}
+
+	shipment := dtos.ShipmentDetail{
+		ID:             fulfillment.ID,
+		Carrier:        fulfillment.Carrier,
+		TrackingNumber: fulfillment.TrackingNumber,
+		TrackingURL:    fulfillment.TrackingURL,
+		Status:         string(fulfillment.Status),
+		ShippedAt:      fulfillment.ShippedAt,
+		DeliveredAt:    fulfillment.DeliveredAt,
+		Items:          make([]dtos.ShipmentItemDetail, 0, len(fulfillment.Items)),
+	}
+	for _, item := range fulfillment.Items {
+		orderItem := byID[item.OrderItemID]
+		shipment.Items = append(shipment.Items, dtos.ShipmentItemDetail{
+			OrderItemID: item.OrderItemID,
+			ProductName: orderItem.ProductName,
+			SKU:         orderItem.SKU,
+			Quantity:    item.Quantity,
+		})
+	}
+	return shipment
+}
@@ -1,115 +1,395 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
 	"instashop/internal/common"
 	"instashop/internal/dtos"
+	"instashop/internal/events"
 	"instashop/internal/repositories"
+	"instashop/internal/utils"
 	"instashop/models"
 )
 
+const descriptionExcerptLength = 200
+
+var errOrderNotCancellable = errors.New("order not cancellable")
+
 type OrderClient interface {
-	PlaceOrder(input dtos.PlaceOrderRequest, userID uint) (*models.Order, *common.RestErr)
-	ListOrders(userID uint) ([]dtos.OrderResponse, *common.RestErr)
-	CancelOrder(userID, orderID uint) *common.RestErr
+	PlaceOrder(ctx context.Context, input dtos.PlaceOrderRequest, userID uint) (*models.Order, *common.RestErr)
+	QuoteShipping(ctx context.Context, input dtos.ShippingQuoteRequest) ([]dtos.ShippingQuote, *common.RestErr)
+	GetOrder(ctx context.Context, userID, orderID uint) (*dtos.OrderResponse, *common.RestErr)
+	ListOrders(ctx context.Context, userID uint, input dtos.ListOrdersRequest) ([]dtos.OrderResponse, int64, *common.RestErr)
+	CancelOrder(ctx context.Context, userID, orderID uint) *common.RestErr
 }
 
 type OrderService struct {
-	orderRepo   *repositories.OrderRepository
-	productRepo *repositories.ProductRepository
-	restErr     *common.RestErr
+	transactor   repositories.TxRunner
+	orderRepo    repositories.OrderStore
+	productRepo  repositories.ProductStore
+	variantRepo  repositories.VariantStore
+	couponRepo   repositories.CouponStore
+	outboxRepo   repositories.OutboxStore
+	pricer       *Pricer
+	productCache *ProductCache
+	discounts    *DiscountEngine
+	tax          TaxCalculator
+	shipping     *ShippingCalculator
+	restErr      *common.RestErr
 }
 
 func NewOrderService(
-	orderRepo *repositories.OrderRepository,
-	productRepo *repositories.ProductRepository,
+	transactor repositories.TxRunner,
+	orderRepo repositories.OrderStore,
+	productRepo repositories.ProductStore,
+	variantRepo repositories.VariantStore,
+	couponRepo repositories.CouponStore,
+	outboxRepo repositories.OutboxStore,
+	pricer *Pricer,
+	productCache *ProductCache,
+	discounts *DiscountEngine,
+	tax TaxCalculator,
+	shipping *ShippingCalculator,
 	restErr *common.RestErr,
 ) OrderClient {
 	return &OrderService{
+		transactor,
 		orderRepo,
 		productRepo,
+		variantRepo,
+		couponRepo,
+		outboxRepo,
+		pricer,
+		productCache,
+		discounts,
+		tax,
+		shipping,
 		restErr,
 	}
 }
 
-func (o *OrderService) PlaceOrder(input dtos.PlaceOrderRequest, userID uint) (*models.Order, *common.RestErr) {
-	var totalPrice float64
-	var orderItems []models.OrderItem
+func (o *OrderService) PlaceOrder(ctx context.Context, input dtos.PlaceOrderRequest, userID uint) (*models.Order, *common.RestErr) {
+	currency := storeCurrencyOr(input.Currency)
+	cart, srvErr := o.priceCart(ctx, input.Items, currency)
+	if srvErr != nil {
+		return nil, srvErr
+	}
+	subtotal := cart.subtotal
 
-	for _, item := range input.Items {
-		product, err := o.productRepo.FindByID(item.ProductID)
-		if err != nil {
-			return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
-		}
-		if product == nil {
-			return nil, o.restErr.BadRequest(common.ErrProductNotFound)
+	order := models.Order{
+		UserID:          userID,
+		Status:          models.OrderStatusPending,
+		Items:           cart.items,
+		Subtotal:        subtotal,
+		DiscountTotal:   models.NewMoney(0, currency),
+		BaseCurrency:    utils.GetConfig().StoreCurrency,
+		ExchangeRate:    1,
+		ShippingAddress: toAddress(input.ShippingAddress),
+	}
+	if cart.rate != nil {
+		order.BaseCurrency = cart.rate.From
+		order.ExchangeRate = cart.rate.Rate
+		if cart.rate.Record != nil {
+			order.ExchangeRateID = &cart.rate.Record.ID
 		}
+	}
 
-		if product.Stock < item.Quantity {
-			return nil, o.restErr.BadRequest(common.ErrInsufficientStock)
-		}
+	if srvErr := o.chooseShipping(ctx, &order, input.ShippingMethodID, cart.weightGrams); srvErr != nil {
+		return nil, srvErr
+	}
 
-		itemPrice := product.Price * float64(item.Quantity)
-		totalPrice += itemPrice
+	var applied *AppliedCoupon
+	if input.CouponCode != "" {
+		applied, srvErr = o.discounts.Apply(ctx, input.CouponCode, userID, cart.lines, subtotal)
+		if srvErr != nil {
+			return nil, srvErr
+		}
+		if applied.FreeShipping {
+			applied.Amount = order.ShippingTotal
+		}
+		order.CouponCode = applied.Coupon.Code
+		order.DiscountTotal = applied.Amount
+		order.Discounts = []models.OrderDiscount{{
+			CouponID: applied.Coupon.ID,
+			Code:     applied.Coupon.Code,
+			Type:     applied.Coupon.Type,
+			Amount:   applied.Amount,
+		}}
+	}
 
-		orderItems = append(orderItems, models.OrderItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     product.Price,
-		})
+	taxableLines := make([]TaxableLine, len(cart.lines))
+	for i, line := range cart.lines {
+		taxableLines[i] = TaxableLine{ProductID: line.ProductID, TaxClass: cart.taxClasses[i], Amount: line.Total}
+		order.Items[i].DiscountAmount = models.NewMoney(0, currency)
+		if applied != nil {
+			order.Items[i].DiscountAmount = applied.Allocations[i]
+			taxableLines[i].Amount, _ = line.Total.Sub(applied.Allocations[i])
+		}
+	}
+	taxes, err := o.tax.Calculate(ctx, order.ShippingAddress, taxableLines)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	order.TaxTotal = models.NewMoney(taxes.Total.Amount, currency)
+	order.TaxInclusive = taxes.Inclusive
+	for i, lineTax := range taxes.Lines {
+		order.Items[i].TaxAmount = lineTax.Amount
+		order.Items[i].TaxRate = lineTax.Rate
+	}
 
-		if err := o.productRepo.DecreaseStock(item.ProductID, item.Quantity); err != nil {
+	order.TotalPrice, err = subtotal.Add(order.ShippingTotal)
+	if err != nil {
+		return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if order.TotalPrice, err = order.TotalPrice.Sub(order.DiscountTotal); err != nil {
+		return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if !order.TaxInclusive {
+		if order.TotalPrice, err = order.TotalPrice.Add(order.TaxTotal); err != nil {
 			return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
 		}
 	}
 
-	order := models.Order{
-		UserID:     userID,
-		Status:     models.OrderStatusPending,
-		TotalPrice: totalPrice,
-		Items:      orderItems,
-	}
+	lowStockThreshold := utils.GetConfig().LowStockThreshold
+	err = o.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		productRepo, variantRepo := o.productRepo.WithTx(tx), o.variantRepo.WithTx(tx)
+		var stockEvents []events.Event
+		for _, item := range order.Items {
+			var (
+				stock int
+				err   error
+			)
+			if item.VariantID != nil {
+				stock, err = variantRepo.DecreaseStock(*item.VariantID, item.Quantity)
+			} else {
+				stock, err = productRepo.DecreaseStock(item.ProductID, item.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+			if event, low := stockLowEvent(item, stock, lowStockThreshold); low {
+				stockEvents = append(stockEvents, event)
+			}
+		}
 
-	if err := o.orderRepo.Create(&order); err != nil {
-		return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
+		if err := o.orderRepo.WithTx(tx).Create(&order); err != nil {
+			return err
+		}
+
+		if applied != nil {
+			if err := o.couponRepo.WithTx(tx).Redeem(applied.Coupon.ID, userID, order.ID); err != nil {
+				return err
+			}
+		}
+		return recordEvents(o.outboxRepo.WithTx(tx), append([]events.Event{orderEvent(events.OrderPlaced, &order)}, stockEvents...)...)
+	})
+	if errors.Is(err, repositories.ErrInsufficientStock) {
+		return nil, o.restErr.BadRequest(common.ErrInsufficientStock)
 	}
+	if err != nil {
+		return nil, o.discounts.redeemErr(err)
+	}
+	o.productCache.Invalidate(ctx, orderProductIDs(order.Items)...)
 
 	return &order, nil
 }
 
-func (o *OrderService) ListOrders(userID uint) ([]dtos.OrderResponse, *common.RestErr) {
-	orders, err := o.orderRepo.FindByUserID(userID)
+// chooseShipping sets the order's shipping to methodID as quoted for its
+// address. Stores without shipping zones ship for free, so a method is only
+// needed once a zone exists.
+func (o *OrderService) chooseShipping(ctx context.Context, order *models.Order, methodID uint, weightGrams int) *common.RestErr {
+	order.ShippingTotal = models.NewMoney(0, order.Subtotal.Currency)
+	if methodID == 0 {
+		configured, err := o.shipping.Configured(ctx)
+		if err != nil {
+			log.Error(zap.Error(err))
+			return o.restErr.ServerError(common.ErrSomethingWentWrong)
+		}
+		if configured {
+			return o.restErr.BadRequest(common.ErrShippingMethodRequired)
+		}
+		return nil
+	}
+
+	rates, err := o.shipping.Quote(ctx, order.ShippingAddress, order.Subtotal, weightGrams)
 	if err != nil {
-		return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
+		return pricingErr(o.restErr, err)
+	}
+	for i := range rates {
+		if rates[i].Method.ID == methodID {
+			order.ShippingMethodID = &rates[i].Method.ID
+			order.ShippingMethodName = rates[i].Method.Name
+			order.ShippingTotal = rates[i].Cost
+			return nil
+		}
 	}
+	return o.restErr.BadRequest(common.ErrShippingMethodUnavailable)
+}
 
-	var orderResponses []dtos.OrderResponse
-	for _, order := range orders {
-		orderResponse := dtos.OrderResponse{
-			ID:         order.ID,
-			UserID:     order.UserID,
-			Status:     string(order.Status),
-			TotalPrice: order.TotalPrice,
-			CreatedAt:  order.CreatedAt,
-			Items:      make([]dtos.OrderItemDetail, 0),
+func (o *OrderService) QuoteShipping(ctx context.Context, input dtos.ShippingQuoteRequest) ([]dtos.ShippingQuote, *common.RestErr) {
+	currency := storeCurrencyOr(input.Currency)
+	cart, srvErr := o.priceCart(ctx, input.Items, currency)
+	if srvErr != nil {
+		return nil, srvErr
+	}
+
+	rates, err := o.shipping.Quote(ctx, toAddress(input.ShippingAddress), cart.subtotal, cart.weightGrams)
+	if err != nil {
+		return nil, pricingErr(o.restErr, err)
+	}
+
+	quotes := make([]dtos.ShippingQuote, 0, len(rates))
+	for _, rate := range rates {
+		quotes = append(quotes, dtos.ShippingQuote{
+			MethodID: rate.Method.ID,
+			Name:     rate.Method.Name,
+			Type:     string(rate.Method.Type),
+			Cost:     rate.Cost,
+		})
+	}
+
+	return quotes, nil
+}
+
+// pricedCart is a priced set of order lines. lines and taxClasses are parallel to
+// items. rate is the exchange rate the first converted line was priced at,
+// and nil when every line was priced without converting.
+type pricedCart struct {
+	items       []models.OrderItem
+	lines       []DiscountLine
+	taxClasses  []string
+	subtotal    models.Money
+	weightGrams int
+	rate        *AppliedRate
+}
+
+// priceCart checks each requested item can be bought and prices it in
+// currency, snapshotting the product onto the order item.
+func (o *OrderService) priceCart(ctx context.Context, items []dtos.OrderItemRequest, currency string) (*pricedCart, *common.RestErr) {
+	c := &pricedCart{subtotal: models.NewMoney(0, currency)}
+	productRepo, variantRepo := o.productRepo.WithContext(ctx), o.variantRepo.WithContext(ctx)
+
+	for _, item := range items {
+		product, err := productRepo.FindByID(item.ProductID)
+		if err != nil {
+			return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
+		}
+		if product == nil {
+			return nil, o.restErr.BadRequest(common.ErrProductNotFound)
+		}
+		if product.Status != models.ProductStatusActive {
+			return nil, o.restErr.BadRequest(common.ErrProductUnavailable)
 		}
 
-		for _, item := range order.Items {
-			orderResponse.Items = append(orderResponse.Items, dtos.OrderItemDetail{
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-				Price:     item.Price,
-			})
+		var variant *models.ProductVariant
+		var sku string
+		if item.VariantID != nil {
+			variant, err = variantRepo.FindByID(*item.VariantID)
+			if err != nil {
+				return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
+			}
+			if variant == nil || variant.ProductID != product.ID {
+				return nil, o.restErr.BadRequest(common.ErrVariantNotFound)
+			}
+			if variant.Stock < item.Quantity {
+				return nil, o.restErr.BadRequest(common.ErrInsufficientStock)
+			}
+			sku = variant.SKU
+		} else {
+			if len(product.Variants) > 0 {
+				return nil, o.restErr.BadRequest(common.ErrVariantRequired)
+			}
+			if product.Stock < item.Quantity {
+				return nil, o.restErr.BadRequest(common.ErrInsufficientStock)
+			}
 		}
 
-		orderResponses = append(orderResponses, orderResponse)
+		unitPrice, rate, err := o.pricer.UnitPrice(ctx, product, variant, currency)
+		if err != nil {
+			return nil, pricingErr(o.restErr, err)
+		}
+		if c.rate == nil {
+			c.rate = rate
+		}
+		lineTotal := unitPrice.Mul(item.Quantity)
+		c.subtotal, err = c.subtotal.Add(lineTotal)
+		if err != nil {
+			return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
+		}
+
+		c.items = append(c.items, models.OrderItem{
+			ProductID:          item.ProductID,
+			VariantID:          item.VariantID,
+			Quantity:           item.Quantity,
+			Price:              unitPrice,
+			ProductName:        product.Name,
+			SKU:                sku,
+			DescriptionExcerpt: utils.Excerpt(product.Description, descriptionExcerptLength),
+			ImageURL:           product.ImageURL,
+		})
+		c.lines = append(c.lines, DiscountLine{
+			ProductID: product.ID,
+			Category:  product.Category,
+			Total:     lineTotal,
+		})
+		c.taxClasses = append(c.taxClasses, product.TaxClass)
+		c.weightGrams += product.WeightGrams * item.Quantity
 	}
 
-	return orderResponses, nil
+	return c, nil
 }
 
-func (o *OrderService) CancelOrder(userID, orderID uint) *common.RestErr {
+func (o *OrderService) GetOrder(ctx context.Context, userID, orderID uint) (*dtos.OrderResponse, *common.RestErr) {
+	order, exists, err := o.orderRepo.WithContext(ctx).FindByID(orderID)
+	if err != nil {
+		return nil, o.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if !exists || order.UserID != userID {
+		return nil, o.restErr.NotFound(common.ErrOrderNotFound)
+	}
 
-	order, exists, err := o.orderRepo.FindByID(uint(orderID))
+	orderResponse := toOrderResponse(*order)
+	return &orderResponse, nil
+}
+
+func (o *OrderService) ListOrders(ctx context.Context, userID uint, input dtos.ListOrdersRequest) ([]dtos.OrderResponse, int64, *common.RestErr) {
+	filter := repositories.OrderListFilter{
+		UserID: userID,
+		Status: models.OrderStatus(input.Status),
+		Sort:   input.Sort,
+	}
+	var err error
+	if filter.From, err = parseDateBound(input.From, false); err != nil {
+		return nil, 0, o.restErr.BadRequest(common.ErrInvalidDateRange)
+	}
+	if filter.To, err = parseDateBound(input.To, true); err != nil {
+		return nil, 0, o.restErr.BadRequest(common.ErrInvalidDateRange)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, 0, o.restErr.BadRequest(common.ErrInvalidDateRange)
+	}
+
+	orders, totalCount, err := o.orderRepo.WithContext(ctx).ListPaginated(filter, input.Page, input.PageSize)
+	if err != nil {
+		return nil, 0, o.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	orderResponses := make([]dtos.OrderResponse, 0, len(orders))
+	for _, order := range orders {
+		orderResponses = append(orderResponses, toOrderResponse(order))
+	}
+
+	return orderResponses, totalCount, nil
+}
+
+func (o *OrderService) CancelOrder(ctx context.Context, userID, orderID uint) *common.RestErr {
+	order, exists, err := o.orderRepo.WithContext(ctx).FindByID(orderID)
 	if err != nil {
 		return o.restErr.ServerError(common.ErrSomethingWentWrong)
 	}
@@ -125,9 +405,133 @@ func (o *OrderService) CancelOrder(userID, orderID uint) *common.RestErr {
 		return o.restErr.BadRequest(common.ErrCanOnlyCancelPendingOrder)
 	}
 
-	if err := o.orderRepo.UpdateStatus(uint(orderID), models.OrderStatusCancelled); err != nil {
+	var cancelled *models.Order
+	err = o.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		orderRepo := o.orderRepo.WithTx(tx)
+
+		// recheck under lock so stock isn't released twice
+		locked, err := orderRepo.LockByID(orderID)
+		if err != nil {
+			return err
+		}
+		if locked == nil || locked.Status != models.OrderStatusPending {
+			return errOrderNotCancellable
+		}
+		if err := releaseStock(o.productRepo.WithTx(tx), o.variantRepo.WithTx(tx), locked.Items); err != nil {
+			return err
+		}
+		if err := o.couponRepo.WithTx(tx).Unredeem(orderID); err != nil {
+			return err
+		}
+		if err := orderRepo.UpdateStatus(orderID, models.OrderStatusCancelled); err != nil {
+			return err
+		}
+
+		locked.Status = models.OrderStatusCancelled
+		cancelled = locked
+		return recordEvents(o.outboxRepo.WithTx(tx), orderEvent(events.OrderCancelled, locked))
+	})
+	if errors.Is(err, errOrderNotCancellable) {
+		return o.restErr.BadRequest(common.ErrCanOnlyCancelPendingOrder)
+	}
+	if err != nil {
+		log.Error(zap.Error(err))
 		return o.restErr.ServerError(common.ErrSomethingWentWrong)
 	}
+	o.productCache.Invalidate(ctx, orderProductIDs(cancelled.Items)...)
 
 	return nil
 }
+
+func toOrderResponse(order models.Order) dtos.OrderResponse {
+	orderResponse := dtos.OrderResponse{
+		ID:              order.ID,
+		UserID:          order.UserID,
+		Status:          string(order.Status),
+		TotalPrice:      order.TotalPrice,
+		ExchangeRate:    order.ExchangeRate,
+		CreatedAt:       order.CreatedAt,
+		Items:           make([]dtos.OrderItemDetail, 0),
+		Subtotal:        order.Subtotal,
+		DiscountTotal:   order.DiscountTotal,
+		ShippingTotal:   order.ShippingTotal,
+		ShippingMethod:  order.ShippingMethodName,
+		TaxTotal:        order.TaxTotal,
+		TaxInclusive:    order.TaxInclusive,
+		ShippingAddress: toAddressDTO(order.ShippingAddress),
+		CouponCode:      order.CouponCode,
+		Discounts:       make([]dtos.OrderDiscountDetail, 0),
+	}
+
+	for _, item := range order.Items {
+		itemDetail := dtos.OrderItemDetail{
+			ProductID:          item.ProductID,
+			VariantID:          item.VariantID,
+			Quantity:           item.Quantity,
+			Price:              item.Price,
+			ProductName:        item.ProductName,
+			SKU:                item.SKU,
+			DescriptionExcerpt: item.DescriptionExcerpt,
+			ImageURL:           item.ImageURL,
+			TaxAmount:          item.TaxAmount,
+			TaxRate:            item.TaxRate,
+			DiscountAmount:     item.DiscountAmount,
+		}
+		if item.Product != nil {
+			product := toProductResponse(*item.Product)
+			itemDetail.Product = &product
+		}
+		orderResponse.Items = append(orderResponse.Items, itemDetail)
+	}
+
+	for _, discount := range order.Discounts {
+		orderResponse.Discounts = append(orderResponse.Discounts, dtos.OrderDiscountDetail{
+			Code:   discount.Code,
+			Type:   string(discount.Type),
+			Amount: discount.Amount,
+		})
+	}
+
+	return orderResponse
+}
+
+func toAddress(address dtos.Address) models.Address {
+	return models.Address{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     strings.ToUpper(address.Region),
+		PostalCode: address.PostalCode,
+		Country:    strings.ToUpper(address.Country),
+	}
+}
+
+func toAddressDTO(address models.Address) dtos.Address {
+	return dtos.Address{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+// parseDateBound parses a date or RFC 3339 timestamp. A date used as an
+// upper bound moves to the start of the next day so the whole day is included.
+func parseDateBound(value string, upper bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
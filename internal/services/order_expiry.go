@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/events"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+// expiryBatchSize caps how many orders one expiry run cancels.
+const expiryBatchSize = 100
+
+// OrderExpiryService cancels orders that have sat unpaid in pending for too
+// long, putting their stock back on sale and giving back any coupon use.
+type OrderExpiryService struct {
+	transactor   repositories.TxRunner
+	orderRepo    repositories.OrderStore
+	productRepo  repositories.ProductStore
+	variantRepo  repositories.VariantStore
+	couponRepo   repositories.CouponStore
+	outboxRepo   repositories.OutboxStore
+	productCache *ProductCache
+}
+
+func NewOrderExpiryService(
+	transactor repositories.TxRunner,
+	orderRepo repositories.OrderStore,
+	productRepo repositories.ProductStore,
+	variantRepo repositories.VariantStore,
+	couponRepo repositories.CouponStore,
+	outboxRepo repositories.OutboxStore,
+	productCache *ProductCache,
+) *OrderExpiryService {
+	return &OrderExpiryService{
+		transactor,
+		orderRepo,
+		productRepo,
+		variantRepo,
+		couponRepo,
+		outboxRepo,
+		productCache,
+	}
+}
+
+// ExpirePendingOrders cancels orders placed more than ttl ago that are still
+// pending, recording order.expired for each, and returns how many it
+// cancelled. A failure on one order is logged and doesn't stop the rest, but
+// cancelling ctx does.
+func (e *OrderExpiryService) ExpirePendingOrders(ctx context.Context, ttl time.Duration) (int, error) {
+	orderIDs, err := e.orderRepo.WithContext(ctx).FindPendingBefore(time.Now().Add(-ttl), expiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, orderID := range orderIDs {
+		if ctx.Err() != nil {
+			return expired, ctx.Err()
+		}
+		cancelled, err := e.expire(ctx, orderID)
+		if err != nil {
+			log.Error(zap.Error(err))
+			continue
+		}
+		if cancelled {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// expire cancels one order if it is still pending, reporting false if it
+// was paid or cancelled in the meantime.
+func (e *OrderExpiryService) expire(ctx context.Context, orderID uint) (bool, error) {
+	var cancelled *models.Order
+	err := e.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		orderRepo := e.orderRepo.WithTx(tx)
+
+		locked, err := orderRepo.LockByID(orderID)
+		if err != nil || locked == nil || locked.Status != models.OrderStatusPending {
+			return err
+		}
+		if err := releaseStock(e.productRepo.WithTx(tx), e.variantRepo.WithTx(tx), locked.Items); err != nil {
+			return err
+		}
+		if err := e.couponRepo.WithTx(tx).Unredeem(orderID); err != nil {
+			return err
+		}
+		if err := orderRepo.UpdateStatus(orderID, models.OrderStatusCancelled); err != nil {
+			return err
+		}
+
+		locked.Status = models.OrderStatusCancelled
+		cancelled = locked
+		return recordEvents(e.outboxRepo.WithTx(tx), orderEvent(events.OrderExpired, locked))
+	})
+	if err != nil || cancelled == nil {
+		return false, err
+	}
+
+	e.productCache.Invalidate(ctx, orderProductIDs(cancelled.Items)...)
+	return true, nil
+}
+
+// releaseStock puts the units held by an order's items back into stock.
+func releaseStock(productRepo repositories.ProductStore, variantRepo repositories.VariantStore, items []models.OrderItem) error {
+	for _, item := range items {
+		var err error
+		if item.VariantID != nil {
+			err = variantRepo.IncreaseStock(*item.VariantID, item.Quantity)
+		} else {
+			err = productRepo.IncreaseStock(item.ProductID, item.Quantity)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
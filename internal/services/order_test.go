@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"instashop/internal/cache"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/events"
+	"instashop/internal/repositories/memory"
+	"instashop/internal/utils"
+	"instashop/models"
+)
+
+func placeOrderRequest(methodID uint, items ...dtos.OrderItemRequest) dtos.PlaceOrderRequest {
+	return dtos.PlaceOrderRequest{
+		Items: items,
+		ShippingAddress: dtos.Address{
+			Line1:   "1 Market Street",
+			City:    "Lagos",
+			Country: "NG",
+		},
+		ShippingMethodID: methodID,
+	}
+}
+
+func TestPlaceOrder(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	method := env.createShippingMethod(t, 500)
+
+	order, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 2}), 7)
+	assertNoRestErr(t, srvErr)
+
+	if order.Status != models.OrderStatusPending || order.UserID != 7 {
+		t.Fatalf("got status %q for user %d", order.Status, order.UserID)
+	}
+	totals := map[string]int64{
+		"subtotal": order.Subtotal.Amount,
+		"shipping": order.ShippingTotal.Amount,
+		"tax":      order.TaxTotal.Amount,
+		"total":    order.TotalPrice.Amount,
+	}
+	want := map[string]int64{"subtotal": 4000, "shipping": 500, "tax": 400, "total": 4900}
+	if !reflect.DeepEqual(totals, want) {
+		t.Fatalf("got totals %v, want %v", totals, want)
+	}
+	if stock := env.stock(t, product.ID); stock != 3 {
+		t.Fatalf("got stock %d, want 3", stock)
+	}
+
+	stored, exists, err := env.orders.FindByID(order.ID)
+	if err != nil || !exists {
+		t.Fatalf("order %d not stored: %v", order.ID, err)
+	}
+	if len(stored.Items) != 1 || stored.Items[0].ProductName != "Mug" || stored.Items[0].Quantity != 2 {
+		t.Fatalf("got items %+v", stored.Items)
+	}
+	if names := env.eventNames(); !reflect.DeepEqual(names, []string{events.OrderPlaced}) {
+		t.Fatalf("got events %v", names)
+	}
+}
+
+func TestPlaceOrderExchangeRate(t *testing.T) {
+	env := newTestEnv(t)
+	storeCurrency := utils.GetConfig().StoreCurrency
+	converted := env.createProduct(t, "Mug", 2000, 5)
+	overridden := env.createProduct(t, "Lamp", 3000, 5)
+	if err := env.currencies.UpsertProductPrices([]models.ProductPrice{
+		{ProductID: overridden.ID, Currency: "EUR", Amount: 2800},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	free := env.createShippingMethod(t, 0)
+
+	// Only overrides are used, so no rate is needed.
+	request := placeOrderRequest(free.ID, dtos.OrderItemRequest{ProductID: overridden.ID, Quantity: 1})
+	request.Currency = "EUR"
+	order, srvErr := env.orderService.PlaceOrder(env.ctx, request, 7)
+	assertNoRestErr(t, srvErr)
+	if order.ExchangeRate != 1 || order.ExchangeRateID != nil || order.Subtotal.Amount != 2800 {
+		t.Fatalf("got rate %v (record %v) and subtotal %d, want 1, none and 2800",
+			order.ExchangeRate, order.ExchangeRateID, order.Subtotal.Amount)
+	}
+
+	// Converting a line needs a rate, and records the one it used.
+	request.Items = append(request.Items, dtos.OrderItemRequest{ProductID: converted.ID, Quantity: 1})
+	_, srvErr = env.orderService.PlaceOrder(env.ctx, request, 7)
+	assertRestErr(t, srvErr, common.ErrNoExchangeRate)
+
+	rate := &models.ExchangeRate{BaseCurrency: storeCurrency, QuoteCurrency: "EUR", Rate: 0.9, EffectiveAt: time.Now().Add(-time.Hour)}
+	if err := env.currencies.CreateExchangeRate(rate); err != nil {
+		t.Fatal(err)
+	}
+	order, srvErr = env.orderService.PlaceOrder(env.ctx, request, 7)
+	assertNoRestErr(t, srvErr)
+	if order.ExchangeRate != 0.9 || order.ExchangeRateID == nil || *order.ExchangeRateID != rate.ID {
+		t.Fatalf("got rate %v (record %v), want 0.9 from record %d", order.ExchangeRate, order.ExchangeRateID, rate.ID)
+	}
+	if order.Subtotal.Amount != 2800+1800 {
+		t.Fatalf("got subtotal %d, want %d", order.Subtotal.Amount, 2800+1800)
+	}
+}
+
+func TestPlaceOrderWithoutShippingZones(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+
+	order, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(0, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 1}), 7)
+	assertNoRestErr(t, srvErr)
+	if order.ShippingMethodID != nil || order.ShippingTotal.Amount != 0 || order.TotalPrice.Amount != 2200 {
+		t.Fatalf("got method %v, shipping %d and total %d; want none, 0 and 2200",
+			order.ShippingMethodID, order.ShippingTotal.Amount, order.TotalPrice.Amount)
+	}
+}
+
+func TestPlaceOrderRecordsLowStock(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 6)
+	method := env.createShippingMethod(t, 500)
+
+	_, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 2}), 7)
+	assertNoRestErr(t, srvErr)
+
+	want := []string{events.OrderPlaced, events.ProductStockLow}
+	if names := env.eventNames(); !reflect.DeepEqual(names, want) {
+		t.Fatalf("got events %v, want %v", names, want)
+	}
+}
+
+func TestPlaceOrderVariant(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Shirt", 3000, 0)
+	price := int64(3500)
+	variant := &models.ProductVariant{ProductID: product.ID, SKU: "SHIRT-L", PriceAmount: &price, Stock: 4}
+	if err := env.variants.Create(variant); err != nil {
+		t.Fatal(err)
+	}
+	method := env.createShippingMethod(t, 0)
+
+	_, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 1}), 7)
+	assertRestErr(t, srvErr, common.ErrVariantRequired)
+
+	order, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, VariantID: &variant.ID, Quantity: 3}), 7)
+	assertNoRestErr(t, srvErr)
+
+	if order.Subtotal.Amount != 10500 || order.Items[0].SKU != "SHIRT-L" {
+		t.Fatalf("got subtotal %d and sku %q", order.Subtotal.Amount, order.Items[0].SKU)
+	}
+	stored, _ := env.variants.FindByID(variant.ID)
+	if stored.Stock != 1 {
+		t.Fatalf("got variant stock %d, want 1", stored.Stock)
+	}
+}
+
+func TestPlaceOrderInsufficientStock(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	method := env.createShippingMethod(t, 500)
+
+	_, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 6}), 7)
+	assertRestErr(t, srvErr, common.ErrInsufficientStock)
+
+	// each line fits on its own, so only the stock update catches it
+	_, srvErr = env.orderService.PlaceOrder(env.ctx, placeOrderRequest(method.ID,
+		dtos.OrderItemRequest{ProductID: product.ID, Quantity: 3},
+		dtos.OrderItemRequest{ProductID: product.ID, Quantity: 3},
+	), 7)
+	assertRestErr(t, srvErr, common.ErrInsufficientStock)
+
+	if stock := env.stock(t, product.ID); stock != 5 {
+		t.Fatalf("got stock %d after rollback, want 5", stock)
+	}
+	if _, exists, _ := env.orders.FindByID(1); exists {
+		t.Fatal("order was stored despite the rollback")
+	}
+	if names := env.eventNames(); len(names) != 0 {
+		t.Fatalf("got events %v after rollback", names)
+	}
+}
+
+func TestPlaceOrderRejectsUnavailableItems(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	method := env.createShippingMethod(t, 500)
+
+	_, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID + 100, Quantity: 1}), 7)
+	assertRestErr(t, srvErr, common.ErrProductNotFound)
+
+	_, srvErr = env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID+100, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 1}), 7)
+	assertRestErr(t, srvErr, common.ErrShippingMethodUnavailable)
+
+	_, srvErr = env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(0, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 1}), 7)
+	assertRestErr(t, srvErr, common.ErrShippingMethodRequired)
+
+	product.Status = models.ProductStatusArchived
+	if err := env.products.Update(product); err != nil {
+		t.Fatal(err)
+	}
+	_, srvErr = env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 1}), 7)
+	assertRestErr(t, srvErr, common.ErrProductUnavailable)
+}
+
+func TestPlaceOrderWithCoupon(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	method := env.createShippingMethod(t, 500)
+	usageLimit := 1
+	coupon := &models.Coupon{Code: "TENOFF", Type: models.CouponTypePercentage, PercentOff: 1000, UsageLimit: &usageLimit}
+	if err := env.coupons.Create(coupon); err != nil {
+		t.Fatal(err)
+	}
+
+	input := placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 1})
+	input.CouponCode = "tenoff"
+	order, srvErr := env.orderService.PlaceOrder(env.ctx, input, 7)
+	assertNoRestErr(t, srvErr)
+
+	// 2000 less 200 off, plus 500 shipping and 10% tax on 1800
+	if order.DiscountTotal.Amount != 200 || order.TotalPrice.Amount != 2480 {
+		t.Fatalf("got discount %d and total %d", order.DiscountTotal.Amount, order.TotalPrice.Amount)
+	}
+	if discount := order.Items[0].DiscountAmount.Amount; discount != 200 {
+		t.Fatalf("got item discount %d, want 200", discount)
+	}
+	if stored, _ := env.coupons.FindByID(coupon.ID); stored.TimesUsed != 1 {
+		t.Fatalf("got %d coupon uses, want 1", stored.TimesUsed)
+	}
+
+	_, srvErr = env.orderService.PlaceOrder(env.ctx, input, 8)
+	assertRestErr(t, srvErr, common.ErrCouponUsageLimitReached)
+}
+
+func TestCancelOrder(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	method := env.createShippingMethod(t, 500)
+	order, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 2}), 7)
+	assertNoRestErr(t, srvErr)
+
+	assertNoRestErr(t, env.orderService.CancelOrder(env.ctx, 7, order.ID))
+
+	stored, _, _ := env.orders.FindByID(order.ID)
+	if stored.Status != models.OrderStatusCancelled {
+		t.Fatalf("got status %q, want cancelled", stored.Status)
+	}
+	if stock := env.stock(t, product.ID); stock != 5 {
+		t.Fatalf("got stock %d, want 5", stock)
+	}
+	want := []string{events.OrderPlaced, events.OrderCancelled}
+	if names := env.eventNames(); !reflect.DeepEqual(names, want) {
+		t.Fatalf("got events %v, want %v", names, want)
+	}
+
+	assertRestErr(t, env.orderService.CancelOrder(env.ctx, 7, order.ID), common.ErrCanOnlyCancelPendingOrder)
+	if stock := env.stock(t, product.ID); stock != 5 {
+		t.Fatalf("stock released twice, got %d", stock)
+	}
+}
+
+func TestExpirePendingOrders(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	method := env.createShippingMethod(t, 500)
+	order, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 2}), 7)
+	assertNoRestErr(t, srvErr)
+	expirySvc := NewOrderExpiryService(memory.NewTransactor(env.db), env.orders, env.products, env.variants,
+		env.coupons, env.outbox, NewProductCache(cache.NewMemoryCache(100), time.Minute))
+
+	// a negative ttl makes the order just placed overdue
+	ctx, cancel := context.WithCancel(env.ctx)
+	cancel()
+	expired, err := expirySvc.ExpirePendingOrders(ctx, -time.Hour)
+	if expired != 0 || !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %d expired and error %v from a cancelled run", expired, err)
+	}
+
+	expired, err = expirySvc.ExpirePendingOrders(env.ctx, -time.Hour)
+	if expired != 1 || err != nil {
+		t.Fatalf("got %d expired and error %v, want 1", expired, err)
+	}
+	stored, _, _ := env.orders.FindByID(order.ID)
+	if stored.Status != models.OrderStatusCancelled {
+		t.Fatalf("got status %q, want cancelled", stored.Status)
+	}
+	if stock := env.stock(t, product.ID); stock != 5 {
+		t.Fatalf("got stock %d, want 5", stock)
+	}
+}
+
+func TestCancelOrderRestoresVariantStock(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Shirt", 3000, 0)
+	variant := &models.ProductVariant{ProductID: product.ID, SKU: "SHIRT-M", Stock: 2}
+	if err := env.variants.Create(variant); err != nil {
+		t.Fatal(err)
+	}
+	method := env.createShippingMethod(t, 0)
+	order, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, VariantID: &variant.ID, Quantity: 2}), 7)
+	assertNoRestErr(t, srvErr)
+
+	assertNoRestErr(t, env.orderService.CancelOrder(env.ctx, 7, order.ID))
+
+	if stored, _ := env.variants.FindByID(variant.ID); stored.Stock != 2 {
+		t.Fatalf("got variant stock %d, want 2", stored.Stock)
+	}
+}
+
+func TestCancelOrderGivesBackCouponUse(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	method := env.createShippingMethod(t, 500)
+	usageLimit, perUserLimit := 1, 1
+	coupon := &models.Coupon{Code: "ONCE", Type: models.CouponTypePercentage, PercentOff: 1000,
+		UsageLimit: &usageLimit, PerUserLimit: &perUserLimit}
+	if err := env.coupons.Create(coupon); err != nil {
+		t.Fatal(err)
+	}
+	input := placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 1})
+	input.CouponCode = "ONCE"
+	order, srvErr := env.orderService.PlaceOrder(env.ctx, input, 7)
+	assertNoRestErr(t, srvErr)
+
+	assertNoRestErr(t, env.orderService.CancelOrder(env.ctx, 7, order.ID))
+
+	if stored, _ := env.coupons.FindByID(coupon.ID); stored.TimesUsed != 0 {
+		t.Fatalf("got %d coupon uses, want 0", stored.TimesUsed)
+	}
+	// the same user can use it again now the cancelled order no longer counts
+	_, srvErr = env.orderService.PlaceOrder(env.ctx, input, 7)
+	assertNoRestErr(t, srvErr)
+}
+
+func TestCancelOrderRejects(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	method := env.createShippingMethod(t, 500)
+	order, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, Quantity: 1}), 7)
+	assertNoRestErr(t, srvErr)
+
+	assertRestErr(t, env.orderService.CancelOrder(env.ctx, 8, order.ID), common.ErrUnauthorized)
+	assertRestErr(t, env.orderService.CancelOrder(env.ctx, 7, order.ID+100), common.ErrOrderNotFound)
+
+	if err := env.orders.UpdateStatus(order.ID, models.OrderStatusShipped); err != nil {
+		t.Fatal(err)
+	}
+	assertRestErr(t, env.orderService.CancelOrder(env.ctx, 7, order.ID), common.ErrCanOnlyCancelPendingOrder)
+	if stock := env.stock(t, product.ID); stock != 4 {
+		t.Fatalf("got stock %d, want 4", stock)
+	}
+}
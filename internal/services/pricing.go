@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"instashop/internal/common"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+var ErrNoExchangeRate = errors.New("no exchange rate for currency pair")
+
+// Pricer works out what products cost in a customer's currency: a
+// per-currency override when the product has one, otherwise the base price
+// converted at the latest effective exchange rate.
+type Pricer struct {
+	currencyRepo repositories.CurrencyStore
+}
+
+func NewPricer(currencyRepo repositories.CurrencyStore) *Pricer {
+	return &Pricer{currencyRepo}
+}
+
+// Rate returns how many units of to one unit of from buys, along with the
+// recorded rate it came from. Same-currency pairs have rate 1 and no record.
+// When only the reverse pair is recorded its inverse is used.
+func (p *Pricer) Rate(ctx context.Context, from, to string) (float64, *models.ExchangeRate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil, nil
+	}
+
+	currencyRepo := p.currencyRepo.WithContext(ctx)
+	now := time.Now()
+	rate, err := currencyRepo.FindEffectiveRate(from, to, now)
+	if err != nil {
+		return 0, nil, err
+	}
+	if rate != nil {
+		return rate.Rate, rate, nil
+	}
+
+	inverse, err := currencyRepo.FindEffectiveRate(to, from, now)
+	if err != nil {
+		return 0, nil, err
+	}
+	if inverse != nil && inverse.Rate > 0 {
+		return 1 / inverse.Rate, inverse, nil
+	}
+
+	return 0, nil, ErrNoExchangeRate
+}
+
+// AppliedRate is the exchange rate a price was converted at. Record is nil
+// when the rate wasn't recorded for the pair, e.g. a same-currency pair.
+type AppliedRate struct {
+	From   string
+	Rate   float64
+	Record *models.ExchangeRate
+}
+
+func (p *Pricer) Convert(ctx context.Context, amount models.Money, currency string) (models.Money, error) {
+	converted, _, err := p.convert(ctx, amount, currency)
+	return converted, err
+}
+
+// convert prices amount in currency, returning the rate used when it had to
+// be converted. Zero converts to zero without needing a rate.
+func (p *Pricer) convert(ctx context.Context, amount models.Money, currency string) (models.Money, *AppliedRate, error) {
+	currency = strings.ToUpper(currency)
+	if strings.ToUpper(amount.Currency) == currency {
+		return amount, nil, nil
+	}
+	if amount.Amount == 0 {
+		return models.NewMoney(0, currency), nil, nil
+	}
+	rate, record, err := p.Rate(ctx, amount.Currency, currency)
+	if err != nil {
+		return models.Money{}, nil, err
+	}
+	return amount.Convert(currency, rate), &AppliedRate{From: strings.ToUpper(amount.Currency), Rate: rate, Record: record}, nil
+}
+
+// UnitPrice prices one unit of product, or of variant when given, in
+// currency. The rate is returned when the price was converted, and is nil
+// when it came from a per-currency override or needed no conversion.
+func (p *Pricer) UnitPrice(ctx context.Context, product *models.Product, variant *models.ProductVariant, currency string) (models.Money, *AppliedRate, error) {
+	currency = strings.ToUpper(currency)
+	if variant != nil && variant.PriceAmount != nil {
+		return p.convert(ctx, variant.EffectivePrice(product.Price), currency)
+	}
+	if currency == product.Price.Currency {
+		return product.Price, nil, nil
+	}
+
+	overrides, err := p.currencyRepo.WithContext(ctx).FindProductPricesIn([]uint{product.ID}, currency)
+	if err != nil {
+		return models.Money{}, nil, err
+	}
+	if override, ok := overrides[product.ID]; ok {
+		return override.Money(), nil, nil
+	}
+	return p.convert(ctx, product.Price, currency)
+}
+
+// Localize reprices products, and their variant overrides, in currency.
+func (p *Pricer) Localize(ctx context.Context, products []models.Product, currency string) error {
+	currency = strings.ToUpper(currency)
+	if currency == "" || len(products) == 0 {
+		return nil
+	}
+
+	productIDs := make([]uint, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+	}
+	overrides, err := p.currencyRepo.WithContext(ctx).FindProductPricesIn(productIDs, currency)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		product := &products[i]
+		if product.Price.Currency == currency {
+			continue
+		}
+
+		rate, _, err := p.Rate(ctx, product.Price.Currency, currency)
+		if err != nil {
+			return err
+		}
+		for j := range product.Variants {
+			variant := &product.Variants[j]
+			if variant.PriceAmount != nil {
+				amount := variant.EffectivePrice(product.Price).Convert(currency, rate).Amount
+				variant.PriceAmount = &amount
+			}
+		}
+		if override, ok := overrides[product.ID]; ok {
+			product.Price = override.Money()
+		} else {
+			product.Price = product.Price.Convert(currency, rate)
+		}
+	}
+
+	return nil
+}
+
+func pricingErr(restErr *common.RestErr, err error) *common.RestErr {
+	if errors.Is(err, ErrNoExchangeRate) {
+		return restErr.BadRequest(common.ErrNoExchangeRate)
+	}
+	return restErr.ServerError(common.ErrSomethingWentWrong)
+}
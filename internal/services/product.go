@@ -1,33 +1,73 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-playground/validator"
 	"instashop/internal/common"
 	"instashop/internal/dtos"
+	"instashop/internal/events"
 	"instashop/internal/repositories"
+	"instashop/internal/utils"
 	"instashop/models"
 )
 
 type ProductClient interface {
-	CreateProducts(inputs []dtos.CreateProductRequest) ([]models.Product, *common.RestErr)
-	GetProduct(productID uint) (*models.Product, *common.RestErr)
-	DeleteProduct(productID uint) *common.RestErr
-	ListProducts(page, pageSize int) ([]models.Product, int64, *common.RestErr)
-	UpdateProduct(productID uint, input dtos.UpdateProductRequest) (*models.Product, *common.RestErr)
+	CreateProducts(ctx context.Context, inputs []dtos.CreateProductRequest) ([]models.Product, *common.RestErr)
+	GetProduct(ctx context.Context, productID uint) (*models.Product, *common.RestErr)
+	GetCatalogProduct(ctx context.Context, productID uint, currency string) (*models.Product, *common.RestErr)
+	DeleteProduct(ctx context.Context, productID uint) *common.RestErr
+	RestoreProduct(ctx context.Context, productID uint) *common.RestErr
+	ListProducts(ctx context.Context, input dtos.ListProductsRequest) ([]models.Product, int64, *common.RestErr)
+	ListProductsByCursor(ctx context.Context, input dtos.ListProductsRequest) ([]models.Product, string, *common.RestErr)
+	UpdateProduct(ctx context.Context, productID uint, input dtos.UpdateProductRequest) (*models.Product, *common.RestErr)
+	CreateVariants(ctx context.Context, productID uint, inputs []dtos.CreateVariantRequest) ([]models.ProductVariant, *common.RestErr)
+	ListVariants(ctx context.Context, productID uint) ([]models.ProductVariant, *common.RestErr)
+	UpdateVariant(ctx context.Context, variantID uint, input dtos.UpdateVariantRequest) (*models.ProductVariant, *common.RestErr)
+	DeleteVariant(ctx context.Context, variantID uint) *common.RestErr
+	ListOptionTypes(ctx context.Context) ([]models.OptionType, *common.RestErr)
+	SearchProducts(ctx context.Context, input dtos.SearchProductsRequest) (*dtos.ProductSearchResponse, *common.RestErr)
+	SetProductPrices(ctx context.Context, productID uint, inputs []dtos.ProductPriceRequest) ([]models.ProductPrice, *common.RestErr)
+	ListProductPrices(ctx context.Context, productID uint) ([]models.ProductPrice, *common.RestErr)
+	DeleteProductPrice(ctx context.Context, productID uint, currency string) *common.RestErr
+	ImportCatalog(ctx context.Context, inputs []dtos.ImportProductRequest) (*dtos.CatalogImportResult, *common.RestErr)
 }
 
 type ProductService struct {
-	productRepo *repositories.ProductRepository
-	restErr     *common.RestErr
+	transactor   repositories.TxRunner
+	productRepo  repositories.ProductStore
+	variantRepo  repositories.VariantStore
+	currencyRepo repositories.CurrencyStore
+	outboxRepo   repositories.OutboxStore
+	pricer       *Pricer
+	cache        *ProductCache
+	restErr      *common.RestErr
 }
 
-func NewProductService(productRepo *repositories.ProductRepository,
+func NewProductService(transactor repositories.TxRunner,
+	productRepo repositories.ProductStore,
+	variantRepo repositories.VariantStore,
+	currencyRepo repositories.CurrencyStore,
+	outboxRepo repositories.OutboxStore,
+	pricer *Pricer,
+	cache *ProductCache,
 	restErr *common.RestErr) ProductClient {
 	return &ProductService{
+		transactor,
 		productRepo,
+		variantRepo,
+		currencyRepo,
+		outboxRepo,
+		pricer,
+		cache,
 		restErr}
 }
 
-func (p *ProductService) CreateProducts(inputs []dtos.CreateProductRequest) ([]models.Product, *common.RestErr) {
+func (p *ProductService) CreateProducts(ctx context.Context, inputs []dtos.CreateProductRequest) ([]models.Product, *common.RestErr) {
 	var products []models.Product
 	var productNames []string
 
@@ -35,7 +75,7 @@ func (p *ProductService) CreateProducts(inputs []dtos.CreateProductRequest) ([]m
 		productNames = append(productNames, input.Name)
 	}
 
-	existingProducts, err := p.productRepo.FetchByNames(productNames)
+	existingProducts, err := p.productRepo.WithContext(ctx).FetchByNames(productNames)
 	if err != nil {
 		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
 	}
@@ -52,8 +92,22 @@ func (p *ProductService) CreateProducts(inputs []dtos.CreateProductRequest) ([]m
 		product := models.Product{
 			Name:        input.Name,
 			Description: input.Description,
-			Price:       input.Price,
+			Price:       productPrice(input.Price, input.LegacyPrice, storeCurrencyOr(input.Currency)),
 			Stock:       input.Stock,
+			ImageURL:    input.ImageURL,
+			Category:    strings.ToLower(input.Category),
+			Status:      models.ProductStatusActive,
+			TaxClass:    models.DefaultTaxClass,
+			WeightGrams: input.WeightGrams,
+			LengthMM:    input.LengthMM,
+			WidthMM:     input.WidthMM,
+			HeightMM:    input.HeightMM,
+		}
+		if input.Status != "" {
+			product.Status = models.ProductStatus(input.Status)
+		}
+		if input.TaxClass != "" {
+			product.TaxClass = strings.ToLower(input.TaxClass)
 		}
 		products = append(products, product)
 	}
@@ -62,15 +116,34 @@ func (p *ProductService) CreateProducts(inputs []dtos.CreateProductRequest) ([]m
 		return nil, p.restErr.BadRequest("No new products to add")
 	}
 
-	if err := p.productRepo.CreateMany(products); err != nil {
+	err = p.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		if err := p.productRepo.WithTx(tx).CreateMany(products); err != nil {
+			return err
+		}
+		created := make([]events.Event, len(products))
+		for i := range products {
+			created[i] = productEvent(events.ProductCreated, &products[i])
+		}
+		return recordEvents(p.outboxRepo.WithTx(tx), created...)
+	})
+	if err != nil {
 		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
 	}
+	productIDs := make([]uint, len(products))
+	for i := range products {
+		productIDs[i] = products[i].ID
+	}
+	p.cache.Invalidate(ctx, productIDs...)
 
 	return products, nil
 }
 
-func (p *ProductService) GetProduct(productID uint) (*models.Product, *common.RestErr) {
-	product, err := p.productRepo.FindByID(productID)
+func (p *ProductService) GetProduct(ctx context.Context, productID uint) (*models.Product, *common.RestErr) {
+	if product := p.cache.getProduct(ctx, "admin", productID, ""); product != nil {
+		return product, nil
+	}
+
+	product, err := p.productRepo.WithContext(ctx).FindByID(productID)
 	if err != nil {
 		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
 	}
@@ -78,11 +151,35 @@ func (p *ProductService) GetProduct(productID uint) (*models.Product, *common.Re
 		return nil, p.restErr.BadRequest(common.ErrProductNotFound)
 	}
 
+	p.cache.setProduct(ctx, "admin", product, "")
 	return product, nil
 }
 
-func (p *ProductService) UpdateProduct(productID uint, input dtos.UpdateProductRequest) (*models.Product, *common.RestErr) {
-	product, err := p.productRepo.FindByID(productID)
+func (p *ProductService) GetCatalogProduct(ctx context.Context, productID uint, currency string) (*models.Product, *common.RestErr) {
+	if product := p.cache.getProduct(ctx, "catalog", productID, storeCurrencyOr(currency)); product != nil {
+		return product, nil
+	}
+
+	product, err := p.productRepo.WithContext(ctx).FindActiveByID(productID)
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if product == nil {
+		return nil, p.restErr.NotFound(common.ErrProductNotFound)
+	}
+
+	products := []models.Product{*product}
+	if srvErr := p.localize(ctx, products, currency); srvErr != nil {
+		return nil, srvErr
+	}
+	product = &products[0]
+
+	p.cache.setProduct(ctx, "catalog", product, storeCurrencyOr(currency))
+	return product, nil
+}
+
+func (p *ProductService) UpdateProduct(ctx context.Context, productID uint, input dtos.UpdateProductRequest) (*models.Product, *common.RestErr) {
+	product, err := p.productRepo.WithContext(ctx).FindByID(productID)
 	if err != nil {
 		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
 	}
@@ -96,33 +193,633 @@ func (p *ProductService) UpdateProduct(productID uint, input dtos.UpdateProductR
 	if input.Description != "" {
 		product.Description = input.Description
 	}
-	if input.Price > 0 {
-		product.Price = input.Price
+	currency := product.Price.Currency
+	if input.Currency != "" {
+		currency = strings.ToUpper(input.Currency)
+	}
+	switch {
+	case input.Price != nil:
+		product.Price = models.NewMoney(*input.Price, currency)
+	case input.LegacyPrice != nil:
+		product.Price = productPrice(0, input.LegacyPrice, currency)
+	case currency != product.Price.Currency:
+		// the old amount means nothing in the new currency
+		return nil, p.restErr.BadRequest(common.ErrPriceRequiredForCurrency)
 	}
 	if input.Stock >= 0 {
 		product.Stock = input.Stock
 	}
+	if input.ImageURL != "" {
+		product.ImageURL = input.ImageURL
+	}
+	if input.Category != "" {
+		product.Category = strings.ToLower(input.Category)
+	}
+	if input.Status != "" {
+		product.Status = models.ProductStatus(input.Status)
+	}
+	if input.TaxClass != "" {
+		product.TaxClass = strings.ToLower(input.TaxClass)
+	}
+	if input.WeightGrams != nil {
+		product.WeightGrams = *input.WeightGrams
+	}
+	if input.LengthMM != nil {
+		product.LengthMM = *input.LengthMM
+	}
+	if input.WidthMM != nil {
+		product.WidthMM = *input.WidthMM
+	}
+	if input.HeightMM != nil {
+		product.HeightMM = *input.HeightMM
+	}
 
-	if err := p.productRepo.Update(product); err != nil {
+	err = p.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		if err := p.productRepo.WithTx(tx).Update(product); err != nil {
+			return err
+		}
+		return recordEvents(p.outboxRepo.WithTx(tx), productEvent(events.ProductUpdated, product))
+	})
+	if err != nil {
 		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
 	}
+	p.cache.Invalidate(ctx, product.ID)
 
 	return product, nil
 }
 
-func (p *ProductService) DeleteProduct(productID uint) *common.RestErr {
-	if err := p.productRepo.Delete(productID); err != nil {
+// errProductNotFound is returned inside a transaction when the product it
+// changes doesn't exist, so that nothing is recorded for it.
+var errProductNotFound = errors.New("product not found")
+
+func (p *ProductService) DeleteProduct(ctx context.Context, productID uint) *common.RestErr {
+	err := p.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		deleted, err := p.productRepo.WithTx(tx).Delete(productID)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return errProductNotFound
+		}
+		return recordEvents(p.outboxRepo.WithTx(tx), productEvent(events.ProductDeleted, &models.Product{ID: productID}))
+	})
+	if errors.Is(err, errProductNotFound) {
+		return p.restErr.NotFound(common.ErrProductNotFound)
+	}
+	if err != nil {
 		return p.restErr.ServerError(common.ErrSomethingWentWrong)
 	}
+	p.cache.Invalidate(ctx, productID)
+
+	return nil
+}
+
+func (p *ProductService) RestoreProduct(ctx context.Context, productID uint) *common.RestErr {
+	restored, err := p.productRepo.WithContext(ctx).Restore(productID)
+	if err != nil {
+		return p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if !restored {
+		return p.restErr.BadRequest(common.ErrProductNotFound)
+	}
+	p.cache.Invalidate(ctx, productID)
 
 	return nil
 }
 
-func (p *ProductService) ListProducts(page, pageSize int) ([]models.Product, int64, *common.RestErr) {
-	products, totalCount, err := p.productRepo.ListPaginated(page, pageSize)
+func (p *ProductService) ListProducts(ctx context.Context, input dtos.ListProductsRequest) ([]models.Product, int64, *common.RestErr) {
+	if cached := p.cache.getList(ctx, "page", input); cached != nil {
+		return cached.Products, cached.TotalCount, nil
+	}
+
+	products, totalCount, err := p.productRepo.WithContext(ctx).ListPaginated(productListFilter(input), input.Page, input.PageSize)
 	if err != nil {
 		return nil, 0, p.restErr.ServerError(common.ErrSomethingWentWrong)
 	}
+	if srvErr := p.localize(ctx, products, input.Currency); srvErr != nil {
+		return nil, 0, srvErr
+	}
 
+	p.cache.setList(ctx, "page", input, cachedProductList{Products: products, TotalCount: totalCount})
 	return products, totalCount, nil
 }
+
+func (p *ProductService) ListProductsByCursor(ctx context.Context, input dtos.ListProductsRequest) ([]models.Product, string, *common.RestErr) {
+	if cached := p.cache.getList(ctx, "cursor", input); cached != nil {
+		return cached.Products, cached.NextCursor, nil
+	}
+
+	filter := productListFilter(input)
+
+	var cursor *repositories.ProductCursor
+	if input.Cursor != "" {
+		decoded, err := repositories.DecodeProductCursor(input.Cursor)
+		if err != nil {
+			return nil, "", p.restErr.BadRequest(common.ErrInvalidCursor)
+		}
+		cursor = decoded
+	}
+
+	products, next, err := p.productRepo.WithContext(ctx).ListAfterCursor(filter, cursor, input.PageSize)
+	if errors.Is(err, repositories.ErrInvalidCursor) {
+		return nil, "", p.restErr.BadRequest(common.ErrInvalidCursor)
+	}
+	if err != nil {
+		return nil, "", p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	var nextCursor string
+	if next != nil {
+		nextCursor, err = next.Encode()
+		if err != nil {
+			return nil, "", p.restErr.ServerError(common.ErrSomethingWentWrong)
+		}
+	}
+	if srvErr := p.localize(ctx, products, input.Currency); srvErr != nil {
+		return nil, "", srvErr
+	}
+
+	p.cache.setList(ctx, "cursor", input, cachedProductList{Products: products, NextCursor: nextCursor})
+	return products, nextCursor, nil
+}
+
+func productListFilter(input dtos.ListProductsRequest) repositories.ProductListFilter {
+	sort := input.Sort
+	if sort == "" {
+		sort = "-created_at"
+	}
+	return repositories.ProductListFilter{
+		Sort:        sort,
+		Status:      models.ProductStatus(input.Status),
+		Currency:    storeCurrencyOr(input.Currency),
+		MinPrice:    input.MinPrice,
+		MaxPrice:    input.MaxPrice,
+		InStock:     input.InStock,
+		OnlyDeleted: input.Deleted,
+	}
+}
+
+func (p *ProductService) CreateVariants(ctx context.Context, productID uint, inputs []dtos.CreateVariantRequest) ([]models.ProductVariant, *common.RestErr) {
+	product, err := p.productRepo.WithContext(ctx).FindByID(productID)
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if product == nil {
+		return nil, p.restErr.BadRequest(common.ErrProductNotFound)
+	}
+
+	if len(inputs) == 0 {
+		return nil, p.restErr.BadRequest("No variants to add")
+	}
+
+	skus := make([]string, 0, len(inputs))
+	seen := make(map[string]bool)
+	for _, input := range inputs {
+		if seen[input.SKU] {
+			return nil, p.restErr.BadRequest(common.ErrSKUAlreadyInUse)
+		}
+		seen[input.SKU] = true
+		skus = append(skus, input.SKU)
+	}
+
+	existingVariants, err := p.variantRepo.WithContext(ctx).FetchBySKUs(skus)
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if len(existingVariants) > 0 {
+		return nil, p.restErr.BadRequest(common.ErrSKUAlreadyInUse)
+	}
+
+	var variants []models.ProductVariant
+	err = p.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		variantRepo := p.variantRepo.WithTx(tx)
+		for _, input := range inputs {
+			variant := models.ProductVariant{
+				ProductID:   product.ID,
+				SKU:         input.SKU,
+				PriceAmount: input.Price,
+				Stock:       input.Stock,
+			}
+			for typeName, value := range input.Options {
+				optionValue, err := variantRepo.FindOrCreateOptionValue(strings.ToLower(typeName), value)
+				if err != nil {
+					return err
+				}
+				variant.OptionValues = append(variant.OptionValues, *optionValue)
+			}
+
+			if err := variantRepo.Create(&variant); err != nil {
+				return err
+			}
+			variants = append(variants, variant)
+		}
+		return recordEvents(p.outboxRepo.WithTx(tx), productEvent(events.ProductUpdated, product))
+	})
+	// another request can take a SKU between the check above and the insert
+	if errors.Is(err, repositories.ErrSKUInUse) {
+		return nil, p.restErr.BadRequest(common.ErrSKUAlreadyInUse)
+	}
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	p.cache.Invalidate(ctx, product.ID)
+
+	return variants, nil
+}
+
+func (p *ProductService) ListVariants(ctx context.Context, productID uint) ([]models.ProductVariant, *common.RestErr) {
+	variants, err := p.variantRepo.WithContext(ctx).FindByProductID(productID)
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return variants, nil
+}
+
+func (p *ProductService) UpdateVariant(ctx context.Context, variantID uint, input dtos.UpdateVariantRequest) (*models.ProductVariant, *common.RestErr) {
+	variant, err := p.variantRepo.WithContext(ctx).FindByID(variantID)
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if variant == nil {
+		return nil, p.restErr.BadRequest(common.ErrVariantNotFound)
+	}
+
+	if input.SKU != "" && input.SKU != variant.SKU {
+		existingVariants, err := p.variantRepo.WithContext(ctx).FetchBySKUs([]string{input.SKU})
+		if err != nil {
+			return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+		}
+		if len(existingVariants) > 0 {
+			return nil, p.restErr.BadRequest(common.ErrSKUAlreadyInUse)
+		}
+		variant.SKU = input.SKU
+	}
+	if input.Price != nil {
+		variant.PriceAmount = input.Price
+	}
+	if input.Stock != nil {
+		variant.Stock = *input.Stock
+	}
+
+	err = p.variantRepo.WithContext(ctx).Update(variant)
+	if errors.Is(err, repositories.ErrSKUInUse) {
+		return nil, p.restErr.BadRequest(common.ErrSKUAlreadyInUse)
+	}
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	p.cache.Invalidate(ctx, variant.ProductID)
+
+	return variant, nil
+}
+
+func (p *ProductService) DeleteVariant(ctx context.Context, variantID uint) *common.RestErr {
+	variant, err := p.variantRepo.WithContext(ctx).FindByID(variantID)
+	if err != nil {
+		return p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if variant == nil {
+		return p.restErr.NotFound(common.ErrVariantNotFound)
+	}
+	if err := p.variantRepo.WithContext(ctx).Delete(variantID); err != nil {
+		return p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	p.cache.Invalidate(ctx, variant.ProductID)
+
+	return nil
+}
+
+func (p *ProductService) ListOptionTypes(ctx context.Context) ([]models.OptionType, *common.RestErr) {
+	optionTypes, err := p.variantRepo.WithContext(ctx).ListOptionTypes()
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return optionTypes, nil
+}
+
+func (p *ProductService) SearchProducts(ctx context.Context, input dtos.SearchProductsRequest) (*dtos.ProductSearchResponse, *common.RestErr) {
+	if input.Page == 0 {
+		input.Page = 1
+	}
+	if input.PageSize == 0 {
+		input.PageSize = 10
+	}
+
+	filter := repositories.ProductSearchFilter{
+		Query:    strings.TrimSpace(input.Query),
+		Currency: storeCurrencyOr(input.Currency),
+		MinPrice: input.MinPrice,
+		MaxPrice: input.MaxPrice,
+		InStock:  input.InStock,
+		Limit:    input.PageSize,
+		Offset:   (input.Page - 1) * input.PageSize,
+	}
+
+	hits, totalCount, err := p.productRepo.WithContext(ctx).SearchFullText(filter)
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	fuzzy := false
+	if totalCount == 0 && filter.Query != "" {
+		hits, totalCount, err = p.productRepo.WithContext(ctx).SearchTrigram(filter)
+		if err != nil {
+			return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+		}
+		fuzzy = true
+	}
+
+	facets, err := p.productRepo.WithContext(ctx).SearchFacets(filter, fuzzy)
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	products := make([]models.Product, 0, len(hits))
+	for _, hit := range hits {
+		products = append(products, hit.Product)
+	}
+	if srvErr := p.localize(ctx, products, input.Currency); srvErr != nil {
+		return nil, srvErr
+	}
+
+	resp := &dtos.ProductSearchResponse{
+		Results:    make([]dtos.ProductSearchHit, 0, len(hits)),
+		Facets:     dtos.ProductSearchFacets{Currency: filter.Currency},
+		TotalCount: totalCount,
+		Fuzzy:      fuzzy,
+	}
+	for i, hit := range hits {
+		resp.Results = append(resp.Results, dtos.ProductSearchHit{
+			Product: toProductResponse(products[i]),
+			Rank:    hit.Rank,
+		})
+	}
+	for _, facet := range facets.PriceRanges {
+		resp.Facets.PriceRanges = append(resp.Facets.PriceRanges, dtos.FacetCount{Value: facet.Value, Count: facet.Count})
+	}
+	for _, facet := range facets.Availability {
+		resp.Facets.Availability = append(resp.Facets.Availability, dtos.FacetCount{Value: facet.Value, Count: facet.Count})
+	}
+
+	return resp, nil
+}
+
+func (p *ProductService) SetProductPrices(ctx context.Context, productID uint, inputs []dtos.ProductPriceRequest) ([]models.ProductPrice, *common.RestErr) {
+	product, err := p.productRepo.WithContext(ctx).FindByID(productID)
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if product == nil {
+		return nil, p.restErr.BadRequest(common.ErrProductNotFound)
+	}
+	if len(inputs) == 0 {
+		return nil, p.restErr.BadRequest("No prices to set")
+	}
+
+	prices := make([]models.ProductPrice, 0, len(inputs))
+	for _, input := range inputs {
+		prices = append(prices, models.ProductPrice{
+			ProductID: product.ID,
+			Currency:  strings.ToUpper(input.Currency),
+			Amount:    input.Amount,
+		})
+	}
+
+	if err := p.currencyRepo.WithContext(ctx).UpsertProductPrices(prices); err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	p.cache.Invalidate(ctx, productID)
+
+	return p.ListProductPrices(ctx, productID)
+}
+
+func (p *ProductService) ListProductPrices(ctx context.Context, productID uint) ([]models.ProductPrice, *common.RestErr) {
+	prices, err := p.currencyRepo.WithContext(ctx).FindProductPrices(productID)
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return prices, nil
+}
+
+func (p *ProductService) DeleteProductPrice(ctx context.Context, productID uint, currency string) *common.RestErr {
+	if err := p.currencyRepo.WithContext(ctx).DeleteProductPrice(productID, strings.ToUpper(currency)); err != nil {
+		return p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	p.cache.Invalidate(ctx, productID)
+
+	return nil
+}
+
+// errImportSKUInUse is returned inside an import's transaction when a SKU
+// belongs to a product other than the one being imported.
+var errImportSKUInUse = errors.New("sku belongs to another product")
+
+// ImportCatalog makes the catalog match inputs in one transaction. Products
+// are matched by name, including deleted ones, which the import restores,
+// and variants by SKU; ones that don't exist are created and ones that do
+// are overwritten. Products and variants missing from inputs are left alone.
+func (p *ProductService) ImportCatalog(ctx context.Context, inputs []dtos.ImportProductRequest) (*dtos.CatalogImportResult, *common.RestErr) {
+	validate := validator.New()
+	names := make(map[string]bool, len(inputs))
+	skus := make(map[string]bool)
+	for _, input := range inputs {
+		if err := validate.Struct(input); err != nil {
+			return nil, p.restErr.BadRequest(fmt.Sprintf("product %q: %v", input.Name, err))
+		}
+		if names[input.Name] {
+			return nil, p.restErr.BadRequest(fmt.Sprintf("product %q is listed more than once", input.Name))
+		}
+		names[input.Name] = true
+		for _, variant := range input.Variants {
+			if skus[variant.SKU] {
+				return nil, p.restErr.BadRequest(fmt.Sprintf("product %q: %s", input.Name, common.ErrSKUAlreadyInUse))
+			}
+			skus[variant.SKU] = true
+		}
+	}
+
+	result := &dtos.CatalogImportResult{}
+	err := p.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		productRepo, variantRepo := p.productRepo.WithTx(tx), p.variantRepo.WithTx(tx)
+		imported := make([]events.Event, 0, len(inputs))
+		for _, input := range inputs {
+			product, err := productRepo.FindByNameUnscoped(input.Name)
+			if err != nil {
+				return err
+			}
+			eventName := events.ProductUpdated
+			if product == nil {
+				product = &models.Product{Name: input.Name}
+				applyImport(product, input)
+				if err := productRepo.Create(product); err != nil {
+					return err
+				}
+				eventName = events.ProductCreated
+				result.Created++
+			} else {
+				if product.DeletedAt.Valid {
+					if _, err := productRepo.Restore(product.ID); err != nil {
+						return err
+					}
+					product.DeletedAt.Valid = false
+				}
+				applyImport(product, input)
+				if err := productRepo.Update(product); err != nil {
+					return err
+				}
+				result.Updated++
+			}
+
+			if err := importVariants(variantRepo, product.ID, input.Variants); err != nil {
+				return fmt.Errorf("product %q: %w", input.Name, err)
+			}
+			if len(input.Prices) > 0 {
+				prices := make([]models.ProductPrice, len(input.Prices))
+				for i, price := range input.Prices {
+					prices[i] = models.ProductPrice{ProductID: product.ID, Currency: strings.ToUpper(price.Currency), Amount: price.Amount}
+				}
+				if err := p.currencyRepo.WithTx(tx).UpsertProductPrices(prices); err != nil {
+					return err
+				}
+			}
+			imported = append(imported, productEvent(eventName, product))
+			result.ProductIDs = append(result.ProductIDs, product.ID)
+		}
+		return recordEvents(p.outboxRepo.WithTx(tx), imported...)
+	})
+	if errors.Is(err, errImportSKUInUse) {
+		return nil, p.restErr.BadRequest(err.Error())
+	}
+	if err != nil {
+		return nil, p.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	p.cache.Invalidate(ctx, result.ProductIDs...)
+
+	return result, nil
+}
+
+// applyImport copies an imported product's fields onto product, filling in
+// the defaults for those it leaves out.
+func applyImport(product *models.Product, input dtos.ImportProductRequest) {
+	product.Description = input.Description
+	product.Price = models.NewMoney(input.Price, storeCurrencyOr(input.Currency))
+	product.Stock = input.Stock
+	product.ImageURL = input.ImageURL
+	product.Category = strings.ToLower(input.Category)
+	product.Status = models.ProductStatusActive
+	if input.Status != "" {
+		product.Status = models.ProductStatus(input.Status)
+	}
+	product.TaxClass = models.DefaultTaxClass
+	if input.TaxClass != "" {
+		product.TaxClass = strings.ToLower(input.TaxClass)
+	}
+	product.WeightGrams = input.WeightGrams
+	product.LengthMM = input.LengthMM
+	product.WidthMM = input.WidthMM
+	product.HeightMM = input.HeightMM
+}
+
+// importVariants creates or overwrites the product's variants by SKU.
+func importVariants(variantRepo repositories.VariantStore, productID uint, inputs []dtos.CreateVariantRequest) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+	skus := make([]string, len(inputs))
+	for i, input := range inputs {
+		skus[i] = input.SKU
+	}
+	existing, err := variantRepo.FetchBySKUs(skus)
+	if err != nil {
+		return err
+	}
+	bySKU := make(map[string]models.ProductVariant, len(existing))
+	for _, variant := range existing {
+		bySKU[variant.SKU] = variant
+	}
+
+	for _, input := range inputs {
+		optionValues := make([]models.OptionValue, 0, len(input.Options))
+		for typeName, value := range input.Options {
+			optionValue, err := variantRepo.FindOrCreateOptionValue(strings.ToLower(typeName), value)
+			if err != nil {
+				return err
+			}
+			optionValues = append(optionValues, *optionValue)
+		}
+
+		variant, ok := bySKU[input.SKU]
+		if !ok {
+			variant = models.ProductVariant{
+				ProductID:    productID,
+				SKU:          input.SKU,
+				PriceAmount:  input.Price,
+				Stock:        input.Stock,
+				OptionValues: optionValues,
+			}
+			if err := variantRepo.Create(&variant); err != nil {
+				return err
+			}
+			continue
+		}
+		if variant.ProductID != productID {
+			return fmt.Errorf("%w: %s", errImportSKUInUse, input.SKU)
+		}
+		variant.PriceAmount = input.Price
+		variant.Stock = input.Stock
+		if err := variantRepo.Update(&variant); err != nil {
+			return err
+		}
+		if err := variantRepo.SetOptionValues(variant.ID, optionValues); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ProductService) localize(ctx context.Context, products []models.Product, currency string) *common.RestErr {
+	if err := p.pricer.Localize(ctx, products, currency); err != nil {
+		return pricingErr(p.restErr, err)
+	}
+	return nil
+}
+
+// productPrice is price in currency, or legacy scaled from major units when
+// the request gave a legacy price instead.
+func productPrice(price int64, legacy *float64, currency string) models.Money {
+	if legacy != nil {
+		price = int64(math.Round(*legacy * math.Pow10(models.MinorUnits(currency))))
+	}
+	return models.NewMoney(price, currency)
+}
+
+// storeCurrencyOr returns currency, or the store's currency when it is empty.
+func storeCurrencyOr(currency string) string {
+	if currency == "" {
+		return utils.GetConfig().StoreCurrency
+	}
+	return strings.ToUpper(currency)
+}
+
+func toProductResponse(product models.Product) dtos.ProductResponse {
+	return dtos.ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Stock:       product.Stock,
+		ImageURL:    product.ImageURL,
+		Category:    product.Category,
+		Status:      string(product.Status),
+		TaxClass:    product.TaxClass,
+		WeightGrams: product.WeightGrams,
+		LengthMM:    product.LengthMM,
+		WidthMM:     product.WidthMM,
+		HeightMM:    product.HeightMM,
+		CreatedAt:   product.CreatedAt,
+		UpdatedAt:   product.UpdatedAt,
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/cache"
+	"instashop/models"
+)
+
+const productListVersionKey = "products:list:version"
+
+// ProductCache holds product detail and listing responses. Keys embed a
+// version that Invalidate moves on, so a stale entry is never read again and
+// a read racing a write can only fill a key nobody will ask for. Cache
+// failures are logged and treated as misses. Localized prices can lag an
+// exchange rate change by up to ttl.
+type ProductCache struct {
+	store cache.Cache
+	ttl   time.Duration
+}
+
+func NewProductCache(store cache.Cache, ttl time.Duration) *ProductCache {
+	return &ProductCache{store, ttl}
+}
+
+type cachedProductList struct {
+	Products   []models.Product
+	TotalCount int64
+	NextCursor string
+}
+
+// Invalidate drops the cached detail of each product and every cached
+// listing.
+func (c *ProductCache) Invalidate(ctx context.Context, productIDs ...uint) {
+	version := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+	for _, productID := range productIDs {
+		if err := c.store.Set(ctx, productVersionKey(productID), version, 0); err != nil {
+			log.Error(zap.Error(err))
+		}
+	}
+	if err := c.store.Set(ctx, productListVersionKey, version, 0); err != nil {
+		log.Error(zap.Error(err))
+	}
+}
+
+func (c *ProductCache) getProduct(ctx context.Context, scope string, productID uint, currency string) *models.Product {
+	var product models.Product
+	if !c.get(ctx, c.productKey(ctx, scope, productID, currency), &product) {
+		return nil
+	}
+	return &product
+}
+
+func (c *ProductCache) setProduct(ctx context.Context, scope string, product *models.Product, currency string) {
+	c.set(ctx, c.productKey(ctx, scope, product.ID, currency), product)
+}
+
+func (c *ProductCache) getList(ctx context.Context, scope string, query interface{}) *cachedProductList {
+	var list cachedProductList
+	if !c.get(ctx, c.listKey(ctx, scope, query), &list) {
+		return nil
+	}
+	return &list
+}
+
+func (c *ProductCache) setList(ctx context.Context, scope string, query interface{}, list cachedProductList) {
+	c.set(ctx, c.listKey(ctx, scope, query), list)
+}
+
+func (c *ProductCache) productKey(ctx context.Context, scope string, productID uint, currency string) string {
+	return fmt.Sprintf("product:%d:%s:%s:%s", productID, c.version(ctx, productVersionKey(productID)), scope, currency)
+}
+
+func (c *ProductCache) listKey(ctx context.Context, scope string, query interface{}) string {
+	encoded, _ := json.Marshal(query)
+	sum := sha256.Sum256(encoded)
+	return fmt.Sprintf("products:list:%s:%s:%s", c.version(ctx, productListVersionKey), scope, hex.EncodeToString(sum[:16]))
+}
+
+func (c *ProductCache) version(ctx context.Context, key string) string {
+	version, err := c.store.Get(ctx, key)
+	if err != nil {
+		log.Error(zap.Error(err))
+	}
+	if len(version) == 0 {
+		return "0"
+	}
+	return string(version)
+}
+
+func (c *ProductCache) get(ctx context.Context, key string, dest interface{}) bool {
+	cached, err := c.store.Get(ctx, key)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return false
+	}
+	if cached == nil {
+		return false
+	}
+	if err := json.Unmarshal(cached, dest); err != nil {
+		log.Error(zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (c *ProductCache) set(ctx context.Context, key string, value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return
+	}
+	if err := c.store.Set(ctx, key, encoded, c.ttl); err != nil {
+		log.Error(zap.Error(err))
+	}
+}
+
+func productVersionKey(productID uint) string {
+	return fmt.Sprintf("product:%d:version", productID)
+}
+
+// orderProductIDs returns the products whose stock items change.
+func orderProductIDs(items []models.OrderItem) []uint {
+	productIDs := make([]uint, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	return productIDs
+}
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"instashop/internal/cache"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/events"
+	"instashop/internal/repositories"
+	"instashop/internal/repositories/memory"
+	"instashop/models"
+)
+
+func TestCreateProducts(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProduct(t, "Mug", 2000, 5)
+
+	products, srvErr := env.productService.CreateProducts(env.ctx, []dtos.CreateProductRequest{
+		{Name: "Mug", Description: "Already exists", Price: 2000, Stock: 1},
+		{Name: "Lamp", Description: "Desk lamp", Price: 4500, Stock: 3, Category: "Home", Status: "draft"},
+	})
+	assertNoRestErr(t, srvErr)
+
+	if len(products) != 1 || products[0].ID == 0 || products[0].Name != "Lamp" {
+		t.Fatalf("got products %+v", products)
+	}
+	lamp := products[0]
+	if lamp.Category != "home" || lamp.Status != models.ProductStatusDraft || lamp.Price.Currency != "USD" {
+		t.Fatalf("got category %q, status %q, currency %q", lamp.Category, lamp.Status, lamp.Price.Currency)
+	}
+	if names := env.eventNames(); !reflect.DeepEqual(names, []string{events.ProductCreated}) {
+		t.Fatalf("got events %v", names)
+	}
+
+	_, srvErr = env.productService.CreateProducts(env.ctx, []dtos.CreateProductRequest{
+		{Name: "Lamp", Description: "Desk lamp", Price: 4500, Stock: 3},
+	})
+	assertRestErr(t, srvErr, "No new products to add")
+}
+
+func TestGetProduct(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+
+	found, srvErr := env.productService.GetProduct(env.ctx, product.ID)
+	assertNoRestErr(t, srvErr)
+	if found.Name != "Mug" || found.Price.Amount != 2000 {
+		t.Fatalf("got product %+v", found)
+	}
+
+	_, srvErr = env.productService.GetProduct(env.ctx, product.ID+100)
+	assertRestErr(t, srvErr, common.ErrProductNotFound)
+}
+
+func TestUpdateProduct(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	// fill the cache so the update has to invalidate it
+	_, srvErr := env.productService.GetProduct(env.ctx, product.ID)
+	assertNoRestErr(t, srvErr)
+
+	weight := 350
+	price := int64(2500)
+	updated, srvErr := env.productService.UpdateProduct(env.ctx, product.ID, dtos.UpdateProductRequest{
+		Name:        "Large Mug",
+		Price:       &price,
+		Stock:       8,
+		WeightGrams: &weight,
+	})
+	assertNoRestErr(t, srvErr)
+	if updated.Name != "Large Mug" || updated.Price.Amount != 2500 || updated.Stock != 8 || updated.WeightGrams != 350 {
+		t.Fatalf("got product %+v", updated)
+	}
+
+	found, srvErr := env.productService.GetProduct(env.ctx, product.ID)
+	assertNoRestErr(t, srvErr)
+	if found.Name != "Large Mug" || found.Description != "Mug description" {
+		t.Fatalf("got stale product %+v", found)
+	}
+	if names := env.eventNames(); !reflect.DeepEqual(names, []string{events.ProductUpdated}) {
+		t.Fatalf("got events %v", names)
+	}
+
+	_, srvErr = env.productService.UpdateProduct(env.ctx, product.ID+100, dtos.UpdateProductRequest{Name: "Nothing"})
+	assertRestErr(t, srvErr, common.ErrProductNotFound)
+}
+
+func TestUpdateProductCurrency(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+
+	_, srvErr := env.productService.UpdateProduct(env.ctx, product.ID, dtos.UpdateProductRequest{Currency: "jpy"})
+	assertRestErr(t, srvErr, common.ErrPriceRequiredForCurrency)
+
+	var input dtos.UpdateProductRequest
+	if err := json.Unmarshal([]byte(`{"currency": "jpy", "price": 3000}`), &input); err != nil {
+		t.Fatal(err)
+	}
+	updated, srvErr := env.productService.UpdateProduct(env.ctx, product.ID, input)
+	assertNoRestErr(t, srvErr)
+	if updated.Price != models.NewMoney(3000, "JPY") {
+		t.Fatalf("got price %v, want 3000 JPY", updated.Price)
+	}
+
+	// the same currency in another case isn't a change
+	_, srvErr = env.productService.UpdateProduct(env.ctx, product.ID, dtos.UpdateProductRequest{Currency: "JPY", Name: "Big Mug"})
+	assertNoRestErr(t, srvErr)
+}
+
+func TestProductRequestsReadLegacyMajorUnitPrices(t *testing.T) {
+	env := newTestEnv(t)
+
+	var inputs []dtos.CreateProductRequest
+	body := `[{"name": "Mug", "description": "A mug", "price": 19.99, "stock": 5},
+		{"name": "Pen", "description": "A pen", "price": 300, "stock": 5}]`
+	if err := json.Unmarshal([]byte(body), &inputs); err != nil {
+		t.Fatal(err)
+	}
+	products, srvErr := env.productService.CreateProducts(env.ctx, inputs)
+	assertNoRestErr(t, srvErr)
+	if products[0].Price.Amount != 1999 || products[1].Price.Amount != 300 {
+		t.Fatalf("got prices %v and %v, want 1999 and 300", products[0].Price, products[1].Price)
+	}
+
+	var update dtos.UpdateProductRequest
+	if err := json.Unmarshal([]byte(`{"currency": "JPY", "price": 1500.0}`), &update); err != nil {
+		t.Fatal(err)
+	}
+	updated, srvErr := env.productService.UpdateProduct(env.ctx, products[0].ID, update)
+	assertNoRestErr(t, srvErr)
+	if updated.Price != models.NewMoney(1500, "JPY") {
+		t.Fatalf("got price %v, want 1500 JPY", updated.Price)
+	}
+}
+
+func TestProductCacheKeepsVersionsWhenFull(t *testing.T) {
+	env := newTestEnv(t)
+	productService := NewProductService(memory.NewTransactor(env.db), env.products, env.variants, env.currencies,
+		env.outbox, NewPricer(env.currencies), NewProductCache(cache.NewMemoryCache(3), time.Minute), &common.RestErr{})
+	mug := env.createProduct(t, "Mug", 2000, 5)
+	_, srvErr := productService.GetProduct(env.ctx, mug.ID)
+	assertNoRestErr(t, srvErr)
+
+	_, srvErr = productService.UpdateProduct(env.ctx, mug.ID, dtos.UpdateProductRequest{Name: "Large Mug"})
+	assertNoRestErr(t, srvErr)
+	// keep the cache full so every new entry evicts one
+	for i := 0; i < 20; i++ {
+		product := env.createProduct(t, fmt.Sprintf("Pen %d", i), 300, 1)
+		_, srvErr := productService.GetProduct(env.ctx, product.ID)
+		assertNoRestErr(t, srvErr)
+
+		found, srvErr := productService.GetProduct(env.ctx, mug.ID)
+		assertNoRestErr(t, srvErr)
+		if found.Name != "Large Mug" {
+			t.Fatalf("got stale product %q after %d evictions", found.Name, i+1)
+		}
+	}
+}
+
+func TestDeleteAndRestoreProduct(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Mug", 2000, 5)
+	_, srvErr := env.productService.GetProduct(env.ctx, product.ID)
+	assertNoRestErr(t, srvErr)
+
+	assertNoRestErr(t, env.productService.DeleteProduct(env.ctx, product.ID))
+	_, srvErr = env.productService.GetProduct(env.ctx, product.ID)
+	assertRestErr(t, srvErr, common.ErrProductNotFound)
+
+	// Deleting it again, or a product that never existed, changes nothing.
+	assertRestErr(t, env.productService.DeleteProduct(env.ctx, product.ID), common.ErrProductNotFound)
+	assertRestErr(t, env.productService.DeleteProduct(env.ctx, product.ID+100), common.ErrProductNotFound)
+	if names := env.eventNames(); !reflect.DeepEqual(names, []string{events.ProductDeleted}) {
+		t.Fatalf("got events %v", names)
+	}
+
+	deleted, _, srvErr := env.productService.ListProducts(env.ctx, dtos.ListProductsRequest{Page: 1, PageSize: 10, Deleted: true})
+	assertNoRestErr(t, srvErr)
+	if len(deleted) != 1 || deleted[0].ID != product.ID {
+		t.Fatalf("got deleted products %+v", deleted)
+	}
+
+	assertNoRestErr(t, env.productService.RestoreProduct(env.ctx, product.ID))
+	if _, srvErr := env.productService.GetProduct(env.ctx, product.ID); srvErr != nil {
+		t.Fatalf("restored product not found: %s", srvErr.Message)
+	}
+	assertRestErr(t, env.productService.RestoreProduct(env.ctx, product.ID), common.ErrProductNotFound)
+}
+
+func TestListProducts(t *testing.T) {
+	env := newTestEnv(t)
+	env.createProduct(t, "Mug", 2000, 5)
+	env.createProduct(t, "Lamp", 4500, 0)
+	env.createProduct(t, "Pen", 300, 40)
+	env.createProduct(t, "Desk", 15000, 2)
+
+	products, total, srvErr := env.productService.ListProducts(env.ctx, dtos.ListProductsRequest{Page: 1, PageSize: 3, Sort: "price"})
+	assertNoRestErr(t, srvErr)
+	if total != 4 || !reflect.DeepEqual(productNames(products), []string{"Pen", "Mug", "Lamp"}) {
+		t.Fatalf("got %v of %d", productNames(products), total)
+	}
+
+	products, total, srvErr = env.productService.ListProducts(env.ctx, dtos.ListProductsRequest{Page: 2, PageSize: 3, Sort: "price"})
+	assertNoRestErr(t, srvErr)
+	if total != 4 || !reflect.DeepEqual(productNames(products), []string{"Desk"}) {
+		t.Fatalf("got %v of %d on page 2", productNames(products), total)
+	}
+
+	products, total, srvErr = env.productService.ListProducts(env.ctx, dtos.ListProductsRequest{Page: 1, PageSize: 10, Sort: "-name", InStock: true})
+	assertNoRestErr(t, srvErr)
+	if total != 3 || !reflect.DeepEqual(productNames(products), []string{"Pen", "Mug", "Desk"}) {
+		t.Fatalf("got in-stock %v of %d", productNames(products), total)
+	}
+}
+
+func TestListProductsByCursor(t *testing.T) {
+	env := newTestEnv(t)
+	for _, name := range []string{"Mug", "Lamp", "Pen", "Desk", "Chair"} {
+		env.createProduct(t, name, 1000, 1)
+	}
+
+	var names []string
+	input := dtos.ListProductsRequest{PageSize: 2, Sort: "name"}
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("cursor never ran out")
+		}
+		products, next, srvErr := env.productService.ListProductsByCursor(env.ctx, input)
+		assertNoRestErr(t, srvErr)
+		names = append(names, productNames(products)...)
+		if next == "" {
+			break
+		}
+		input.Cursor = next
+	}
+
+	if want := []string{"Chair", "Desk", "Lamp", "Mug", "Pen"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("got %v, want %v", names, want)
+	}
+
+	_, _, srvErr := env.productService.ListProductsByCursor(env.ctx, dtos.ListProductsRequest{PageSize: 2, Cursor: "not-a-cursor"})
+	assertRestErr(t, srvErr, common.ErrInvalidCursor)
+}
+
+// createMixedCurrencyCatalog adds a 20 USD mug, a 1500 JPY teapot that costs
+// 10 USD, and a 40 EUR lamp with a 55 USD override.
+func createMixedCurrencyCatalog(t *testing.T, env *testEnv) {
+	t.Helper()
+	env.createRate(t, "USD", "JPY", 150)
+	env.createRate(t, "EUR", "USD", 1.1)
+	env.createProductIn(t, "Mug", models.NewMoney(2000, "USD"), 5)
+	env.createProductIn(t, "Teapot", models.NewMoney(1500, "JPY"), 5)
+	lamp := env.createProductIn(t, "Lamp", models.NewMoney(4000, "EUR"), 5)
+	_, srvErr := env.productService.SetProductPrices(env.ctx, lamp.ID, []dtos.ProductPriceRequest{{Currency: "USD", Amount: 5500}})
+	assertNoRestErr(t, srvErr)
+}
+
+func TestSearchProductsMixedCurrencies(t *testing.T) {
+	env := newTestEnv(t)
+	createMixedCurrencyCatalog(t, env)
+
+	minPrice := int64(1500)
+	resp, srvErr := env.productService.SearchProducts(env.ctx, dtos.SearchProductsRequest{MinPrice: &minPrice})
+	assertNoRestErr(t, srvErr)
+	var names []string
+	for _, hit := range resp.Results {
+		names = append(names, hit.Product.Name)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"Lamp", "Mug"}) {
+		t.Fatalf("got %v from 15 USD up", names)
+	}
+
+	resp, srvErr = env.productService.SearchProducts(env.ctx, dtos.SearchProductsRequest{})
+	assertNoRestErr(t, srvErr)
+	facets := map[string]int64{}
+	for _, facet := range resp.Facets.PriceRanges {
+		facets[facet.Value] = facet.Count
+	}
+	if resp.Facets.Currency != "USD" || facets["10-50"] != 2 || facets["50-100"] != 1 || facets["500+"] != 0 {
+		t.Fatalf("got %s price facets %v", resp.Facets.Currency, facets)
+	}
+
+	// Yen bounds are whole yen; the mug costs 3000 and the lamp has no rate.
+	maxPrice := int64(2000)
+	resp, srvErr = env.productService.SearchProducts(env.ctx, dtos.SearchProductsRequest{MaxPrice: &maxPrice, Currency: "JPY"})
+	assertNoRestErr(t, srvErr)
+	if len(resp.Results) != 1 || resp.Results[0].Product.Name != "Teapot" || resp.Results[0].Product.Price.Amount != 1500 {
+		t.Fatalf("got %+v up to 2000 JPY", resp.Results)
+	}
+}
+
+func TestListProductsMixedCurrencies(t *testing.T) {
+	env := newTestEnv(t)
+	createMixedCurrencyCatalog(t, env)
+
+	products, total, srvErr := env.productService.ListProducts(env.ctx, dtos.ListProductsRequest{Page: 1, PageSize: 10, Sort: "price"})
+	assertNoRestErr(t, srvErr)
+	if total != 3 || !reflect.DeepEqual(productNames(products), []string{"Teapot", "Mug", "Lamp"}) {
+		t.Fatalf("got %v of %d by USD price", productNames(products), total)
+	}
+
+	minPrice := int64(1500)
+	products, _, srvErr = env.productService.ListProducts(env.ctx, dtos.ListProductsRequest{Page: 1, PageSize: 10, Sort: "-price", MinPrice: &minPrice})
+	assertNoRestErr(t, srvErr)
+	if !reflect.DeepEqual(productNames(products), []string{"Lamp", "Mug"}) {
+		t.Fatalf("got %v from 15 USD up", productNames(products))
+	}
+
+	// The lamp has no rate into yen, so it can't take a place in yen order.
+	var names []string
+	input := dtos.ListProductsRequest{PageSize: 1, Sort: "-price", Currency: "JPY"}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("cursor never ran out")
+		}
+		products, next, srvErr := env.productService.ListProductsByCursor(env.ctx, input)
+		assertNoRestErr(t, srvErr)
+		names = append(names, productNames(products)...)
+		if next == "" {
+			break
+		}
+
+		// a cursor into yen order doesn't carry over to dollars
+		_, _, srvErr = env.productService.ListProductsByCursor(env.ctx, dtos.ListProductsRequest{PageSize: 1, Sort: "-price", Cursor: next})
+		assertRestErr(t, srvErr, common.ErrInvalidCursor)
+		input.Cursor = next
+	}
+	if !reflect.DeepEqual(names, []string{"Mug", "Teapot"}) {
+		t.Fatalf("got %v by JPY price", names)
+	}
+}
+
+// racingVariants misses SKUs taken since the check, like a concurrent
+// request taking them between CreateVariants' check and its inserts.
+type racingVariants struct {
+	repositories.VariantStore
+}
+
+func (r racingVariants) WithContext(ctx context.Context) repositories.VariantStore {
+	return r
+}
+
+func (r racingVariants) FetchBySKUs(skus []string) ([]models.ProductVariant, error) {
+	return nil, nil
+}
+
+func TestCreateVariants(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Shirt", 3000, 0)
+
+	variants, srvErr := env.productService.CreateVariants(env.ctx, product.ID, []dtos.CreateVariantRequest{
+		{SKU: "SHIRT-M", Stock: 2, Options: map[string]string{"size": "M"}},
+		{SKU: "SHIRT-L", Stock: 1, Options: map[string]string{"size": "L"}},
+	})
+	assertNoRestErr(t, srvErr)
+	if len(variants) != 2 || variants[0].ID == 0 || len(variants[1].OptionValues) != 1 {
+		t.Fatalf("got variants %+v", variants)
+	}
+	if names := env.eventNames(); !reflect.DeepEqual(names, []string{events.ProductUpdated}) {
+		t.Fatalf("got events %v", names)
+	}
+
+	_, srvErr = env.productService.CreateVariants(env.ctx, product.ID, []dtos.CreateVariantRequest{{SKU: "SHIRT-M"}})
+	assertRestErr(t, srvErr, common.ErrSKUAlreadyInUse)
+
+	// A SKU taken after the check fails the whole batch.
+	racing := NewProductService(memory.NewTransactor(env.db), env.products, racingVariants{env.variants}, env.currencies,
+		env.outbox, NewPricer(env.currencies), NewProductCache(cache.NewMemoryCache(10), time.Minute), &common.RestErr{})
+	_, srvErr = racing.CreateVariants(env.ctx, product.ID, []dtos.CreateVariantRequest{{SKU: "SHIRT-XL"}, {SKU: "SHIRT-M"}})
+	assertRestErr(t, srvErr, common.ErrSKUAlreadyInUse)
+	if found, err := env.variants.FetchBySKUs([]string{"SHIRT-XL"}); err != nil || len(found) != 0 {
+		t.Fatalf("got %+v, %v after the batch failed", found, err)
+	}
+	if names := env.eventNames(); len(names) != 1 {
+		t.Fatalf("got events %v after the batch failed", names)
+	}
+}
+
+func TestDeleteVariant(t *testing.T) {
+	env := newTestEnv(t)
+	product := env.createProduct(t, "Shirt", 3000, 0)
+	variant := &models.ProductVariant{ProductID: product.ID, SKU: "SHIRT-M", Stock: 2}
+	if err := env.variants.Create(variant); err != nil {
+		t.Fatal(err)
+	}
+	method := env.createShippingMethod(t, 0)
+	order, srvErr := env.orderService.PlaceOrder(env.ctx,
+		placeOrderRequest(method.ID, dtos.OrderItemRequest{ProductID: product.ID, VariantID: &variant.ID, Quantity: 1}), 7)
+	assertNoRestErr(t, srvErr)
+
+	assertNoRestErr(t, env.productService.DeleteVariant(env.ctx, variant.ID))
+	assertRestErr(t, env.productService.DeleteVariant(env.ctx, variant.ID), common.ErrVariantNotFound)
+	if variants, srvErr := env.productService.ListVariants(env.ctx, product.ID); srvErr != nil || len(variants) != 0 {
+		t.Fatalf("got variants %+v after deleting, %v", variants, srvErr)
+	}
+
+	// Orders for the deleted variant can still be cancelled, but it can't be
+	// sold any more.
+	assertNoRestErr(t, env.orderService.CancelOrder(env.ctx, 7, order.ID))
+	if stock, err := env.variants.DecreaseStock(variant.ID, 1); err == nil {
+		t.Fatalf("took stock from a deleted variant, %d left", stock)
+	}
+
+	// The SKU is free for a new variant.
+	_, srvErr = env.productService.CreateVariants(env.ctx, product.ID, []dtos.CreateVariantRequest{
+		{SKU: "SHIRT-M", Stock: 1, Options: map[string]string{"size": "M"}},
+	})
+	assertNoRestErr(t, srvErr)
+}
+
+func TestImportCatalog(t *testing.T) {
+	env := newTestEnv(t)
+	mug := env.createProduct(t, "Mug", 2000, 5)
+	lamp := env.createProduct(t, "Lamp", 4500, 3)
+	if _, err := env.products.Delete(lamp.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	result, srvErr := env.productService.ImportCatalog(env.ctx, []dtos.ImportProductRequest{
+		{Name: "Mug", Description: "Bigger mug", Price: 2200, Stock: 8},
+		{Name: "Lamp", Description: "Desk lamp", Price: 4000, Stock: 2},
+		{Name: "Tee", Description: "Cotton tee", Price: 1500, Variants: []dtos.CreateVariantRequest{
+			{SKU: "TEE-S", Stock: 4, Options: map[string]string{"Size": "S"}},
+		}},
+	})
+	assertNoRestErr(t, srvErr)
+	if result.Created != 1 || result.Updated != 2 {
+		t.Fatalf("got %d created and %d updated, want 1 and 2", result.Created, result.Updated)
+	}
+
+	// The deleted lamp is restored rather than duplicated.
+	restored, err := env.products.FindByID(lamp.ID)
+	if err != nil || restored == nil || restored.Price.Amount != 4000 {
+		t.Fatalf("got lamp %+v, %v", restored, err)
+	}
+	if stock := env.stock(t, mug.ID); stock != 8 {
+		t.Fatalf("got mug stock %d, want 8", stock)
+	}
+	variants, err := env.variants.FetchBySKUs([]string{"TEE-S"})
+	if err != nil || len(variants) != 1 || variants[0].Stock != 4 {
+		t.Fatalf("got variants %+v, %v", variants, err)
+	}
+	want := []string{events.ProductUpdated, events.ProductUpdated, events.ProductCreated}
+	if names := env.eventNames(); !reflect.DeepEqual(names, want) {
+		t.Fatalf("got events %v, want %v", names, want)
+	}
+
+	// A SKU can't move to another product, and nothing is applied.
+	_, srvErr = env.productService.ImportCatalog(env.ctx, []dtos.ImportProductRequest{
+		{Name: "Mug", Description: "Mug", Price: 2500, Stock: 1},
+		{Name: "Shirt", Description: "Shirt", Price: 3000, Variants: []dtos.CreateVariantRequest{
+			{SKU: "TEE-S", Stock: 1, Options: map[string]string{"size": "S"}},
+		}},
+	})
+	if srvErr == nil {
+		t.Fatal("expected an error moving a SKU to another product")
+	}
+	if stock := env.stock(t, mug.ID); stock != 8 {
+		t.Fatalf("got mug stock %d after a failed import, want 8", stock)
+	}
+
+	_, srvErr = env.productService.ImportCatalog(env.ctx, []dtos.ImportProductRequest{
+		{Name: "Vase", Price: 1000, Status: "sold"},
+	})
+	if srvErr == nil || srvErr.StatusCode != 400 {
+		t.Fatalf("expected a validation error, got %+v", srvErr)
+	}
+}
+
+func productNames(products []models.Product) []string {
+	names := make([]string, 0, len(products))
+	for _, product := range products {
+		names = append(names, product.Name)
+	}
+	return names
+}
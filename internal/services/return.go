@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+var (
+	errOrderNotReturnable      = errors.New("order not returnable")
+	errInvalidReturnItem       = errors.New("invalid return item")
+	errReturnNotFound          = errors.New("return not found")
+	errReturnAlreadyResolved   = errors.New("return already resolved")
+	errRefundExceedsOrderTotal = errors.New("refund exceeds order total")
+)
+
+type ReturnClient interface {
+	RequestReturn(ctx context.Context, userID, orderID uint, input dtos.CreateReturnRequest) (*dtos.ReturnResponse, *common.RestErr)
+	ListOrderReturns(ctx context.Context, userID, orderID uint) ([]dtos.ReturnResponse, *common.RestErr)
+	ListReturns(ctx context.Context, status string) ([]dtos.ReturnResponse, *common.RestErr)
+	GetReturn(ctx context.Context, returnID uint) (*dtos.ReturnResponse, *common.RestErr)
+	ApproveReturn(ctx context.Context, returnID uint, input dtos.ApproveReturnRequest) (*dtos.ReturnResponse, *common.RestErr)
+	RejectReturn(ctx context.Context, returnID uint, input dtos.RejectReturnRequest) (*dtos.ReturnResponse, *common.RestErr)
+}
+
+type ReturnService struct {
+	transactor   repositories.TxRunner
+	orderRepo    repositories.OrderStore
+	returnRepo   *repositories.ReturnRepository
+	productRepo  repositories.ProductStore
+	variantRepo  repositories.VariantStore
+	productCache *ProductCache
+	restErr      *common.RestErr
+}
+
+func NewReturnService(
+	transactor repositories.TxRunner,
+	orderRepo repositories.OrderStore,
+	returnRepo *repositories.ReturnRepository,
+	productRepo repositories.ProductStore,
+	variantRepo repositories.VariantStore,
+	productCache *ProductCache,
+	restErr *common.RestErr,
+) ReturnClient {
+	return &ReturnService{
+		transactor,
+		orderRepo,
+		returnRepo,
+		productRepo,
+		variantRepo,
+		productCache,
+		restErr,
+	}
+}
+
+// RequestReturn opens a return for items of a delivered order. Units already
+// in a pending or approved return can't be returned again.
+func (r *ReturnService) RequestReturn(ctx context.Context, userID, orderID uint, input dtos.CreateReturnRequest) (*dtos.ReturnResponse, *common.RestErr) {
+	request := models.ReturnRequest{
+		OrderID:   orderID,
+		UserID:    userID,
+		Status:    models.ReturnStatusRequested,
+		Reason:    input.Reason,
+		PhotoURLs: input.PhotoURLs,
+	}
+
+	err := r.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		order, err := r.orderRepo.WithTx(tx).LockByID(orderID)
+		if err != nil {
+			return err
+		}
+		if order == nil || order.UserID != userID {
+			return errOrderNotFound
+		}
+		if order.Status != models.OrderStatusDelivered && order.Status != models.OrderStatusCompleted {
+			return errOrderNotReturnable
+		}
+		request.RefundAmount = models.NewMoney(0, order.TotalPrice.Currency)
+
+		returnRepo := r.returnRepo.WithTx(tx)
+		returned, err := returnRepo.ReturnedQuantities(orderID)
+		if err != nil {
+			return err
+		}
+		remaining := make(map[uint]int, len(order.Items))
+		for _, item := range order.Items {
+			remaining[item.ID] = item.Quantity - returned[item.ID]
+		}
+
+		for _, item := range input.Items {
+			left, ok := remaining[item.OrderItemID]
+			if !ok || item.Quantity > left {
+				return errInvalidReturnItem
+			}
+			remaining[item.OrderItemID] -= item.Quantity
+			request.Items = append(request.Items, models.ReturnItem{
+				OrderItemID: item.OrderItemID,
+				Quantity:    item.Quantity,
+			})
+		}
+
+		return returnRepo.Create(&request)
+	})
+	if err != nil {
+		return nil, r.returnErr(err)
+	}
+
+	response := toReturnResponse(request)
+	return &response, nil
+}
+
+func (r *ReturnService) ListOrderReturns(ctx context.Context, userID, orderID uint) ([]dtos.ReturnResponse, *common.RestErr) {
+	order, exists, err := r.orderRepo.WithContext(ctx).FindByID(orderID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, r.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if !exists || order.UserID != userID {
+		return nil, r.restErr.NotFound(common.ErrOrderNotFound)
+	}
+
+	requests, err := r.returnRepo.WithContext(ctx).FindByOrderID(orderID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, r.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return toReturnResponses(requests), nil
+}
+
+func (r *ReturnService) ListReturns(ctx context.Context, status string) ([]dtos.ReturnResponse, *common.RestErr) {
+	requests, err := r.returnRepo.WithContext(ctx).List(models.ReturnStatus(status))
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, r.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return toReturnResponses(requests), nil
+}
+
+func (r *ReturnService) GetReturn(ctx context.Context, returnID uint) (*dtos.ReturnResponse, *common.RestErr) {
+	request, err := r.returnRepo.WithContext(ctx).FindByID(returnID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, r.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if request == nil {
+		return nil, r.restErr.NotFound(common.ErrReturnNotFound)
+	}
+
+	response := toReturnResponse(*request)
+	return &response, nil
+}
+
+// ApproveReturn accepts a return, puts the items marked for restocking back
+// into inventory and records the refund. Refunds across all of an order's
+// returns can't exceed what was paid for it.
+func (r *ReturnService) ApproveReturn(ctx context.Context, returnID uint, input dtos.ApproveReturnRequest) (*dtos.ReturnResponse, *common.RestErr) {
+	var request *models.ReturnRequest
+	var restocked []uint
+
+	err := r.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		returnRepo := r.returnRepo.WithTx(tx)
+
+		var err error
+		request, err = returnRepo.LockByID(returnID)
+		if err != nil {
+			return err
+		}
+		if request == nil {
+			return errReturnNotFound
+		}
+		if request.Status != models.ReturnStatusRequested {
+			return errReturnAlreadyResolved
+		}
+
+		order, err := r.orderRepo.WithTx(tx).LockByID(request.OrderID)
+		if err != nil {
+			return err
+		}
+		if order == nil {
+			return errOrderNotFound
+		}
+		orderItems := make(map[uint]models.OrderItem, len(order.Items))
+		for _, item := range order.Items {
+			orderItems[item.ID] = item
+		}
+
+		restock := make(map[uint]bool, len(input.Restock))
+		for _, item := range input.Restock {
+			restock[item.ReturnItemID] = item.Restock
+		}
+
+		restocked = nil
+		refund := models.NewMoney(0, order.TotalPrice.Currency)
+		productRepo, variantRepo := r.productRepo.WithTx(tx), r.variantRepo.WithTx(tx)
+		for i := range request.Items {
+			item := &request.Items[i]
+			orderItem := orderItems[item.OrderItemID]
+			refund.Amount += itemRefund(order, orderItem, item.Quantity)
+
+			item.Restock = restock[item.ID]
+			if !item.Restock {
+				continue
+			}
+			if orderItem.VariantID != nil {
+				err = variantRepo.IncreaseStock(*orderItem.VariantID, item.Quantity)
+			} else {
+				err = productRepo.IncreaseStock(orderItem.ProductID, item.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+			restocked = append(restocked, orderItem.ProductID)
+		}
+		if input.RefundAmount != nil {
+			refund.Amount = *input.RefundAmount
+		}
+
+		refunded, err := returnRepo.RefundedTotal(order.ID)
+		if err != nil {
+			return err
+		}
+		if refunded+refund.Amount > order.TotalPrice.Amount {
+			return errRefundExceedsOrderTotal
+		}
+
+		now := time.Now()
+		request.Status = models.ReturnStatusApproved
+		request.RefundAmount = refund
+		request.AdminNote = input.Note
+		request.ResolvedAt = &now
+		return returnRepo.Resolve(request)
+	})
+	if err != nil {
+		return nil, r.returnErr(err)
+	}
+	if len(restocked) > 0 {
+		r.productCache.Invalidate(ctx, restocked...)
+	}
+
+	response := toReturnResponse(*request)
+	return &response, nil
+}
+
+func (r *ReturnService) RejectReturn(ctx context.Context, returnID uint, input dtos.RejectReturnRequest) (*dtos.ReturnResponse, *common.RestErr) {
+	var request *models.ReturnRequest
+
+	err := r.transactor.WithContext(ctx).Transaction(func(tx repositories.Tx) error {
+		returnRepo := r.returnRepo.WithTx(tx)
+
+		var err error
+		request, err = returnRepo.LockByID(returnID)
+		if err != nil {
+			return err
+		}
+		if request == nil {
+			return errReturnNotFound
+		}
+		if request.Status != models.ReturnStatusRequested {
+			return errReturnAlreadyResolved
+		}
+
+		now := time.Now()
+		request.Status = models.ReturnStatusRejected
+		request.AdminNote = input.Note
+		request.ResolvedAt = &now
+		return returnRepo.Resolve(request)
+	})
+	if err != nil {
+		return nil, r.returnErr(err)
+	}
+
+	response := toReturnResponse(*request)
+	return &response, nil
+}
+
+func (r *ReturnService) returnErr(err error) *common.RestErr {
+	switch {
+	case errors.Is(err, errOrderNotFound):
+		return r.restErr.NotFound(common.ErrOrderNotFound)
+	case errors.Is(err, errOrderNotReturnable):
+		return r.restErr.BadRequest(common.ErrOrderNotReturnable)
+	case errors.Is(err, errInvalidReturnItem):
+		return r.restErr.BadRequest(common.ErrInvalidReturnItem)
+	case errors.Is(err, errReturnNotFound):
+		return r.restErr.NotFound(common.ErrReturnNotFound)
+	case errors.Is(err, errReturnAlreadyResolved):
+		return r.restErr.BadRequest(common.ErrReturnAlreadyResolved)
+	case errors.Is(err, errRefundExceedsOrderTotal):
+		return r.restErr.BadRequest(common.ErrRefundExceedsOrderTotal)
+	}
+	log.Error(zap.Error(err))
+	return r.restErr.ServerError(common.ErrSomethingWentWrong)
+}
+
+// itemRefund is what the customer paid for quantity units of item: their
+// share of the line after its part of the coupon discount, plus their share
+// of the line's tax when it was charged on top.
+func itemRefund(order *models.Order, item models.OrderItem, quantity int) int64 {
+	if item.Quantity == 0 {
+		return 0
+	}
+	paid := item.Price.Amount*int64(item.Quantity) - item.DiscountAmount.Amount
+	if !order.TaxInclusive {
+		paid += item.TaxAmount.Amount
+	}
+	return paid * int64(quantity) / int64(item.Quantity)
+}
+
+func toReturnResponses(requests []models.ReturnRequest) []dtos.ReturnResponse {
+	responses := make([]dtos.ReturnResponse, 0, len(requests))
+	for _, request := range requests {
+		responses = append(responses, toReturnResponse(request))
+	}
+	return responses
+}
+
+func toReturnResponse(request models.ReturnRequest) dtos.ReturnResponse {
+	response := dtos.ReturnResponse{
+		ID:           request.ID,
+		OrderID:      request.OrderID,
+		UserID:       request.UserID,
+		Status:       string(request.Status),
+		Reason:       request.Reason,
+		PhotoURLs:    request.PhotoURLs,
+		AdminNote:    request.AdminNote,
+		RefundAmount: request.RefundAmount,
+		ResolvedAt:   request.ResolvedAt,
+		CreatedAt:    request.CreatedAt,
+		Items:        make([]dtos.ReturnItemDetail, 0, len(request.Items)),
+	}
+	for _, item := range request.Items {
+		response.Items = append(response.Items, dtos.ReturnItemDetail{
+			ID:          item.ID,
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+			Restock:     item.Restock,
+		})
+	}
+	return response
+}
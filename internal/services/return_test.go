@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+
+	"instashop/models"
+)
+
+func TestItemRefund(t *testing.T) {
+	// 3 units at 2000 with 600 of the coupon discount and 540 tax on the
+	// discounted 5400
+	item := models.OrderItem{
+		Quantity:       3,
+		Price:          models.NewMoney(2000, "USD"),
+		DiscountAmount: models.NewMoney(600, "USD"),
+		TaxAmount:      models.NewMoney(540, "USD"),
+	}
+
+	tests := []struct {
+		name      string
+		inclusive bool
+		quantity  int
+		want      int64
+	}{
+		{"whole line", false, 3, 5940},
+		{"one unit", false, 1, 1980},
+		{"tax inclusive", true, 1, 1800},
+	}
+	for _, tt := range tests {
+		order := &models.Order{TaxInclusive: tt.inclusive}
+		if got := itemRefund(order, item, tt.quantity); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
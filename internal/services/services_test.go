@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"instashop/internal/cache"
+	"instashop/internal/common"
+	"instashop/internal/repositories/memory"
+	"instashop/internal/utils"
+	"instashop/models"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("APP_ENV", utils.ProfileTest)
+	os.Setenv("JWT_SCECRET", "test-secret")
+	if err := utils.InitConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// testEnv wires the services to in-memory repositories sharing one DB.
+type testEnv struct {
+	ctx        context.Context
+	db         *memory.DB
+	products   *memory.ProductRepository
+	variants   *memory.VariantRepository
+	orders     *memory.OrderRepository
+	users      *memory.UserRepository
+	coupons    *memory.CouponRepository
+	outbox     *memory.OutboxRepository
+	shipping   *memory.ShippingRepository
+	currencies *memory.CurrencyRepository
+
+	productService ProductClient
+	orderService   OrderClient
+	authService    AuthClient
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := memory.NewDB()
+	env := &testEnv{
+		ctx:        context.Background(),
+		db:         db,
+		products:   memory.NewProductRepository(db),
+		variants:   memory.NewVariantRepository(db),
+		orders:     memory.NewOrderRepository(db),
+		users:      memory.NewUserRepository(db),
+		coupons:    memory.NewCouponRepository(db),
+		outbox:     memory.NewOutboxRepository(db),
+		shipping:   memory.NewShippingRepository(db),
+		currencies: memory.NewCurrencyRepository(db),
+	}
+
+	restErr := &common.RestErr{}
+	transactor := memory.NewTransactor(db)
+	currencyRepo := env.currencies
+	pricer := NewPricer(currencyRepo)
+	productCache := NewProductCache(cache.NewMemoryCache(1000), time.Minute)
+
+	env.productService = NewProductService(transactor, env.products, env.variants, currencyRepo, env.outbox,
+		pricer, productCache, restErr)
+	env.orderService = NewOrderService(transactor, env.orders, env.products, env.variants, env.coupons, env.outbox,
+		pricer, productCache, NewDiscountEngine(env.coupons, pricer, restErr), flatTax{rate: 1000},
+		NewShippingCalculator(env.shipping, pricer), restErr)
+	env.authService = NewAuthService(transactor, env.users, env.outbox, restErr)
+	return env
+}
+
+func (e *testEnv) createProduct(t *testing.T, name string, price int64, stock int) *models.Product {
+	t.Helper()
+	return e.createProductIn(t, name, models.NewMoney(price, utils.GetConfig().StoreCurrency), stock)
+}
+
+func (e *testEnv) createProductIn(t *testing.T, name string, price models.Money, stock int) *models.Product {
+	t.Helper()
+	product := &models.Product{
+		Name:        name,
+		Description: name + " description",
+		Price:       price,
+		Stock:       stock,
+		Status:      models.ProductStatusActive,
+		TaxClass:    models.DefaultTaxClass,
+	}
+	if err := e.products.Create(product); err != nil {
+		t.Fatalf("creating product: %v", err)
+	}
+	return product
+}
+
+// createRate records a base→quote rate that took effect an hour ago.
+func (e *testEnv) createRate(t *testing.T, base, quote string, rate float64) {
+	t.Helper()
+	err := e.currencies.CreateExchangeRate(&models.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          rate,
+		EffectiveAt:   time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("creating exchange rate: %v", err)
+	}
+}
+
+// createShippingMethod adds a flat-rate method shipping anywhere.
+func (e *testEnv) createShippingMethod(t *testing.T, rate int64) *models.ShippingMethod {
+	t.Helper()
+	zone := &models.ShippingZone{
+		Name: fmt.Sprintf("zone-%d", rate),
+		Methods: []models.ShippingMethod{{
+			Name: "Standard",
+			Type: models.ShippingMethodFlat,
+			Rate: models.NewMoney(rate, utils.GetConfig().StoreCurrency),
+		}},
+	}
+	if err := e.shipping.CreateZone(zone); err != nil {
+		t.Fatalf("creating shipping zone: %v", err)
+	}
+	return &zone.Methods[0]
+}
+
+func (e *testEnv) stock(t *testing.T, productID uint) int {
+	t.Helper()
+	product, err := e.products.FindByID(productID)
+	if err != nil || product == nil {
+		t.Fatalf("finding product %d: %v", productID, err)
+	}
+	return product.Stock
+}
+
+// eventNames lists the names of the events recorded in the outbox.
+func (e *testEnv) eventNames() []string {
+	var names []string
+	for _, event := range e.outbox.Events() {
+		names = append(names, event.Name)
+	}
+	return names
+}
+
+// flatTax charges rate basis points on every line, on top of the price.
+type flatTax struct {
+	rate int
+}
+
+func (f flatTax) Calculate(ctx context.Context, address models.Address, lines []TaxableLine) (*TaxBreakdown, error) {
+	breakdown := &TaxBreakdown{}
+	for _, line := range lines {
+		amount := models.NewMoney(line.Amount.Amount*int64(f.rate)/10000, line.Amount.Currency)
+		breakdown.Lines = append(breakdown.Lines, LineTax{Rate: f.rate, Amount: amount})
+		breakdown.Total.Amount += amount.Amount
+	}
+	return breakdown, nil
+}
+
+func assertRestErr(t *testing.T, srvErr *common.RestErr, message string) {
+	t.Helper()
+	if srvErr == nil {
+		t.Fatalf("expected error %q, got none", message)
+	}
+	if srvErr.Message != message {
+		t.Fatalf("expected error %q, got %q", message, srvErr.Message)
+	}
+}
+
+func assertNoRestErr(t *testing.T, srvErr *common.RestErr) {
+	t.Helper()
+	if srvErr != nil {
+		t.Fatalf("unexpected error: %d %s", srvErr.StatusCode, srvErr.Message)
+	}
+}
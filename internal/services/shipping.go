@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/repositories"
+	"instashop/internal/utils"
+	"instashop/models"
+)
+
+// ShippingRate is what one shipping method charges for an order.
+type ShippingRate struct {
+	Method *models.ShippingMethod
+	Cost   models.Money
+}
+
+// ShippingCalculator prices the shipping methods available for a
+// destination. Method rates are converted into the order's currency.
+type ShippingCalculator struct {
+	shippingRepo repositories.ShippingStore
+	pricer       *Pricer
+}
+
+func NewShippingCalculator(
+	shippingRepo repositories.ShippingStore,
+	pricer *Pricer,
+) *ShippingCalculator {
+	return &ShippingCalculator{
+		shippingRepo,
+		pricer,
+	}
+}
+
+// Quote returns the active methods that ship to address, priced for an
+// order of subtotal weighing weightGrams. Free-over thresholds are checked
+// against the subtotal before discounts.
+func (s *ShippingCalculator) Quote(ctx context.Context, address models.Address, subtotal models.Money, weightGrams int) ([]ShippingRate, error) {
+	zones, err := s.shippingRepo.WithContext(ctx).ListZones()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched, fallback []models.ShippingZone
+	for _, zone := range zones {
+		if len(zone.Countries) == 0 {
+			fallback = append(fallback, zone)
+			continue
+		}
+		for _, country := range zone.Countries {
+			if strings.EqualFold(country, address.Country) {
+				matched = append(matched, zone)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		matched = fallback
+	}
+
+	var rates []ShippingRate
+	for _, zone := range matched {
+		for i := range zone.Methods {
+			method := &zone.Methods[i]
+			if method.IsActive != nil && !*method.IsActive {
+				continue
+			}
+			cost, err := s.cost(ctx, method, subtotal, weightGrams)
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, ShippingRate{Method: method, Cost: cost})
+		}
+	}
+
+	return rates, nil
+}
+
+// Configured reports whether the store has any shipping zones. Until it
+// does, orders ship free without choosing a method.
+func (s *ShippingCalculator) Configured(ctx context.Context) (bool, error) {
+	zones, err := s.shippingRepo.WithContext(ctx).ListZones()
+	return len(zones) > 0, err
+}
+
+func (s *ShippingCalculator) cost(ctx context.Context, method *models.ShippingMethod, subtotal models.Money, weightGrams int) (models.Money, error) {
+	cost := method.Rate
+	switch method.Type {
+	case models.ShippingMethodWeightBased:
+		kilograms := (weightGrams + 999) / 1000
+		var err error
+		if cost, err = cost.Add(method.PerKg.Mul(kilograms)); err != nil {
+			return models.Money{}, err
+		}
+	case models.ShippingMethodFreeOverThreshold:
+		threshold, err := s.pricer.Convert(ctx, method.FreeOver, subtotal.Currency)
+		if err != nil {
+			return models.Money{}, err
+		}
+		if subtotal.Amount >= threshold.Amount {
+			return models.NewMoney(0, subtotal.Currency), nil
+		}
+	}
+	return s.pricer.Convert(ctx, cost, subtotal.Currency)
+}
+
+type ShippingClient interface {
+	CreateZone(input dtos.CreateShippingZoneRequest) (*models.ShippingZone, *common.RestErr)
+	ListZones() ([]models.ShippingZone, *common.RestErr)
+	DeleteZone(zoneID uint) *common.RestErr
+	CreateMethod(zoneID uint, input dtos.CreateShippingMethodRequest) (*models.ShippingMethod, *common.RestErr)
+	UpdateMethod(methodID uint, input dtos.UpdateShippingMethodRequest) (*models.ShippingMethod, *common.RestErr)
+	DeleteMethod(methodID uint) *common.RestErr
+}
+
+type ShippingService struct {
+	shippingRepo repositories.ShippingStore
+	restErr      *common.RestErr
+}
+
+func NewShippingService(
+	shippingRepo repositories.ShippingStore,
+	restErr *common.RestErr,
+) ShippingClient {
+	return &ShippingService{
+		shippingRepo,
+		restErr,
+	}
+}
+
+func (s *ShippingService) CreateZone(input dtos.CreateShippingZoneRequest) (*models.ShippingZone, *common.RestErr) {
+	existing, err := s.shippingRepo.FindZoneByName(input.Name)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, s.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if existing != nil {
+		return nil, s.restErr.BadRequest(common.ErrShippingZoneExists)
+	}
+
+	zone := models.ShippingZone{Name: input.Name}
+	for _, country := range input.Countries {
+		zone.Countries = append(zone.Countries, strings.ToUpper(country))
+	}
+
+	if err := s.shippingRepo.CreateZone(&zone); err != nil {
+		log.Error(zap.Error(err))
+		return nil, s.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return &zone, nil
+}
+
+func (s *ShippingService) ListZones() ([]models.ShippingZone, *common.RestErr) {
+	zones, err := s.shippingRepo.ListZones()
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, s.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return zones, nil
+}
+
+func (s *ShippingService) DeleteZone(zoneID uint) *common.RestErr {
+	if err := s.shippingRepo.DeleteZone(zoneID); err != nil {
+		log.Error(zap.Error(err))
+		return s.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return nil
+}
+
+func (s *ShippingService) CreateMethod(zoneID uint, input dtos.CreateShippingMethodRequest) (*models.ShippingMethod, *common.RestErr) {
+	methodType := models.ShippingMethodType(input.Type)
+	if methodType == models.ShippingMethodWeightBased && input.PerKg == 0 ||
+		methodType == models.ShippingMethodFreeOverThreshold && input.FreeOver == 0 {
+		return nil, s.restErr.BadRequest(common.ErrShippingMethodInvalid)
+	}
+
+	zone, err := s.shippingRepo.FindZoneByID(zoneID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, s.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if zone == nil {
+		return nil, s.restErr.NotFound(common.ErrShippingZoneNotFound)
+	}
+
+	currency := storeCurrencyOr(input.Currency)
+	method := models.ShippingMethod{
+		ZoneID:   zone.ID,
+		Name:     input.Name,
+		Type:     methodType,
+		Rate:     models.NewMoney(input.Rate, currency),
+		PerKg:    models.NewMoney(input.PerKg, currency),
+		FreeOver: models.NewMoney(input.FreeOver, currency),
+		IsActive: utils.BoolPointer(true),
+	}
+
+	if err := s.shippingRepo.CreateMethod(&method); err != nil {
+		log.Error(zap.Error(err))
+		return nil, s.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return &method, nil
+}
+
+func (s *ShippingService) UpdateMethod(methodID uint, input dtos.UpdateShippingMethodRequest) (*models.ShippingMethod, *common.RestErr) {
+	method, err := s.shippingRepo.FindMethodByID(methodID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, s.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if method == nil {
+		return nil, s.restErr.NotFound(common.ErrShippingMethodNotFound)
+	}
+
+	if input.Name != "" {
+		method.Name = input.Name
+	}
+	if input.Rate != nil {
+		method.Rate.Amount = *input.Rate
+	}
+	if input.PerKg != nil {
+		method.PerKg.Amount = *input.PerKg
+	}
+	if input.FreeOver != nil {
+		method.FreeOver.Amount = *input.FreeOver
+	}
+	if input.IsActive != nil {
+		method.IsActive = input.IsActive
+	}
+
+	if err := s.shippingRepo.UpdateMethod(method); err != nil {
+		log.Error(zap.Error(err))
+		return nil, s.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return method, nil
+}
+
+func (s *ShippingService) DeleteMethod(methodID uint) *common.RestErr {
+	if err := s.shippingRepo.DeleteMethod(methodID); err != nil {
+		log.Error(zap.Error(err))
+		return s.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return nil
+}
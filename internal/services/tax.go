@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+// TaxableLine is an order line as a TaxCalculator sees it. Amount is the
+// line total after discounts.
+type TaxableLine struct {
+	ProductID uint
+	TaxClass  string
+	Amount    models.Money
+}
+
+// LineTax is the tax on one TaxableLine.
+type LineTax struct {
+	Rate   int
+	Amount models.Money
+}
+
+// TaxBreakdown is the tax on an order, with Lines in the same order as the
+// lines it was calculated for. When Inclusive is set the tax is already part
+// of the line amounts and must not be added to the total.
+type TaxBreakdown struct {
+	Inclusive bool
+	Lines     []LineTax
+	Total     models.Money
+}
+
+// TaxCalculator works out the tax due on an order shipped to address.
+// RuleTaxCalculator uses the store's own tax rules; a third-party tax
+// service can be used instead by implementing this interface.
+type TaxCalculator interface {
+	Calculate(ctx context.Context, address models.Address, lines []TaxableLine) (*TaxBreakdown, error)
+}
+
+type RuleTaxCalculator struct {
+	taxRepo *repositories.TaxRepository
+	mode    models.TaxMode
+}
+
+func NewRuleTaxCalculator(taxRepo *repositories.TaxRepository, mode models.TaxMode) TaxCalculator {
+	return &RuleTaxCalculator{
+		taxRepo,
+		mode,
+	}
+}
+
+func (r *RuleTaxCalculator) Calculate(ctx context.Context, address models.Address, lines []TaxableLine) (*TaxBreakdown, error) {
+	rules, err := r.taxRepo.WithContext(ctx).FindApplicable(address.Country, address.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	// a region's own rate overrides the country-wide one for the same class
+	rates := make(map[string]int)
+	for _, rule := range rules {
+		if _, ok := rates[rule.TaxClass]; ok && rule.Region == "" {
+			continue
+		}
+		rates[rule.TaxClass] = rule.Rate
+	}
+
+	breakdown := &TaxBreakdown{Inclusive: r.mode == models.TaxModeInclusive}
+	for _, line := range lines {
+		taxClass := line.TaxClass
+		if taxClass == "" {
+			taxClass = models.DefaultTaxClass
+		}
+		rate := rates[taxClass]
+
+		tax := line.Amount.Percent(int64(rate))
+		if breakdown.Inclusive {
+			tax = line.Amount.IncludedPercent(int64(rate))
+		}
+		if breakdown.Total, err = breakdown.Total.Add(tax); err != nil {
+			return nil, err
+		}
+		breakdown.Lines = append(breakdown.Lines, LineTax{Rate: rate, Amount: tax})
+	}
+
+	return breakdown, nil
+}
+
+type TaxClient interface {
+	CreateTaxRule(input dtos.TaxRuleRequest) (*models.TaxRule, *common.RestErr)
+	ListTaxRules(country string) ([]models.TaxRule, *common.RestErr)
+	UpdateTaxRule(ruleID uint, input dtos.UpdateTaxRuleRequest) (*models.TaxRule, *common.RestErr)
+	DeleteTaxRule(ruleID uint) *common.RestErr
+}
+
+type TaxService struct {
+	taxRepo *repositories.TaxRepository
+	restErr *common.RestErr
+}
+
+func NewTaxService(
+	taxRepo *repositories.TaxRepository,
+	restErr *common.RestErr,
+) TaxClient {
+	return &TaxService{
+		taxRepo,
+		restErr,
+	}
+}
+
+func (t *TaxService) CreateTaxRule(input dtos.TaxRuleRequest) (*models.TaxRule, *common.RestErr) {
+	rule := models.TaxRule{
+		Name:     input.Name,
+		Country:  strings.ToUpper(input.Country),
+		Region:   strings.ToUpper(input.Region),
+		TaxClass: strings.ToLower(input.TaxClass),
+		Rate:     int(math.Round(input.Rate * 100)),
+	}
+	if rule.TaxClass == "" {
+		rule.TaxClass = models.DefaultTaxClass
+	}
+
+	existing, err := t.taxRepo.FindByScope(rule.Country, rule.Region, rule.TaxClass)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, t.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if existing != nil {
+		return nil, t.restErr.BadRequest(common.ErrTaxRuleExists)
+	}
+
+	if err := t.taxRepo.Create(&rule); err != nil {
+		log.Error(zap.Error(err))
+		return nil, t.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return &rule, nil
+}
+
+func (t *TaxService) ListTaxRules(country string) ([]models.TaxRule, *common.RestErr) {
+	rules, err := t.taxRepo.List(strings.ToUpper(country))
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, t.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return rules, nil
+}
+
+func (t *TaxService) UpdateTaxRule(ruleID uint, input dtos.UpdateTaxRuleRequest) (*models.TaxRule, *common.RestErr) {
+	rule, err := t.taxRepo.FindByID(ruleID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, t.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if rule == nil {
+		return nil, t.restErr.NotFound(common.ErrTaxRuleNotFound)
+	}
+
+	if input.Name != "" {
+		rule.Name = input.Name
+	}
+	if input.Rate != nil {
+		rule.Rate = int(math.Round(*input.Rate * 100))
+	}
+
+	if err := t.taxRepo.Update(rule); err != nil {
+		log.Error(zap.Error(err))
+		return nil, t.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return rule, nil
+}
+
+func (t *TaxService) DeleteTaxRule(ruleID uint) *common.RestErr {
+	if err := t.taxRepo.Delete(ruleID); err != nil {
+		log.Error(zap.Error(err))
+		return t.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+
 	"github.com/gofiber/fiber/v2/log"
 	"go.uber.org/zap"
 	"instashop/internal/common"
@@ -9,16 +11,16 @@ import (
 )
 
 type UserClient interface {
-	GetUserDetails(userId uint) (*models.GetUser, *common.RestErr)
+	GetUserDetails(ctx context.Context, userId uint) (*models.GetUser, *common.RestErr)
 }
 
 type UserService struct {
-	userRepo *repositories.UserRepository
+	userRepo repositories.UserStore
 	restErr  *common.RestErr
 }
 
 func NewUserService(
-	userRepo *repositories.UserRepository,
+	userRepo repositories.UserStore,
 	restErr *common.RestErr,
 ) UserClient {
 	return &UserService{
@@ -27,8 +29,8 @@ func NewUserService(
 	}
 }
 
-func (u *UserService) GetUserDetails(userId uint) (*models.GetUser, *common.RestErr) {
-	user, exist, err := u.userRepo.FetchOne(models.User{ID: userId})
+func (u *UserService) GetUserDetails(ctx context.Context, userId uint) (*models.GetUser, *common.RestErr) {
+	user, exist, err := u.userRepo.WithContext(ctx).FetchOne(models.User{ID: userId})
 	if err != nil {
 		log.Error(zap.Error(err))
 		return nil, u.restErr.ServerError(common.ErrSomethingWentWrong)
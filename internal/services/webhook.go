@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"go.uber.org/zap"
+	"instashop/internal/common"
+	"instashop/internal/dtos"
+	"instashop/internal/events"
+	"instashop/internal/repositories"
+	"instashop/internal/utils"
+	"instashop/models"
+)
+
+type WebhookClient interface {
+	CreateWebhook(ctx context.Context, input dtos.CreateWebhookRequest) (*dtos.WebhookEndpointResponse, *common.RestErr)
+	ListWebhooks(ctx context.Context) ([]dtos.WebhookEndpointResponse, *common.RestErr)
+	GetWebhook(ctx context.Context, endpointID uint) (*dtos.WebhookEndpointResponse, *common.RestErr)
+	UpdateWebhook(ctx context.Context, endpointID uint, input dtos.UpdateWebhookRequest) (*dtos.WebhookEndpointResponse, *common.RestErr)
+	DeleteWebhook(ctx context.Context, endpointID uint) *common.RestErr
+	ListDeliveries(ctx context.Context, endpointID uint, input dtos.ListWebhookDeliveriesRequest) ([]dtos.WebhookDeliveryResponse, int64, *common.RestErr)
+	Redeliver(ctx context.Context, deliveryID uint) (*dtos.WebhookDeliveryResponse, *common.RestErr)
+}
+
+type WebhookService struct {
+	webhookRepo *repositories.WebhookRepository
+	restErr     *common.RestErr
+}
+
+func NewWebhookService(
+	webhookRepo *repositories.WebhookRepository,
+	restErr *common.RestErr,
+) WebhookClient {
+	return &WebhookService{
+		webhookRepo,
+		restErr,
+	}
+}
+
+func (w *WebhookService) CreateWebhook(ctx context.Context, input dtos.CreateWebhookRequest) (*dtos.WebhookEndpointResponse, *common.RestErr) {
+	if !knownEvents(input.Events) {
+		return nil, w.restErr.BadRequest(common.ErrUnknownWebhookEvent)
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, w.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	endpoint := models.WebhookEndpoint{
+		URL:         input.URL,
+		Description: input.Description,
+		Secret:      secret,
+		Events:      input.Events,
+		IsActive:    utils.BoolPointer(true),
+	}
+	if err := w.webhookRepo.WithContext(ctx).CreateEndpoint(&endpoint); err != nil {
+		log.Error(zap.Error(err))
+		return nil, w.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	response := toWebhookEndpointResponse(endpoint)
+	response.Secret = endpoint.Secret
+	return &response, nil
+}
+
+func (w *WebhookService) ListWebhooks(ctx context.Context) ([]dtos.WebhookEndpointResponse, *common.RestErr) {
+	endpoints, err := w.webhookRepo.WithContext(ctx).ListEndpoints()
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, w.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	responses := make([]dtos.WebhookEndpointResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		responses[i] = toWebhookEndpointResponse(endpoint)
+	}
+	return responses, nil
+}
+
+func (w *WebhookService) GetWebhook(ctx context.Context, endpointID uint) (*dtos.WebhookEndpointResponse, *common.RestErr) {
+	endpoint, srvErr := w.findEndpoint(ctx, endpointID)
+	if srvErr != nil {
+		return nil, srvErr
+	}
+
+	response := toWebhookEndpointResponse(*endpoint)
+	return &response, nil
+}
+
+func (w *WebhookService) UpdateWebhook(ctx context.Context, endpointID uint, input dtos.UpdateWebhookRequest) (*dtos.WebhookEndpointResponse, *common.RestErr) {
+	endpoint, srvErr := w.findEndpoint(ctx, endpointID)
+	if srvErr != nil {
+		return nil, srvErr
+	}
+
+	if input.URL != "" {
+		endpoint.URL = input.URL
+	}
+	if input.Description != nil {
+		endpoint.Description = *input.Description
+	}
+	if len(input.Events) > 0 {
+		if !knownEvents(input.Events) {
+			return nil, w.restErr.BadRequest(common.ErrUnknownWebhookEvent)
+		}
+		endpoint.Events = input.Events
+	}
+	if input.IsActive != nil {
+		endpoint.IsActive = input.IsActive
+	}
+	if input.RotateSecret {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			log.Error(zap.Error(err))
+			return nil, w.restErr.ServerError(common.ErrSomethingWentWrong)
+		}
+		endpoint.Secret = secret
+	}
+
+	if err := w.webhookRepo.WithContext(ctx).UpdateEndpoint(endpoint); err != nil {
+		log.Error(zap.Error(err))
+		return nil, w.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	response := toWebhookEndpointResponse(*endpoint)
+	if input.RotateSecret {
+		response.Secret = endpoint.Secret
+	}
+	return &response, nil
+}
+
+func (w *WebhookService) DeleteWebhook(ctx context.Context, endpointID uint) *common.RestErr {
+	if _, srvErr := w.findEndpoint(ctx, endpointID); srvErr != nil {
+		return srvErr
+	}
+
+	if err := w.webhookRepo.WithContext(ctx).DeleteEndpoint(endpointID); err != nil {
+		log.Error(zap.Error(err))
+		return w.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	return nil
+}
+
+func (w *WebhookService) ListDeliveries(ctx context.Context, endpointID uint, input dtos.ListWebhookDeliveriesRequest) ([]dtos.WebhookDeliveryResponse, int64, *common.RestErr) {
+	if _, srvErr := w.findEndpoint(ctx, endpointID); srvErr != nil {
+		return nil, 0, srvErr
+	}
+
+	deliveries, totalCount, err := w.webhookRepo.WithContext(ctx).ListDeliveries(endpointID, models.WebhookDeliveryStatus(input.Status), input.Page, input.PageSize)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, 0, w.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	responses := make([]dtos.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		responses[i] = toWebhookDeliveryResponse(delivery)
+	}
+	return responses, totalCount, nil
+}
+
+// Redeliver queues a delivery to be sent again on the next delivery run,
+// with a fresh set of retries, whatever the outcome of earlier attempts.
+func (w *WebhookService) Redeliver(ctx context.Context, deliveryID uint) (*dtos.WebhookDeliveryResponse, *common.RestErr) {
+	delivery, err := w.webhookRepo.WithContext(ctx).FindDeliveryByID(deliveryID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, w.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if delivery == nil {
+		return nil, w.restErr.NotFound(common.ErrWebhookDeliveryNotFound)
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.LastError = ""
+	if err := w.webhookRepo.WithContext(ctx).SaveAttempt(delivery); err != nil {
+		log.Error(zap.Error(err))
+		return nil, w.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+
+	response := toWebhookDeliveryResponse(*delivery)
+	return &response, nil
+}
+
+func (w *WebhookService) findEndpoint(ctx context.Context, endpointID uint) (*models.WebhookEndpoint, *common.RestErr) {
+	endpoint, err := w.webhookRepo.WithContext(ctx).FindEndpointByID(endpointID)
+	if err != nil {
+		log.Error(zap.Error(err))
+		return nil, w.restErr.ServerError(common.ErrSomethingWentWrong)
+	}
+	if endpoint == nil {
+		return nil, w.restErr.NotFound(common.ErrWebhookNotFound)
+	}
+	return endpoint, nil
+}
+
+func knownEvents(names []string) bool {
+	for _, name := range names {
+		known := name == models.WebhookAllEvents
+		for _, eventName := range events.Names {
+			known = known || name == eventName
+		}
+		if !known {
+			return false
+		}
+	}
+	return true
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+func toWebhookEndpointResponse(endpoint models.WebhookEndpoint) dtos.WebhookEndpointResponse {
+	return dtos.WebhookEndpointResponse{
+		ID:          endpoint.ID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		Events:      endpoint.Events,
+		IsActive:    endpoint.IsActive != nil && *endpoint.IsActive,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery models.WebhookDelivery) dtos.WebhookDeliveryResponse {
+	return dtos.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EndpointID:     delivery.EndpointID,
+		EventID:        delivery.EventID,
+		EventName:      delivery.EventName,
+		Payload:        delivery.Payload,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
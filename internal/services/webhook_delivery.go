@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"instashop/internal/events"
+	"instashop/internal/repositories"
+	"instashop/models"
+)
+
+const (
+	webhookBatchSize     = 50
+	webhookMaxAttempts   = 8
+	webhookBaseBackoff   = 30 * time.Second
+	webhookMaxBackoff    = 6 * time.Hour
+	webhookTimeout       = 10 * time.Second
+	webhookResponseLimit = 1024
+)
+
+// WebhookDispatcher turns outbox events into deliveries for the endpoints
+// that subscribe to them and sends those deliveries. Deliveries are queued
+// from the outbox directly, so webhooks go out whether or not the event
+// reached the broker.
+//
+// Each request is a POST of the event as JSON with these headers:
+//
+//	X-Instashop-Event:     the event name
+//	X-Instashop-Delivery:  the delivery ID, stable across retries
+//	X-Instashop-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256>
+//
+// The signature is computed with the endpoint's secret over the timestamp,
+// a ".", and the request body. Any 2xx response counts as delivered; other
+// outcomes are retried with exponential backoff up to webhookMaxAttempts.
+type WebhookDispatcher struct {
+	webhookRepo *repositories.WebhookRepository
+	outboxRepo  repositories.OutboxStore
+	client      *http.Client
+}
+
+func NewWebhookDispatcher(webhookRepo *repositories.WebhookRepository, outboxRepo repositories.OutboxStore) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookRepo,
+		outboxRepo,
+		&http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// QueueDeliveries creates a delivery to every active endpoint subscribed to
+// each outbox event that hasn't had its deliveries created, and returns how
+// many events it queued. A delivery that already exists is left alone, so
+// an event interrupted between the two steps is safely queued again.
+func (d *WebhookDispatcher) QueueDeliveries(ctx context.Context) (int, error) {
+	outboxRepo, webhookRepo := d.outboxRepo.WithContext(ctx), d.webhookRepo.WithContext(ctx)
+	unqueued, err := outboxRepo.FindWebhooksUnqueued(webhookBatchSize)
+	if err != nil || len(unqueued) == 0 {
+		return 0, err
+	}
+	endpoints, err := webhookRepo.ListActiveEndpoints()
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, event := range unqueued {
+		if ctx.Err() != nil {
+			return queued, ctx.Err()
+		}
+		deliveries, err := webhookDeliveries(endpoints, event)
+		if err != nil {
+			return queued, err
+		}
+		if err := webhookRepo.AddDeliveries(deliveries); err != nil {
+			return queued, err
+		}
+		if err := outboxRepo.MarkWebhooksQueued(event.ID, time.Now()); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+func webhookDeliveries(endpoints []models.WebhookEndpoint, event models.OutboxEvent) ([]models.WebhookDelivery, error) {
+	body, err := json.Marshal(events.Event{
+		ID:         event.ID,
+		Name:       event.Name,
+		OccurredAt: event.OccurredAt,
+		Payload:    json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(event.Name) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       event.ID,
+			EventName:     event.Name,
+			Payload:       body,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	return deliveries, nil
+}
+
+// DeliverDue sends the deliveries that are due and returns how many
+// succeeded.
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) (int, error) {
+	webhookRepo := d.webhookRepo.WithContext(ctx)
+	due, err := webhookRepo.FindDueDeliveries(time.Now(), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	endpoints := make(map[uint]*models.WebhookEndpoint)
+	succeeded := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			return succeeded, ctx.Err()
+		}
+
+		delivery := &due[i]
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			if endpoint, err = webhookRepo.FindEndpointByID(delivery.EndpointID); err != nil {
+				return succeeded, err
+			}
+			endpoints[delivery.EndpointID] = endpoint
+		}
+
+		if endpoint == nil || endpoint.IsActive == nil || !*endpoint.IsActive {
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.LastError = "endpoint is disabled"
+		} else {
+			d.attempt(ctx, endpoint, delivery)
+		}
+		if delivery.Status == models.WebhookDeliverySucceeded {
+			succeeded++
+		}
+		if err := webhookRepo.SaveAttempt(delivery); err != nil {
+			return succeeded, err
+		}
+	}
+
+	return succeeded, nil
+}
+
+// attempt sends delivery once and records the outcome on it.
+func (d *WebhookDispatcher) attempt(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseStatus, delivery.ResponseBody, delivery.LastError = 0, "", ""
+
+	err := d.send(ctx, endpoint, delivery)
+	if err == nil {
+		now := time.Now()
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+}
+
+func (d *WebhookDispatcher) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "instashop-webhooks")
+	request.Header.Set("X-Instashop-Event", delivery.EventName)
+	request.Header.Set("X-Instashop-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	request.Header.Set("X-Instashop-Signature", fmt.Sprintf("t=%d,v1=%s", timestamp, signWebhook(endpoint.Secret, timestamp, delivery.Payload)))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, webhookResponseLimit))
+	delivery.ResponseStatus = response.StatusCode
+	delivery.ResponseBody = strings.ToValidUTF8(string(body), "")
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("endpoint responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff doubles the wait after each failed attempt, up to
+// webhookMaxBackoff.
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return delay
+}
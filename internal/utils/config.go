@@ -1,12 +1,17 @@
 package utils
 
 import (
-	"log"
-	"os"
-	"path/filepath"
-	"strconv"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
 
-	"github.com/joho/godotenv"
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
 )
 
 var (
@@ -14,21 +19,81 @@ var (
 	ConfigFactory = defaultConfig
 )
 
+// Config is the app's configuration. Each field is read from the
+// environment variable in its env tag, or from the same name in lower case
+// in the YAML config file; see LoadConfig for where values come from.
 type Config struct {
-	Port                        string
-	JWTSecretKey                string
-	DbHost                      string
-	ThirdPartyTnxServiceBaseURL string
-	DbPort                      int
-	DbUser                      string
-	DbPassword                  string
-	DbName                      string
-	RedisAddr                   string
-	MailUsername                string
-	MailPassword                string
-	RabbitmqServerURL           string
+	Profile                     string        `env:"APP_ENV" default:"prod"`
+	Port                        string        `env:"PORT" default:":3000"`
+	JWTSecretKey                string        `env:"JWT_SCECRET" required:"true"`
+	DbHost                      string        `env:"DB_HOST" required:"true"`
+	ThirdPartyTnxServiceBaseURL string        `env:"THIRD_PARTY_TNX_SERVICE_BASE_URL"`
+	DbPort                      int           `env:"DB_PORT" default:"5432"`
+	DbUser                      string        `env:"DB_USER" required:"true"`
+	DbPassword                  string        `env:"DB_PASSWORD"`
+	DbName                      string        `env:"DB_NAME" required:"true"`
+	RedisAddr                   string        `env:"REDIS_ADDR"`
+	RedisPassword               string        `env:"REDIS_PASSWORD"`
+	RedisDB                     int           `env:"REDIS_DB" default:"0"`
+	MailUsername                string        `env:"MAIL_USERNAME"`
+	MailPassword                string        `env:"MAIL_PASSWORD"`
+	RabbitmqServerURL           string        `env:"RABBITMQ_SERVER_URL"`
+	StoreCurrency               string        `env:"STORE_CURRENCY" default:"USD"`
+	TaxMode                     string        `env:"TAX_MODE" default:"exclusive"`
+	PendingOrderTTL             time.Duration `env:"PENDING_ORDER_TTL" default:"30m"`
+	OrderExpiryInterval         time.Duration `env:"ORDER_EXPIRY_INTERVAL" default:"1m"`
+	LowStockThreshold           int           `env:"LOW_STOCK_THRESHOLD" default:"5"`
+	EventsExchange              string        `env:"EVENTS_EXCHANGE" default:"instashop.events"`
+	OutboxRelayInterval         time.Duration `env:"OUTBOX_RELAY_INTERVAL" default:"5s"`
+	WebhookDeliveryInterval     time.Duration `env:"WEBHOOK_DELIVERY_INTERVAL" default:"5s"`
+	IdempotencyPurgeInterval    time.Duration `env:"IDEMPOTENCY_PURGE_INTERVAL" default:"1h"`
+	CacheTTL                    time.Duration `env:"CACHE_TTL" default:"5m"`
+	RateLimitLogin              string        `env:"RATE_LIMIT_LOGIN" default:"10/1m"`
+	RateLimitSignup             string        `env:"RATE_LIMIT_SIGNUP" default:"10/1h"`
+	RateLimitPlaceOrder         string        `env:"RATE_LIMIT_PLACE_ORDER" default:"20/1m"`
+	RequestTimeout              time.Duration `env:"REQUEST_TIMEOUT" default:"10s"`
+	ProxyHeader                 string        `env:"PROXY_HEADER"`
+	TrustedProxies              string        `env:"TRUSTED_PROXIES"`
+	AdminEmail                  string        `env:"ADMIN_EMAIL"`
+	AdminPassword               string        `env:"ADMIN_PASSWORD"`
+	SeedFixtures                string        `env:"SEED_FIXTURES" default:"none"`
+}
+
+// profileDefaults fill in settings a profile can run without setting
+// itself. Only dev gets a JWT secret; prod has no defaults at all, so
+// everything required must be configured.
+var profileDefaults = map[string]map[string]string{
+	ProfileDev: {
+		"JWT_SCECRET":   "dev-secret-do-not-use-in-production",
+		"DB_HOST":       "localhost",
+		"DB_USER":       "postgres",
+		"DB_NAME":       "instashop",
+		"SEED_FIXTURES": "demo",
+	},
+	ProfileTest: {
+		"DB_HOST":       "localhost",
+		"DB_USER":       "postgres",
+		"DB_NAME":       "instashop_test",
+		"SEED_FIXTURES": "test",
+	},
+	ProfileProd: {},
+}
+
+var rateLimitPattern = regexp.MustCompile(`^[1-9][0-9]*/(.+)$`)
+
+// InitConfig loads and validates the configuration, making it the one
+// GetConfig returns. Call it once at startup, before anything reads config.
+func InitConfig() error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	_config = config
+	return nil
 }
 
+// GetConfig returns the configuration, loading it through ConfigFactory if
+// InitConfig hasn't run.
 func GetConfig() Config {
 	if _config == nil {
 		_config = ConfigFactory()
@@ -38,25 +103,124 @@ func GetConfig() Config {
 }
 
 func defaultConfig() *Config {
-	dbPort, _ := strconv.Atoi(os.Getenv("DB_PORT"))
-	return &Config{
-		Port:         os.Getenv("PORT"),
-		JWTSecretKey: os.Getenv("JWT_SCECRET"),
-		DbHost:       os.Getenv("DB_HOST"),
-		DbPort:       dbPort,
-		DbUser:       os.Getenv("DB_USER"),
-		DbPassword:   os.Getenv("DB_PASSWORD"),
-		DbName:       os.Getenv("DB_NAME"),
-	}
-}
-
-func init() {
-	var (
-		dir, _   = os.Getwd()
-		basepath = filepath.Join(dir, ".env")
-	)
-	if err := godotenv.Load(basepath); err != nil {
-		log.Print("No .env file found")
+	config, err := LoadConfig()
+	if err != nil {
 		panic(err)
 	}
+	return config
+}
+
+// validate checks what can't be expressed with tags, adding problems to
+// errs.
+func (c *Config) validate(errs *ConfigError) {
+	if _, ok := profileDefaults[c.Profile]; !ok {
+		errs.add("APP_ENV", "must be one of dev, test or prod, got %q", c.Profile)
+	}
+	if c.Profile == ProfileProd && len(c.JWTSecretKey) < 32 {
+		errs.add("JWT_SCECRET", "must be at least 32 characters in prod")
+	}
+	if c.DbPort < 1 || c.DbPort > 65535 {
+		errs.add("DB_PORT", "must be between 1 and 65535, got %d", c.DbPort)
+	}
+	if len(c.StoreCurrency) != 3 {
+		errs.add("STORE_CURRENCY", "must be a 3-letter currency code, got %q", c.StoreCurrency)
+	}
+	if c.TaxMode != "exclusive" && c.TaxMode != "inclusive" {
+		errs.add("TAX_MODE", "must be exclusive or inclusive, got %q", c.TaxMode)
+	}
+	if c.AdminEmail != "" && len(c.AdminPassword) < 8 {
+		errs.add("ADMIN_PASSWORD", "must be at least 8 characters when ADMIN_EMAIL is set")
+	}
+	if c.AdminPassword != "" && c.AdminEmail == "" {
+		errs.add("ADMIN_EMAIL", "must be set when ADMIN_PASSWORD is")
+	}
+	if c.ProxyHeader != "" && c.TrustedProxies == "" {
+		errs.add("TRUSTED_PROXIES", "must list the proxies allowed to set PROXY_HEADER")
+	}
+	for _, proxy := range c.TrustedProxyList() {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs.add("TRUSTED_PROXIES", "must be IP addresses or CIDR ranges, got %q", proxy)
+			}
+		}
+	}
+	if c.LowStockThreshold < 0 {
+		errs.add("LOW_STOCK_THRESHOLD", "must not be negative")
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"PENDING_ORDER_TTL", c.PendingOrderTTL},
+		{"ORDER_EXPIRY_INTERVAL", c.OrderExpiryInterval},
+		{"OUTBOX_RELAY_INTERVAL", c.OutboxRelayInterval},
+		{"WEBHOOK_DELIVERY_INTERVAL", c.WebhookDeliveryInterval},
+		{"IDEMPOTENCY_PURGE_INTERVAL", c.IdempotencyPurgeInterval},
+		{"CACHE_TTL", c.CacheTTL},
+		{"REQUEST_TIMEOUT", c.RequestTimeout},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			errs.add(d.name, "must be a positive duration")
+		}
+	}
+
+	rateLimits := []struct {
+		name  string
+		value string
+	}{
+		{"RATE_LIMIT_LOGIN", c.RateLimitLogin},
+		{"RATE_LIMIT_SIGNUP", c.RateLimitSignup},
+		{"RATE_LIMIT_PLACE_ORDER", c.RateLimitPlaceOrder},
+	}
+	for _, limit := range rateLimits {
+		match := rateLimitPattern.FindStringSubmatch(limit.value)
+		if match == nil {
+			errs.add(limit.name, "must be <requests>/<period>, e.g. 10/1m, got %q", limit.value)
+			continue
+		}
+		if period, err := time.ParseDuration(match[1]); err != nil || period <= 0 {
+			errs.add(limit.name, "period must be a positive duration, got %q", match[1])
+		}
+	}
+}
+
+// TrustedProxyList splits TRUSTED_PROXIES, a comma-separated list of IP
+// addresses and CIDR ranges.
+func (c Config) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// normalize puts values into the form the rest of the app expects.
+func (c *Config) normalize() {
+	c.Profile = strings.ToLower(c.Profile)
+	c.StoreCurrency = strings.ToUpper(c.StoreCurrency)
+	c.TaxMode = strings.ToLower(c.TaxMode)
+	c.SeedFixtures = strings.ToLower(c.SeedFixtures)
+}
+
+// ConfigError lists everything wrong with the configuration, so it can all
+// be fixed in one go. Only the first problem with each setting is kept.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) add(name, format string, args ...interface{}) {
+	for _, problem := range e.Problems {
+		if strings.HasPrefix(problem, name+": ") {
+			return
+		}
+	}
+	e.Problems = append(e.Problems, name+": "+fmt.Sprintf(format, args...))
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
 }
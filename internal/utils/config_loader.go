@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// configSource looks up one setting by its environment variable name.
+type configSource func(name string) (string, bool)
+
+// LoadConfig builds the configuration for the profile named by APP_ENV
+// (prod when unset, so local defaults such as dev's JWT secret only apply
+// when asked for). Each setting takes the first value found in:
+//
+//  1. the process environment
+//  2. .env.<profile>, then .env, in the working directory
+//  3. the YAML file named by CONFIG_FILE, or config.<profile>.yaml or
+//     config.yaml in the working directory
+//  4. the profile's defaults
+//  5. the default in the field's tag
+//
+// Missing files are skipped. Every problem found is reported together in a
+// *ConfigError.
+func LoadConfig() (*Config, error) {
+	sources := []configSource{os.LookupEnv}
+
+	dotenv, err := readDotenv(".env")
+	if err != nil {
+		return nil, err
+	}
+	profile := firstValue([]configSource{os.LookupEnv, mapSource(dotenv)}, "APP_ENV", ProfileProd)
+	profile = strings.ToLower(profile)
+
+	profileDotenv, err := readDotenv(".env." + profile)
+	if err != nil {
+		return nil, err
+	}
+	sources = append(sources, mapSource(profileDotenv), mapSource(dotenv))
+
+	configFile := firstValue(sources, "CONFIG_FILE", "")
+	yamlValues, err := readYAMLConfig(configFile, profile)
+	if err != nil {
+		return nil, err
+	}
+	sources = append(sources, mapSource(yamlValues), mapSource(profileDefaults[profile]))
+
+	var config Config
+	errs := &ConfigError{}
+	populate(&config, sources, errs)
+	config.normalize()
+	config.validate(errs)
+	if len(errs.Problems) > 0 {
+		return nil, errs
+	}
+	return &config, nil
+}
+
+// populate sets each tagged field of config from the first source that has
+// it, or its default, recording missing required fields and unparsable
+// values in errs.
+func populate(config *Config, sources []configSource, errs *ConfigError) {
+	value := reflect.ValueOf(config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		raw := firstValue(sources, name, field.Tag.Get("default"))
+		if raw == "" {
+			if field.Tag.Get("required") == "true" {
+				errs.add(name, "is required")
+			}
+			continue
+		}
+		if err := setField(value.Field(i), raw); err != nil {
+			errs.add(name, "%v", err)
+		}
+	}
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(raw)
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", raw)
+		}
+		field.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		field.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 5m", raw)
+		}
+		field.SetInt(int64(d))
+	default:
+		return fmt.Errorf("unsupported config field type %s", field.Type())
+	}
+	return nil
+}
+
+func firstValue(sources []configSource, name, fallback string) string {
+	for _, source := range sources {
+		if value, ok := source(name); ok && value != "" {
+			return value
+		}
+	}
+	return fallback
+}
+
+func mapSource(values map[string]string) configSource {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func readDotenv(path string) (map[string]string, error) {
+	values, err := godotenv.Read(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// readYAMLConfig reads a flat YAML mapping whose keys are the environment
+// variable names in lower case, e.g. db_host. An explicitly named file must
+// exist; the profile's default files are optional.
+func readYAMLConfig(path, profile string) (map[string]string, error) {
+	candidates := []string{path}
+	if path == "" {
+		candidates = []string{"config." + profile + ".yaml", "config.yaml"}
+	}
+
+	for _, candidate := range candidates {
+		data, err := os.ReadFile(candidate)
+		if errors.Is(err, os.ErrNotExist) && path == "" {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", candidate, err)
+		}
+
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", candidate, err)
+		}
+		values := make(map[string]string, len(raw))
+		for key, value := range raw {
+			if value != nil {
+				values[strings.ToUpper(key)] = fmt.Sprint(value)
+			}
+		}
+		return values, nil
+	}
+	return nil, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator"
@@ -31,6 +32,20 @@ func GenerateToken(JWTSecretKey, email string, id uint, role string) (signedToke
 	return
 }
 
+// Excerpt shortens s to at most max runes, cutting at a word boundary.
+func Excerpt(s string, max int) string {
+	s = strings.TrimSpace(s)
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	cut := string(runes[:max])
+	if i := strings.LastIndexAny(cut, " \t\n"); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + "…"
+}
+
 func BoolPointer(b bool) *bool {
 	return &b
 }
@@ -15,13 +15,13 @@ import (
 )
 
 type AuthValidator struct {
-	userRepo *repositories.UserRepository
+	userRepo repositories.UserStore
 	restErr  *common.RestErr
 	validate *validator.Validate
 }
 
 func NewAuthValidator(
-	userRepo *repositories.UserRepository,
+	userRepo repositories.UserStore,
 	restErr *common.RestErr,
 ) *AuthValidator {
 	return &AuthValidator{
@@ -0,0 +1,57 @@
+package validators
+
+import (
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"instashop/internal/dtos"
+)
+
+type CouponValidator struct {
+	validate *validator.Validate
+}
+
+func NewCouponValidator() *CouponValidator {
+	return &CouponValidator{validate: validator.New()}
+}
+
+func (v *CouponValidator) ValidateCreateCoupon(c *fiber.Ctx) error {
+	var input dtos.CreateCouponRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
+
+func (v *CouponValidator) ValidateUpdateCoupon(c *fiber.Ctx) error {
+	var input dtos.UpdateCouponRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
@@ -0,0 +1,59 @@
+package validators
+
+import (
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"instashop/internal/dtos"
+)
+
+type CurrencyValidator struct {
+	validate *validator.Validate
+}
+
+func NewCurrencyValidator() *CurrencyValidator {
+	return &CurrencyValidator{validate: validator.New()}
+}
+
+func (v *CurrencyValidator) ValidateCreateExchangeRate(c *fiber.Ctx) error {
+	var input dtos.CreateExchangeRateRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
+
+func (v *CurrencyValidator) ValidateSetProductPrices(c *fiber.Ctx) error {
+	var inputs []dtos.ProductPriceRequest
+	if err := c.BodyParser(&inputs); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	for _, input := range inputs {
+		if err := v.validate.Struct(input); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"message": "Validation failed",
+				"errors":  err.(validator.ValidationErrors),
+			})
+		}
+	}
+
+	c.Locals("input", inputs)
+	return c.Next()
+}
@@ -0,0 +1,36 @@
+package validators
+
+import (
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"instashop/internal/dtos"
+)
+
+type FulfillmentValidator struct {
+	validate *validator.Validate
+}
+
+func NewFulfillmentValidator() *FulfillmentValidator {
+	return &FulfillmentValidator{validate: validator.New()}
+}
+
+func (v *FulfillmentValidator) ValidateCreateFulfillment(c *fiber.Ctx) error {
+	var input dtos.CreateFulfillmentRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
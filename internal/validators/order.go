@@ -35,3 +35,45 @@ func (v *OrderValidator) ValidatePlaceOrder(c *fiber.Ctx) error {
 	c.Locals("input", input)
 	return c.Next()
 }
+
+func (v *OrderValidator) ValidateShippingQuote(c *fiber.Ctx) error {
+	var input dtos.ShippingQuoteRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
+
+func (v *OrderValidator) ValidateListOrders(c *fiber.Ctx) error {
+	input := dtos.ListOrdersRequest{Page: 1, PageSize: 10}
+	if err := c.QueryParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid query parameters",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
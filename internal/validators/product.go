@@ -57,3 +57,89 @@ func (v *ProductValidator) ValidateUpdateProduct(c *fiber.Ctx) error {
 	c.Locals("input", input)
 	return c.Next()
 }
+
+func (v *ProductValidator) ValidateCreateVariants(c *fiber.Ctx) error {
+	var inputs []dtos.CreateVariantRequest
+	if err := c.BodyParser(&inputs); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	for _, input := range inputs {
+		if err := v.validate.Struct(input); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"message": "Validation failed",
+				"errors":  err.(validator.ValidationErrors),
+			})
+		}
+	}
+
+	c.Locals("input", inputs)
+	return c.Next()
+}
+
+func (v *ProductValidator) ValidateUpdateVariant(c *fiber.Ctx) error {
+	var input dtos.UpdateVariantRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
+
+func (v *ProductValidator) ValidateSearchProducts(c *fiber.Ctx) error {
+	var input dtos.SearchProductsRequest
+	if err := c.QueryParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid query parameters",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
+
+func (v *ProductValidator) ValidateListProducts(c *fiber.Ctx) error {
+	input := dtos.ListProductsRequest{Page: 1, PageSize: 10}
+	if err := c.QueryParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid query parameters",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
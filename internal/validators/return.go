@@ -0,0 +1,78 @@
+package validators
+
+import (
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"instashop/internal/dtos"
+)
+
+type ReturnValidator struct {
+	validate *validator.Validate
+}
+
+func NewReturnValidator() *ReturnValidator {
+	return &ReturnValidator{validate: validator.New()}
+}
+
+func (v *ReturnValidator) ValidateCreateReturn(c *fiber.Ctx) error {
+	var input dtos.CreateReturnRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
+
+func (v *ReturnValidator) ValidateApproveReturn(c *fiber.Ctx) error {
+	var input dtos.ApproveReturnRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
+
+func (v *ReturnValidator) ValidateRejectReturn(c *fiber.Ctx) error {
+	var input dtos.RejectReturnRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := v.validate.Struct(input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Validation failed",
+			"errors":  err.(validator.ValidationErrors),
+		})
+	}
+
+	c.Locals("input", input)
+	return c.Next()
+}
@@ -2,73 +2,90 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	db "instashop/database"
 	"instashop/internal/utils"
-	"instashop/router"
 )
 
-func main() {
-	err := db.ConnectToPgDB(
-		utils.GetConfig().DbHost,
-		utils.GetConfig().DbUser,
-		utils.GetConfig().DbPassword,
-		utils.GetConfig().DbName,
-		utils.GetConfig().DbPort,
-	)
-	if err != nil {
-		panic(err)
-	}
-
-	app := fiber.New()
-	app.Use(cors.New())
-
-	loggerSettings := logger.New(logger.Config{
-		Format: "[${ip}]:${port} ${status} - ${method} ${path}\n",
-	})
-	app.Use(loggerSettings)
-
-	router.Routes(app, db.Client)
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
 
-	db.Migrate(db.Client)
+var commands = []command{
+	{"serve", "run the API server and background jobs (the default)", runServe},
+	{"migrate", "apply, roll back or list schema migrations", runMigrate},
+	{"seed", "create the configured admin and load a fixture set", runSeed},
+	{"create-admin", "create an admin user", runCreateAdmin},
+	{"reset-password", "set a user's password", runResetPassword},
+	{"export-catalog", "write every product and variant as YAML", runExportCatalog},
+	{"import-catalog", "create or update products from a YAML or JSON file", runImportCatalog},
+}
 
-	if err := db.StartSeeder(db.Client); err != nil {
-		fmt.Printf("Failed to seed data: %v\n", err)
+func main() {
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	app.Use(func(c *fiber.Ctx) error {
-		return c.SendStatus(404)
-	})
-
-	port := utils.GetConfig().Port
-
-	idleConnsClosed := make(chan struct{})
-	go func() {
-		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
-		<-sigint
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		if name != "help" {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		}
+		usage()
+		os.Exit(2)
+	}
 
-		if err := app.Shutdown(); err != nil {
-			log.Printf("Error shutting down server: %v", err)
+	if err := utils.InitConfig(); err != nil {
+		log.Fatal(err)
+	}
+	if err := cmd.run(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
 		}
+		log.Fatal(err)
+	}
+}
 
-		close(idleConnsClosed)
-	}()
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: instashop <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run instashop <command> -h for a command's flags.")
+}
 
-	log.Printf("Starting server on port: %s", port)
-	if err := app.Listen(port); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Fatalf("Listen: %v", err)
+// newFlagSet returns the flag set for a command, with usage that names it.
+func newFlagSet(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: instashop %s %s\n", name, arguments)
+		fs.PrintDefaults()
 	}
+	return fs
+}
 
-	<-idleConnsClosed
-	log.Println("Server stopped gracefully")
+func connectDB() error {
+	return db.ConnectToPgDB(
+		utils.GetConfig().DbHost,
+		utils.GetConfig().DbUser,
+		utils.GetConfig().DbPassword,
+		utils.GetConfig().DbName,
+		utils.GetConfig().DbPort,
+	)
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	db "instashop/database"
+	"instashop/internal/utils"
+)
+
+func runMigrate(args []string) error {
+	fs := newFlagSet("migrate", "up | down [-steps n] [-confirm-drop-all] | to [-confirm-drop-all] <version> | status")
+	steps := fs.Int("steps", 1, "number of migrations down rolls back")
+	confirmDropAll := fs.Bool("confirm-drop-all", false, "allow rolling back the first migration, which drops every table")
+	if len(args) == 0 {
+		fs.Usage()
+		return fmt.Errorf("migrate needs an action")
+	}
+	action := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if err := connectDB(); err != nil {
+		return err
+	}
+	migrator, err := db.NewMigrator(db.Client, utils.GetConfig().StoreCurrency)
+	if err != nil {
+		return err
+	}
+	migrator.ConfirmDropAll = *confirmDropAll
+
+	switch action {
+	case "up":
+		count, err := migrator.Up()
+		fmt.Printf("Applied %d migration(s)\n", count)
+		return err
+	case "down":
+		if *steps < 1 {
+			return fmt.Errorf("-steps must be at least 1, got %d", *steps)
+		}
+		count, err := migrator.Down(*steps)
+		fmt.Printf("Rolled back %d migration(s)\n", count)
+		return dropAllHint(err)
+	case "to":
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return fmt.Errorf("migrate to needs a version")
+		}
+		version, err := strconv.ParseUint(fs.Arg(0), 10, 32)
+		if err != nil {
+			return fmt.Errorf("version must be a number, got %q", fs.Arg(0))
+		}
+		count, err := migrator.To(uint(version))
+		fmt.Printf("Ran %d migration(s)\n", count)
+		return dropAllHint(err)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+}
+
+func dropAllHint(err error) error {
+	if errors.Is(err, db.ErrDropsAllTables) {
+		return fmt.Errorf("%w: pass -confirm-drop-all", err)
+	}
+	return err
+}
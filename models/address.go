@@ -0,0 +1,11 @@
+package models
+
+// Address is a postal address. Country is an ISO 3166-1 alpha-2 code.
+type Address struct {
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string `gorm:"type:varchar(2)"`
+}
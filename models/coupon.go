@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type CouponType string
+
+const (
+	CouponTypePercentage   CouponType = "percentage"
+	CouponTypeFixed        CouponType = "fixed"
+	CouponTypeFreeShipping CouponType = "free_shipping"
+)
+
+// Coupon is a promotion code. PercentOff is in basis points (1250 = 12.5%)
+// and applies to percentage coupons; AmountOff applies to fixed coupons.
+// An empty ProductIDs and Categories scope makes the coupon store-wide.
+type Coupon struct {
+	ID           uint       `gorm:"primaryKey"`
+	Code         string     `gorm:"not null;uniqueIndex"`
+	Type         CouponType `gorm:"type:varchar(20);not null"`
+	PercentOff   int        `gorm:"not null;default:0"`
+	AmountOff    Money      `gorm:"embedded;embeddedPrefix:amount_off_"`
+	MinSpend     Money      `gorm:"embedded;embeddedPrefix:min_spend_"`
+	UsageLimit   *int       `gorm:"default:null"`
+	PerUserLimit *int       `gorm:"default:null"`
+	TimesUsed    int        `gorm:"not null;default:0"`
+	StartsAt     *time.Time `gorm:"default:null"`
+	EndsAt       *time.Time `gorm:"default:null"`
+	ProductIDs   []uint     `gorm:"serializer:json"`
+	Categories   []string   `gorm:"serializer:json"`
+	IsActive     *bool      `gorm:"default:true"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type CouponRedemption struct {
+	ID        uint      `gorm:"primaryKey"`
+	CouponID  uint      `gorm:"not null;index:idx_coupon_redemption_user"`
+	UserID    uint      `gorm:"not null;index:idx_coupon_redemption_user"`
+	OrderID   uint      `gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderDiscount is one line of an order's discount breakdown.
+type OrderDiscount struct {
+	ID        uint       `gorm:"primaryKey"`
+	OrderID   uint       `gorm:"not null;index"`
+	CouponID  uint       `gorm:"not null"`
+	Code      string     `gorm:"not null"`
+	Type      CouponType `gorm:"type:varchar(20);not null"`
+	Amount    Money      `gorm:"embedded;embeddedPrefix:amount_"`
+	CreatedAt time.Time  `json:"created_at"`
+}
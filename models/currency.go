@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ExchangeRate says one unit of BaseCurrency buys Rate units of
+// QuoteCurrency from EffectiveAt until a later rate for the pair takes over.
+type ExchangeRate struct {
+	ID            uint      `gorm:"primaryKey"`
+	BaseCurrency  string    `gorm:"type:varchar(3);not null;index:idx_exchange_rate_pair"`
+	QuoteCurrency string    `gorm:"type:varchar(3);not null;index:idx_exchange_rate_pair"`
+	Rate          float64   `gorm:"type:numeric(20,10);not null"`
+	EffectiveAt   time.Time `gorm:"not null;index:idx_exchange_rate_pair"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ProductPrice overrides a product's converted price in one currency.
+type ProductPrice struct {
+	ID        uint      `gorm:"primaryKey"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_product_price_currency"`
+	Currency  string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_product_price_currency"`
+	Amount    int64     `gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (p ProductPrice) Money() Money {
+	return Money{Amount: p.Amount, Currency: p.Currency}
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type FulfillmentStatus string
+
+const (
+	FulfillmentStatusShipped   FulfillmentStatus = "shipped"
+	FulfillmentStatusDelivered FulfillmentStatus = "delivered"
+)
+
+// Fulfillment is one shipment of some or all of an order's items. An order
+// shipped in several parcels has one Fulfillment per parcel.
+type Fulfillment struct {
+	ID             uint   `gorm:"primaryKey"`
+	OrderID        uint   `gorm:"not null;index"`
+	Carrier        string `gorm:"not null"`
+	TrackingNumber string `gorm:"index"`
+	TrackingURL    string
+	Status         FulfillmentStatus `gorm:"type:varchar(20);not null;default:'shipped'"`
+	Items          []FulfillmentItem `gorm:"foreignKey:FulfillmentID"`
+	ShippedAt      time.Time
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type FulfillmentItem struct {
+	ID            uint `gorm:"primaryKey"`
+	FulfillmentID uint `gorm:"not null;index"`
+	OrderItemID   uint `gorm:"not null;index"`
+	Quantity      int  `gorm:"not null"`
+}
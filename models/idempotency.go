@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers a request made with an Idempotency-Key header and
+// the response it got, so a retry can be answered without running it again.
+// A zero StatusCode means the first request is still being processed.
+type IdempotencyKey struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key          string `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key"`
+	Fingerprint  string `gorm:"type:varchar(64);not null"`
+	StatusCode   int    `gorm:"not null;default:0"`
+	ResponseBody []byte
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// DefaultCurrency is used for prices that don't name a currency.
+const DefaultCurrency = "USD"
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// zeroDecimalCurrencies have no minor unit, so amounts are whole units.
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true,
+	"KRW": true,
+	"VND": true,
+	"XAF": true,
+	"XOF": true,
+}
+
+// Money is an amount in the currency's minor unit (e.g. cents for USD, kobo
+// for NGN) so that arithmetic on prices is exact.
+type Money struct {
+	Amount   int64  `gorm:"not null;default:0" json:"amount"`
+	Currency string `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Add sums two amounts of the same currency. A zero Money with no currency
+// takes on the currency of o, so totals can start from Money{}.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency == "" {
+		m.Currency = o.Currency
+	}
+	if o.Currency != "" && o.Currency != m.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Percent returns basisPoints/10000 of m (750 = 7.5%), rounded half away
+// from zero to the minor unit.
+func (m Money) Percent(basisPoints int64) Money {
+	product := m.Amount * basisPoints
+	rounded := (abs64(product) + 5000) / 10000
+	if product < 0 {
+		rounded = -rounded
+	}
+	return Money{Amount: rounded, Currency: m.Currency}
+}
+
+// IncludedPercent returns the part of m that is a basisPoints charge already
+// included in it, i.e. m * bp / (10000 + bp), rounded half away from zero.
+func (m Money) IncludedPercent(basisPoints int64) Money {
+	product := m.Amount * basisPoints
+	denominator := 10000 + basisPoints
+	rounded := (2*abs64(product) + denominator) / (2 * denominator)
+	if product < 0 {
+		rounded = -rounded
+	}
+	return Money{Amount: rounded, Currency: m.Currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// MinorUnits is the number of decimal places the currency's minor unit has.
+func MinorUnits(currency string) int {
+	if zeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return 0
+	}
+	return 2
+}
+
+// ZeroDecimalCurrencies lists, in order, the currencies MinorUnits reports
+// no decimal places for.
+func ZeroDecimalCurrencies() []string {
+	currencies := make([]string, 0, len(zeroDecimalCurrencies))
+	for currency := range zeroDecimalCurrencies {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// String formats the amount in major units, e.g. "12.50 USD".
+func (m Money) String() string {
+	units := MinorUnits(m.Currency)
+	if units == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	scale := int64(1)
+	for i := 0; i < units; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/scale, units, amount%scale, m.Currency)
+}
+
+// Convert prices m in currency at rate, where rate is how many units of
+// currency one unit of m.Currency buys. The result is rounded half away from
+// zero to the target currency's minor unit.
+func (m Money) Convert(currency string, rate float64) Money {
+	currency = strings.ToUpper(currency)
+	amount := new(big.Rat).SetInt64(m.Amount)
+	amount.Mul(amount, new(big.Rat).SetFloat64(rate))
+
+	shift := MinorUnits(currency) - MinorUnits(m.Currency)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift > 0 {
+		amount.Mul(amount, scale)
+	} else if shift < 0 {
+		amount.Quo(amount, scale)
+	}
+
+	// round half away from zero: truncate (|x| + 1/2)
+	half := big.NewRat(1, 2)
+	negative := amount.Sign() < 0
+	amount.Abs(amount)
+	amount.Add(amount, half)
+	rounded := new(big.Int).Quo(amount.Num(), amount.Denom())
+	if negative {
+		rounded.Neg(rounded)
+	}
+	return Money{Amount: rounded.Int64(), Currency: currency}
+}
+
+func abs64(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
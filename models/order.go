@@ -7,27 +7,71 @@ import (
 type OrderStatus string
 
 const (
-	OrderStatusPending   OrderStatus = "pending"
-	OrderStatusCompleted OrderStatus = "completed"
-	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusPending          OrderStatus = "pending"
+	OrderStatusPartiallyShipped OrderStatus = "partially_shipped"
+	OrderStatusShipped          OrderStatus = "shipped"
+	OrderStatusDelivered        OrderStatus = "delivered"
+	OrderStatusCompleted        OrderStatus = "completed"
+	OrderStatusCancelled        OrderStatus = "cancelled"
 )
 
 type Order struct {
 	ID         uint        `gorm:"primaryKey"`
 	UserID     uint        `gorm:"not null"`
 	Status     OrderStatus `gorm:"type:varchar(20);default:'pending'"`
-	TotalPrice float64     `gorm:"not null"`
+	TotalPrice Money       `gorm:"embedded;embeddedPrefix:total_price_"`
 	Items      []OrderItem `gorm:"foreignKey:OrderID"`
 	CreatedAt  time.Time   `json:"created_at"`
 	UpdatedAt  time.Time   `json:"updated_at"`
+
+	// TotalPrice is Subtotal plus ShippingTotal less DiscountTotal, plus
+	// TaxTotal unless the order's prices were tax-inclusive.
+	Subtotal      Money           `gorm:"embedded;embeddedPrefix:subtotal_"`
+	DiscountTotal Money           `gorm:"embedded;embeddedPrefix:discount_total_"`
+	CouponCode    string          `gorm:"index"`
+	Discounts     []OrderDiscount `gorm:"foreignKey:OrderID"`
+
+	// ExchangeRate converted the store's BaseCurrency into the order's
+	// currency when the order was priced. It is 1, with no ExchangeRateID,
+	// when no line needed converting: same-currency orders and ones priced
+	// entirely from per-currency overrides.
+	BaseCurrency   string  `gorm:"type:varchar(3)"`
+	ExchangeRate   float64 `gorm:"type:numeric(20,10);not null;default:1"`
+	ExchangeRateID *uint
+
+	TaxTotal        Money   `gorm:"embedded;embeddedPrefix:tax_total_"`
+	TaxInclusive    bool    `gorm:"not null;default:false"`
+	ShippingAddress Address `gorm:"embedded;embeddedPrefix:shipping_"`
+
+	ShippingMethodID   *uint
+	ShippingMethodName string
+	ShippingTotal      Money `gorm:"embedded;embeddedPrefix:shipping_total_"`
 }
 
 type OrderItem struct {
 	ID        uint      `gorm:"primaryKey"`
 	OrderID   uint      `gorm:"not null"`
 	ProductID uint      `gorm:"not null"`
+	VariantID *uint     `gorm:"index"`
 	Quantity  int       `gorm:"not null"`
-	Price     float64   `gorm:"not null"`
+	Price     Money     `gorm:"embedded;embeddedPrefix:price_"`
+	Product   *Product  `gorm:"foreignKey:ProductID" json:",omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Snapshot of the product as it was at purchase time, so order history
+	// survives later renames and deletes.
+	ProductName        string `gorm:"not null;default:''"`
+	SKU                string
+	DescriptionExcerpt string
+	ImageURL           string
+
+	// TaxAmount is the tax on the whole line after discounts, at TaxRate
+	// basis points.
+	TaxAmount Money `gorm:"embedded;embeddedPrefix:tax_"`
+	TaxRate   int   `gorm:"not null;default:0"`
+
+	// DiscountAmount is the part of the order's coupon discount that came
+	// off the whole line.
+	DiscountAmount Money `gorm:"embedded;embeddedPrefix:discount_"`
 }
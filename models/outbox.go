@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending   OutboxStatus = "pending"
+	OutboxStatusPublished OutboxStatus = "published"
+	OutboxStatusFailed    OutboxStatus = "failed"
+)
+
+// OutboxEvent is a domain event recorded in the same transaction as the
+// change it describes. The relay publishes pending events to the broker,
+// retrying from NextAttemptAt until it succeeds or gives up.
+type OutboxEvent struct {
+	ID            uint         `gorm:"primaryKey"`
+	Name          string       `gorm:"type:varchar(100);not null;index"`
+	Payload       []byte       `gorm:"type:jsonb;not null"`
+	OccurredAt    time.Time    `gorm:"not null"`
+	Status        OutboxStatus `gorm:"type:varchar(20);not null;default:'pending';index:idx_outbox_due,priority:1"`
+	Attempts      int          `gorm:"not null;default:0"`
+	NextAttemptAt time.Time    `gorm:"not null;index:idx_outbox_due,priority:2"`
+	LastError     string
+	PublishedAt   *time.Time
+	// WebhooksQueuedAt is when the event's webhook deliveries were created.
+	// It is tracked apart from Status so webhooks don't wait on the broker.
+	WebhooksQueuedAt *time.Time `gorm:"index:idx_outbox_webhooks_unqueued,where:webhooks_queued_at IS NULL"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
@@ -1,13 +1,75 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProductStatus string
+
+const (
+	ProductStatusDraft    ProductStatus = "draft"
+	ProductStatusActive   ProductStatus = "active"
+	ProductStatusArchived ProductStatus = "archived"
+)
 
 type Product struct {
-	ID          uint      `gorm:"primaryKey"`
-	Name        string    `gorm:"not null"`
-	Description string    `gorm:"not null"`
-	Price       float64   `gorm:"not null"`
-	Stock       int       `gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"not null"`
+	Description string `gorm:"not null"`
+	Price       Money  `gorm:"embedded;embeddedPrefix:price_"`
+	Stock       int    `gorm:"not null"`
+	ImageURL    string
+	Category    string           `gorm:"index"`
+	Status      ProductStatus    `gorm:"type:varchar(20);default:'active';index"`
+	TaxClass    string           `gorm:"type:varchar(50);not null;default:'standard'"`
+	WeightGrams int              `gorm:"not null;default:0"`
+	LengthMM    int              `gorm:"not null;default:0"`
+	WidthMM     int              `gorm:"not null;default:0"`
+	HeightMM    int              `gorm:"not null;default:0"`
+	Variants    []ProductVariant `gorm:"foreignKey:ProductID" json:",omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt   `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// OptionType is a dimension a product can vary on, e.g. size or color.
+type OptionType struct {
+	ID        uint          `gorm:"primaryKey"`
+	Name      string        `gorm:"not null;uniqueIndex"`
+	Values    []OptionValue `gorm:"foreignKey:OptionTypeID"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type OptionValue struct {
+	ID           uint        `gorm:"primaryKey"`
+	OptionTypeID uint        `gorm:"not null;uniqueIndex:idx_option_type_value"`
+	Value        string      `gorm:"not null;uniqueIndex:idx_option_type_value"`
+	OptionType   *OptionType `gorm:"foreignKey:OptionTypeID" json:",omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// ProductVariant is a sellable combination of option values with its own SKU
+// and stock. PriceAmount, in the product's currency minor units, overrides
+// the parent product's price when set.
+type ProductVariant struct {
+	ID           uint           `gorm:"primaryKey"`
+	ProductID    uint           `gorm:"not null;index"`
+	SKU          string         `gorm:"not null;uniqueIndex:idx_product_variants_sku,where:deleted_at IS NULL"`
+	PriceAmount  *int64         `gorm:"default:null"`
+	Stock        int            `gorm:"not null"`
+	OptionValues []OptionValue  `gorm:"many2many:variant_option_values"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+func (v *ProductVariant) EffectivePrice(productPrice Money) Money {
+	if v.PriceAmount != nil {
+		return Money{Amount: *v.PriceAmount, Currency: productPrice.Currency}
+	}
+	return productPrice
 }
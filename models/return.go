@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+)
+
+// ReturnRequest is a customer's request to send back items from a delivered
+// order (an RMA). RefundAmount is set when the return is approved.
+type ReturnRequest struct {
+	ID           uint         `gorm:"primaryKey"`
+	OrderID      uint         `gorm:"not null;index"`
+	UserID       uint         `gorm:"not null;index"`
+	Status       ReturnStatus `gorm:"type:varchar(20);not null;default:'requested';index"`
+	Reason       string       `gorm:"not null"`
+	PhotoURLs    []string     `gorm:"serializer:json"`
+	AdminNote    string
+	RefundAmount Money        `gorm:"embedded;embeddedPrefix:refund_"`
+	Items        []ReturnItem `gorm:"foreignKey:ReturnRequestID"`
+	ResolvedAt   *time.Time
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ReturnItem is a quantity of one order item being returned. Restock says
+// whether the units went back into inventory when the return was approved.
+type ReturnItem struct {
+	ID              uint `gorm:"primaryKey"`
+	ReturnRequestID uint `gorm:"not null;index"`
+	OrderItemID     uint `gorm:"not null;index"`
+	Quantity        int  `gorm:"not null"`
+	Restock         bool `gorm:"not null;default:false"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+type ShippingMethodType string
+
+const (
+	// ShippingMethodFlat charges Rate per order.
+	ShippingMethodFlat ShippingMethodType = "flat"
+	// ShippingMethodWeightBased charges Rate plus PerKg for every started
+	// kilogram the order weighs.
+	ShippingMethodWeightBased ShippingMethodType = "weight_based"
+	// ShippingMethodFreeOverThreshold charges Rate unless the order subtotal
+	// reaches FreeOver.
+	ShippingMethodFreeOverThreshold ShippingMethodType = "free_over_threshold"
+)
+
+// ShippingZone groups the countries a set of shipping methods serves. A zone
+// with no countries is the fallback for destinations no other zone lists.
+type ShippingZone struct {
+	ID        uint             `gorm:"primaryKey"`
+	Name      string           `gorm:"not null;uniqueIndex"`
+	Countries []string         `gorm:"serializer:json"`
+	Methods   []ShippingMethod `gorm:"foreignKey:ZoneID"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+type ShippingMethod struct {
+	ID        uint               `gorm:"primaryKey"`
+	ZoneID    uint               `gorm:"not null;index"`
+	Name      string             `gorm:"not null"`
+	Type      ShippingMethodType `gorm:"type:varchar(30);not null"`
+	Rate      Money              `gorm:"embedded;embeddedPrefix:rate_"`
+	PerKg     Money              `gorm:"embedded;embeddedPrefix:per_kg_"`
+	FreeOver  Money              `gorm:"embedded;embeddedPrefix:free_over_"`
+	IsActive  *bool              `gorm:"default:true"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// DefaultTaxClass is the tax class of products that don't name one.
+const DefaultTaxClass = "standard"
+
+type TaxMode string
+
+const (
+	// TaxModeExclusive adds tax on top of catalog prices.
+	TaxModeExclusive TaxMode = "exclusive"
+	// TaxModeInclusive treats catalog prices as already including tax.
+	TaxModeInclusive TaxMode = "inclusive"
+)
+
+// TaxRule is the rate for one tax class in a country, or in a region of it.
+// An empty Region applies country-wide; a rule for the buyer's region takes
+// precedence. Rate is in basis points (2000 = 20%).
+type TaxRule struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"not null"`
+	Country   string    `gorm:"type:varchar(2);not null;uniqueIndex:idx_tax_rule_scope"`
+	Region    string    `gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_tax_rule_scope"`
+	TaxClass  string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_tax_rule_scope"`
+	Rate      int       `gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// WebhookAllEvents subscribes an endpoint to every event.
+const WebhookAllEvents = "*"
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookEndpoint is an integrator's URL that is sent the events it
+// subscribes to, signed with Secret.
+type WebhookEndpoint struct {
+	ID          uint      `gorm:"primaryKey"`
+	URL         string    `gorm:"not null"`
+	Description string    `gorm:"type:varchar(255)"`
+	Secret      string    `gorm:"type:varchar(100);not null" json:"-"`
+	Events      []string  `gorm:"serializer:json"`
+	IsActive    *bool     `gorm:"not null;default:true"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (w *WebhookEndpoint) Subscribes(eventName string) bool {
+	for _, name := range w.Events {
+		if name == WebhookAllEvents || name == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event to be sent to one endpoint, and the outcome
+// of the latest attempt. EventID is the outbox event's ID, so an event
+// relayed twice is still delivered once per endpoint.
+type WebhookDelivery struct {
+	ID             uint                  `gorm:"primaryKey"`
+	EndpointID     uint                  `gorm:"not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventID        uint                  `gorm:"not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventName      string                `gorm:"type:varchar(100);not null"`
+	Payload        []byte                `gorm:"type:jsonb;not null"`
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);not null;default:'pending';index:idx_webhook_delivery_due,priority:1"`
+	Attempts       int                   `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `gorm:"not null;index:idx_webhook_delivery_due,priority:2"`
+	ResponseStatus int
+	ResponseBody   string
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -19,4 +19,11 @@ func Routes(app *fiber.App, database *gorm.DB) {
 	routes.RegisterUserRoutes(router, database)
 	routes.RegisterOrderRoutes(router, database)
 	routes.RegisterProductRoutes(router, database)
+	routes.RegisterCurrencyRoutes(router, database)
+	routes.RegisterCouponRoutes(router, database)
+	routes.RegisterTaxRoutes(router, database)
+	routes.RegisterShippingRoutes(router, database)
+	routes.RegisterFulfillmentRoutes(router, database)
+	routes.RegisterReturnRoutes(router, database)
+	routes.RegisterWebhookRoutes(router, database)
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	db "instashop/database"
+	"instashop/internal/utils"
+)
+
+func runSeed(args []string) error {
+	config := utils.GetConfig()
+	fs := newFlagSet("seed", "[flags]")
+	fixtures := fs.String("fixtures", config.SeedFixtures,
+		fmt.Sprintf("fixture set to load: %s or none", strings.Join(db.FixtureSets(), ", ")))
+	skipAdmin := fs.Bool("skip-admin", false, "don't create the admin from ADMIN_EMAIL")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := connectDB(); err != nil {
+		return err
+	}
+	opts := db.SeedOptions{
+		AdminEmail:    config.AdminEmail,
+		AdminPassword: config.AdminPassword,
+		Fixtures:      strings.ToLower(*fixtures),
+		Currency:      config.StoreCurrency,
+	}
+	if *skipAdmin {
+		opts.AdminEmail = ""
+	}
+	return db.Seed(db.Client, opts)
+}
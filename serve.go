@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	db "instashop/database"
+	"instashop/internal/common"
+	"instashop/internal/events"
+	"instashop/internal/jobs"
+	"instashop/internal/middleware"
+	"instashop/internal/utils"
+	"instashop/router"
+)
+
+func runServe(args []string) error {
+	fs := newFlagSet("serve", "[flags]")
+	migrate := fs.Bool("migrate", true, "apply pending migrations before serving")
+	seed := fs.Bool("seed", true, "create the configured admin and fixtures before serving")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := connectDB(); err != nil {
+		return err
+	}
+
+	if *migrate {
+		if err := db.Migrate(db.Client, utils.GetConfig().StoreCurrency); err != nil {
+			return err
+		}
+	}
+	if *seed {
+		if err := db.StartSeeder(db.Client); err != nil {
+			log.Printf("Failed to seed data: %v", err)
+		}
+	}
+
+	// The proxy header is only read from requests sent by a trusted proxy, so
+	// the rate limiter's client IPs can't be forged.
+	app := fiber.New(fiber.Config{
+		ProxyHeader:             utils.GetConfig().ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          utils.GetConfig().TrustedProxyList(),
+		EnableIPValidation:      true,
+	})
+	app.Use(cors.New())
+
+	loggerSettings := logger.New(logger.Config{
+		Format: "[${ip}]:${port} ${status} - ${method} ${path}\n",
+	})
+	app.Use(loggerSettings)
+	app.Use(middleware.RequestTimeout(utils.GetConfig().RequestTimeout, common.NewRestErr()))
+
+	router.Routes(app, db.Client)
+
+	bus := events.NewBus()
+	bus.Subscribe(events.OrderExpired, events.LogEvent)
+
+	var publisher events.Publisher
+	if url := utils.GetConfig().RabbitmqServerURL; url != "" {
+		publisher = events.NewAMQPPublisher(url, utils.GetConfig().EventsExchange)
+	} else {
+		log.Print("RABBITMQ_SERVER_URL not set, domain events are only logged")
+		publisher = events.NewLogPublisher()
+	}
+	defer publisher.Close()
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobs.StartJobs(jobsCtx, db.Client, bus, publisher)
+
+	app.Use(func(c *fiber.Ctx) error {
+		return c.SendStatus(404)
+	})
+
+	port := utils.GetConfig().Port
+
+	idleConnsClosed := make(chan struct{})
+	go func() {
+		sigint := make(chan os.Signal, 1)
+		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+		<-sigint
+
+		stopJobs()
+		if err := app.Shutdown(); err != nil {
+			log.Printf("Error shutting down server: %v", err)
+		}
+
+		close(idleConnsClosed)
+	}()
+
+	log.Printf("Starting server on port: %s", port)
+	if err := app.Listen(port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	<-idleConnsClosed
+	log.Println("Server stopped gracefully")
+	return nil
+}